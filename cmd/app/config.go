@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func configCommand(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		usage()
+		return fmt.Errorf("usage: %s config print [--redacted]", appName)
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redacted := fs.Bool("redacted", false, "mask secrets in the output")
+	cfg, err := parseConfig(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	return cfg.Print(os.Stdout, *redacted)
+}
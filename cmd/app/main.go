@@ -1,17 +1,95 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/joho/godotenv"
 
 	"restservice/cmd/app/run"
+	"restservice/internal/config"
 )
 
+const appName = "restservice"
+
 func main() {
 	_ = godotenv.Load()
 
-	if err := run.Run(); err != nil {
+	if err := execute(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
 		log.Fatal(err)
 	}
 }
+
+// execute dispatches to a subcommand. Without one, the HTTP server is
+// started and any flags are treated as configuration overrides.
+func execute(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "serve":
+			return serve(args[1:])
+		case "config":
+			return configCommand(args[1:])
+		case "migrate":
+			return migrateCommand(args[1:])
+		case "subs":
+			return subsCommand(args[1:])
+		case "summary":
+			return summaryCommand(args[1:])
+		case "export":
+			return exportCommand(args[1:])
+		case "services":
+			return servicesCommand(args[1:])
+		case "help", "-h", "--help":
+			usage()
+			return nil
+		}
+	}
+	return serve(args)
+}
+
+func serve(args []string) error {
+	fs := flag.NewFlagSet(appName, flag.ContinueOnError)
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		usage()
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+	return run.Run(cfg)
+}
+
+// parseConfig binds the shared configuration flags to fs, parses args and
+// loads the effective configuration.
+func parseConfig(fs *flag.FlagSet, args []string) (*config.Config, error) {
+	flags := config.BindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return config.Load(flags)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s [command] [flags]
+
+Commands:
+  serve                  start the HTTP server (default)
+  config print           print the effective configuration
+  migrate up|down|status|version
+                         manage the database schema
+  subs list|get|create|delete
+                         inspect and edit subscriptions
+  summary                total cost of subscriptions for a period
+  export                 dump subscriptions as CSV or JSON
+  services normalize     rename subscriptions to canonical service names
+
+Run "%[1]s <command> -h" for command flags.
+`, appName)
+}
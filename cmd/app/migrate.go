@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"restservice/internal/config"
+	"restservice/internal/repo"
+	"restservice/internal/repo/sqliterepo"
+)
+
+func migrateCommand(args []string) error {
+	if len(args) == 0 {
+		usage()
+		return fmt.Errorf("usage: %s migrate up|down|status|version", appName)
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	cfg, err := parseConfig(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	if cfg.DBDriver == config.DriverMemory {
+		return errors.New("the memory driver has no migrations")
+	}
+
+	open, newMigrator := repo.Open, repo.NewMigrator
+	dsn := cfg.DSN
+	if cfg.DBDriver == config.DriverSQLite {
+		open, newMigrator = sqliterepo.Open, sqliterepo.NewMigrator
+		dsn = cfg.SQLitePath
+	}
+
+	db, err := open(dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		results, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate up: %w", err)
+		}
+		if len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		for _, res := range results {
+			fmt.Printf("applied %d %s (%s)\n", res.Source.Version, res.Source.Path, res.Duration.Round(time.Millisecond))
+		}
+	case "down":
+		res, err := migrator.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate down: %w", err)
+		}
+		fmt.Printf("rolled back %d %s (%s)\n", res.Source.Version, res.Source.Path, res.Duration.Round(time.Millisecond))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate status: %w", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tSOURCE")
+		for _, st := range statuses {
+			applied := "-"
+			if !st.AppliedAt.IsZero() {
+				applied = st.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", st.Source.Version, st.State, applied, st.Source.Path)
+		}
+		return tw.Flush()
+	case "version":
+		version, err := migrator.GetDBVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate version: %w", err)
+		}
+		fmt.Println(version)
+	default:
+		usage()
+		return fmt.Errorf("unknown migrate command %q", action)
+	}
+	return nil
+}
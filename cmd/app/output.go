@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"restservice/internal/entity"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+var subscriptionColumns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date"}
+
+// subscriptionRecord mirrors the API response so CLI output can be fed
+// back into scripts written against the HTTP API.
+type subscriptionRecord struct {
+	ID          string  `json:"id"`
+	ServiceName string  `json:"service_name"`
+	Price       int     `json:"price"`
+	UserID      string  `json:"user_id"`
+	StartDate   string  `json:"start_date"`
+	EndDate     *string `json:"end_date,omitempty"`
+}
+
+func toRecord(sub entity.Subscription) subscriptionRecord {
+	var endDate *string
+	if sub.EndDate != nil {
+		formatted := entity.FormatMonthYear(*sub.EndDate)
+		endDate = &formatted
+	}
+	return subscriptionRecord{
+		ID:          sub.ID.String(),
+		ServiceName: sub.ServiceName,
+		Price:       sub.Price,
+		UserID:      sub.UserID.String(),
+		StartDate:   entity.FormatMonthYear(sub.StartDate),
+		EndDate:     endDate,
+	}
+}
+
+func (r subscriptionRecord) row() []string {
+	endDate := ""
+	if r.EndDate != nil {
+		endDate = *r.EndDate
+	}
+	return []string{r.ID, r.ServiceName, strconv.Itoa(r.Price), r.UserID, r.StartDate, endDate}
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, expected table, json or csv", format)
+	}
+}
+
+func writeSubscriptions(w io.Writer, format string, subs []entity.Subscription) error {
+	records := make([]subscriptionRecord, 0, len(subs))
+	for _, sub := range subs {
+		records = append(records, toRecord(sub))
+	}
+
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(subscriptionColumns); err != nil {
+			return err
+		}
+		for _, rec := range records {
+			if err := cw.Write(rec.row()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		writeTableRow(tw, subscriptionColumns)
+		for _, rec := range records {
+			writeTableRow(tw, rec.row())
+		}
+		return tw.Flush()
+	}
+}
+
+func writeTableRow(w io.Writer, cells []string) {
+	for i, cell := range cells {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, cell)
+	}
+	fmt.Fprintln(w)
+}
+
+func writeTotal(w io.Writer, format string, total int) error {
+	switch format {
+	case formatJSON:
+		return json.NewEncoder(w).Encode(map[string]int{"total": total})
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"total"})
+		_ = cw.Write([]string{strconv.Itoa(total)})
+		cw.Flush()
+		return cw.Error()
+	default:
+		_, err := fmt.Fprintln(w, total)
+		return err
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+func TestWriteSubscriptions(t *testing.T) {
+	end := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	sub := entity.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Yandex, Plus",
+		Price:       400,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     &end,
+	}
+
+	var csvOut bytes.Buffer
+	if err := writeSubscriptions(&csvOut, formatCSV, []entity.Subscription{sub}); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected csv lines: %q", lines)
+	}
+	if want := sub.ID.String() + `,"Yandex, Plus",400,` + sub.UserID.String() + ",07-2025,09-2025"; lines[1] != want {
+		t.Fatalf("unexpected csv row: %s", lines[1])
+	}
+
+	var jsonOut bytes.Buffer
+	if err := writeSubscriptions(&jsonOut, formatJSON, []entity.Subscription{sub}); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	var records []subscriptionRecord
+	if err := json.Unmarshal(jsonOut.Bytes(), &records); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if len(records) != 1 || records[0].EndDate == nil || *records[0].EndDate != "09-2025" {
+		t.Fatalf("unexpected json records: %+v", records)
+	}
+}
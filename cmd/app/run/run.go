@@ -2,54 +2,242 @@ package run
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
 
 	"restservice/internal/config"
 	httpa "restservice/internal/http"
+	"restservice/internal/publisher"
+	"restservice/internal/ratelimit"
 	"restservice/internal/repo"
+	"restservice/internal/repo/cache"
+	"restservice/internal/repo/memory"
+	"restservice/internal/repo/pgxrepo"
+	"restservice/internal/repo/sqliterepo"
+	"restservice/internal/usecase/catalog"
 	"restservice/internal/usecase/subscription"
+	"restservice/internal/usecase/user"
+	"restservice/internal/usecase/webhook"
 )
 
 const (
-	defaultHTTPAddr    = ":8080"
 	serverReadTimeout  = 5 * time.Second
 	serverWriteTimeout = 10 * time.Second
 	serverIdleTimeout  = 60 * time.Second
 	shutdownTimeout    = 10 * time.Second
+	migrateTimeout     = 5 * time.Minute
 )
 
-func Run() error {
-	cfg := config.MustLoad()
-	if cfg.DSN == "" {
-		return errors.New("DSN env is empty")
+func Run(cfg *config.Config) error {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	if cfg.DBDriver != config.DriverPostgres && cfg.RateLimitBackend == config.RateLimitPostgres {
+		return errors.New("RATE_LIMIT_BACKEND=postgres needs DB_DRIVER=postgres")
+	}
+	if len(cfg.DBReplicaDSNs) > 0 && (cfg.DBDriver != config.DriverPostgres || cfg.DBPool != config.DBPoolStdlib) {
+		return errors.New("DB_REPLICA_DSNS needs DB_DRIVER=postgres and DB_POOL=stdlib")
 	}
 
-	db, err := repo.Open(cfg.DSN)
-	if err != nil {
-		return err
+	var (
+		db       *sql.DB
+		subRepo  subscription.Repository
+		userRepo user.Repository
+		uow      subscription.UnitOfWork
+		// workers run in the background until shutdown.
+		workers []func(ctx context.Context)
+	)
+	switch cfg.DBDriver {
+	case config.DriverMemory:
+		if cfg.SnapshotPath == "" {
+			mem := memory.NewSubscriptionRepo()
+			subRepo, userRepo, uow = mem, mem.Users(), mem.UnitOfWork()
+			break
+		}
+		mem, err := memory.Open(cfg.SnapshotPath)
+		if err != nil {
+			return err
+		}
+		// Deferred first, so it runs last: after the server has drained
+		// and nothing writes any more.
+		defer func() {
+			if err := mem.Save(cfg.SnapshotPath); err != nil {
+				logger.Error("save snapshot", "path", cfg.SnapshotPath, "error", err)
+			}
+		}()
+		if cfg.SnapshotInterval > 0 {
+			workers = append(workers, func(ctx context.Context) {
+				mem.SaveEvery(ctx, cfg.SnapshotPath, cfg.SnapshotInterval, logger)
+			})
+		}
+		subRepo, userRepo, uow = mem, mem.Users(), mem.UnitOfWork()
+	case config.DriverSQLite:
+		sqlite, err := sqliterepo.Open(cfg.SQLitePath)
+		if err != nil {
+			return err
+		}
+		defer sqlite.Close()
+
+		if cfg.MigrateOnStart {
+			if err := migrate(sqlite, sqliterepo.NewMigrator, logger); err != nil {
+				return err
+			}
+		}
+		subRepo = sqliterepo.NewSubscriptionRepo(sqlite)
+		userRepo = sqliterepo.NewUserRepo(sqlite)
+		uow = sqliterepo.NewUnitOfWork(sqlite)
+		logger.Info("sqlite keeps no outbox: domain events go to the event publisher only",
+			"event_publisher", cfg.EventPublisher)
+	default:
+		if cfg.DSN == "" {
+			return errors.New("DSN env is empty")
+		}
+		var err error
+		db, err = repo.Open(cfg.DSN)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if cfg.MigrateOnStart {
+			if err := migrate(db, repo.NewMigrator, logger); err != nil {
+				return err
+			}
+		}
+
+		var repoOpts []repo.SubscriptionOption
+		if len(cfg.DBReplicaDSNs) > 0 {
+			replicas, err := repo.OpenReplicas(cfg.DBReplicaDSNs, cfg.DBReplicaMaxLag, logger)
+			if err != nil {
+				return err
+			}
+			defer replicas.Close()
+			workers = append(workers, func(ctx context.Context) {
+				replicas.Run(ctx, cfg.DBReplicaCheckInterval)
+			})
+			repoOpts = append(repoOpts, repo.WithReplicas(replicas))
+		}
+
+		subRepo = repo.NewSubscriptionRepo(db, repoOpts...)
+		userRepo = repo.NewUserRepo(db)
+		uow = repo.NewUnitOfWork(db)
+		if cfg.DBPool == config.DBPoolPgxpool {
+			// Opened after migrating: statements are prepared on connect.
+			pool, err := pgxrepo.Open(context.Background(), cfg.DSN, cfg.DBHealthCheckPeriod)
+			if err != nil {
+				return err
+			}
+			defer pool.Close()
+			subRepo = pgxrepo.NewSubscriptionRepo(pool)
+			uow = pgxrepo.NewUnitOfWork(pool)
+		}
 	}
-	defer db.Close()
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	// The admin listener, if any, serves operational endpoints apart from
+	// the API and its middleware.
+	admin := http.NewServeMux()
+
+	if cfg.CacheSize > 0 {
+		cached := cache.NewSubscriptionRepo(subRepo, cfg.CacheSize, cfg.CacheTTL)
+		admin.Handle("GET /debug/cache", cached.StatsHandler())
+		if cfg.CacheStatsInterval > 0 {
+			workers = append(workers, func(ctx context.Context) {
+				cached.LogStats(ctx, cfg.CacheStatsInterval, logger)
+			})
+		}
+		subRepo = cached
+	}
+
+	var pubOpts []subscription.Option
+	switch cfg.EventPublisher {
+	case config.PublisherLog:
+		pubOpts = append(pubOpts, subscription.WithPublisher(publisher.NewLog(logger)))
+	case config.PublisherFile:
+		file, err := publisher.NewFile(cfg.EventFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		pubOpts = append(pubOpts, subscription.WithPublisher(file))
+	}
+	subOpts := append([]subscription.Option{subscription.WithUnitOfWork(uow)}, pubOpts...)
+
+	// The services catalog lives in Postgres; other drivers keep service
+	// names as given.
+	var catalogService *catalog.Service
+	if db != nil {
+		catalogService = catalog.NewService(repo.NewCatalogRepo(db), logger)
+		subOpts = append(subOpts, subscription.WithServiceResolver(catalogService))
+	}
+
+	subService := subscription.NewService(subRepo, logger, subOpts...)
+
+	opts := []httpa.Option{
+		httpa.WithCORS(httpa.CORSConfig{
+			AllowedOrigins:   cfg.CORSAllowedOrigins,
+			AllowedMethods:   cfg.CORSAllowedMethods,
+			AllowedHeaders:   cfg.CORSAllowedHeaders,
+			AllowCredentials: cfg.CORSAllowCredentials,
+			MaxAge:           cfg.CORSMaxAge,
+		}),
+	}
+	// A replica is read from while it lags at most DBReplicaMaxLag as of
+	// its last check, so after that plus one check interval it has seen
+	// the write. Without a lag limit there is no such bound, and clients
+	// send the header instead.
+	if len(cfg.DBReplicaDSNs) > 0 && cfg.DBReplicaMaxLag > 0 {
+		opts = append(opts, httpa.WithReadYourWrites(cfg.DBReplicaMaxLag+cfg.DBReplicaCheckInterval))
+	}
+	if limiter := newLimiter(cfg, db); limiter != nil {
+		opts = append(opts, httpa.WithRateLimit(limiter, httpa.RateLimits{
+			Default:        cfg.RateLimitDefault,
+			Read:           cfg.RateLimitRead,
+			Heavy:          cfg.RateLimitHeavy,
+			Routes:         cfg.RateLimitRoutes,
+			Key:            httpa.RateKey(cfg.RateLimitKey),
+			TrustedProxies: cfg.TrustedProxies,
+		}))
+	}
+
+	opts = append(opts, httpa.WithUsers(user.NewService(userRepo, logger)))
 
-	subRepo := repo.NewSubscriptionRepo(db)
-	subService := subscription.NewService(subRepo, logger)
-	handler := httpa.NewHandler(subService, logger)
+	// Webhooks and the event stream read the Postgres outbox; with other
+	// drivers their routes are not registered.
+	if db != nil {
+		stream := subscription.NewStream(repo.NewOutboxRepo(db), logger)
+		webhookRepo := repo.NewWebhookRepo(db)
+		// Ended subscriptions are marked on the primary in the same
+		// transaction as their events, whatever serves the API.
+		ended := subscription.NewService(repo.NewSubscriptionRepo(db), logger,
+			append([]subscription.Option{subscription.WithUnitOfWork(repo.NewUnitOfWork(db))}, pubOpts...)...)
+		dispatcher := webhook.NewDispatcher(webhookRepo, ended, logger, webhook.DispatcherConfig{
+			Interval:    cfg.WebhookInterval,
+			MaxAttempts: cfg.WebhookMaxAttempts,
+			Timeout:     cfg.WebhookTimeout,
+		})
+		opts = append(opts,
+			httpa.WithEventStream(stream),
+			httpa.WithWebhooks(webhook.NewService(webhookRepo, logger)),
+			httpa.WithCatalog(catalogService),
+		)
+		workers = append(workers, dispatcher.Run, stream.Run)
+	}
+	handler := httpa.NewHandler(subService, logger, opts...)
 
 	mux := http.NewServeMux()
 	handler.Register(mux)
 
 	server := &http.Server{
-		Addr:         defaultHTTPAddr,
+		Addr:         ":" + cfg.HTTPPort,
 		Handler:      mux,
 		ReadTimeout:  serverReadTimeout,
 		WriteTimeout: serverWriteTimeout,
@@ -59,10 +247,38 @@ func Run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	errChan := make(chan error, 1)
+	// Background workers stop with ctx. The event stream stopping also
+	// ends open SSE responses, which Shutdown would otherwise wait for.
+	var wg sync.WaitGroup
+	for _, run := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(ctx)
+		}()
+	}
+	defer func() {
+		stop()
+		wg.Wait()
+	}()
+
+	errChan := make(chan error, 2)
 	go func() {
 		errChan <- server.ListenAndServe()
 	}()
+	if cfg.AdminPort != "" {
+		adminServer := &http.Server{
+			Addr:         ":" + cfg.AdminPort,
+			Handler:      admin,
+			ReadTimeout:  serverReadTimeout,
+			WriteTimeout: serverWriteTimeout,
+			IdleTimeout:  serverIdleTimeout,
+		}
+		defer adminServer.Close()
+		go func() {
+			errChan <- adminServer.ListenAndServe()
+		}()
+	}
 
 	select {
 	case <-ctx.Done():
@@ -79,3 +295,33 @@ func Run() error {
 		return nil
 	}
 }
+
+func newLimiter(cfg *config.Config, db *sql.DB) ratelimit.Limiter {
+	switch cfg.RateLimitBackend {
+	case config.RateLimitMemory:
+		return ratelimit.NewMemory()
+	case config.RateLimitPostgres:
+		return ratelimit.NewPostgres(db)
+	default:
+		return nil
+	}
+}
+
+func migrate(db *sql.DB, newMigrator func(*sql.DB) (*goose.Provider, error), logger *slog.Logger) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrateTimeout)
+	defer cancel()
+
+	results, err := migrator.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate on start: %w", err)
+	}
+	for _, res := range results {
+		logger.Info("migration applied", "version", res.Source.Version, "duration", res.Duration)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"restservice/internal/config"
+	"restservice/internal/repo"
+	"restservice/internal/usecase/catalog"
+	"restservice/internal/usecase/subscription"
+)
+
+func servicesCommand(args []string) error {
+	if len(args) == 0 || args[0] != "normalize" {
+		usage()
+		return fmt.Errorf("usage: %s services normalize", appName)
+	}
+	return servicesNormalize(args[1:])
+}
+
+// servicesNormalize is a one-off migration of the service names stored
+// before the catalog existed. It can be run again after catalog changes.
+func servicesNormalize(args []string) error {
+	fs := flag.NewFlagSet("services normalize", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only print what would be renamed")
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if cfg.DBDriver != config.DriverPostgres {
+		return errors.New("the services catalog needs DB_DRIVER=postgres")
+	}
+
+	db, err := repo.Open(cfg.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	// Renames go through the subscription service, so they commit at once
+	// and their events reach the outbox like any other update.
+	renamer := subscription.NewService(repo.NewSubscriptionRepo(db), logger,
+		subscription.WithUnitOfWork(repo.NewUnitOfWork(db)))
+	renames, err := catalog.NewService(repo.NewCatalogRepo(db), logger).Normalize(context.Background(), renamer, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FROM\tTO\tSUBSCRIPTIONS")
+	var total int64
+	for _, r := range renames {
+		fmt.Fprintf(tw, "%q\t%q\t%d\n", r.From, r.To, r.Subscriptions)
+		total += r.Subscriptions
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	verb := "renamed"
+	if *dryRun {
+		verb = "would rename"
+	}
+	fmt.Printf("%s %d subscriptions under %d names\n", verb, total, len(renames))
+	return nil
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/config"
+	"restservice/internal/entity"
+	"restservice/internal/repo"
+	"restservice/internal/repo/memory"
+	"restservice/internal/repo/sqliterepo"
+	"restservice/internal/usecase/catalog"
+	"restservice/internal/usecase/subscription"
+)
+
+// errMemoryWrite refuses commands that would change a memory snapshot. A
+// running server keeps its own copy of the data and overwrites the file on
+// its next save, so such a change would be lost, or would drop the
+// server's changes if it was saved last.
+var errMemoryWrite = errors.New("the memory driver is read-only from the command line; change subscriptions through the API")
+
+// openService wires subscription.Service against the configured database;
+// write tells whether the command changes subscriptions. Service logs go
+// to stderr so they never mix with command output.
+func openService(cfg *config.Config, write bool) (*subscription.Service, func(), error) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	switch cfg.DBDriver {
+	case config.DriverMemory:
+		// Without a snapshot there is nothing to administer: the data lives
+		// in the server process.
+		if cfg.SnapshotPath == "" {
+			return nil, nil, errors.New("the memory driver needs MEMORY_SNAPSHOT_PATH")
+		}
+		if write {
+			return nil, nil, errMemoryWrite
+		}
+		mem, err := memory.Open(cfg.SnapshotPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return subscription.NewService(mem, logger), func() {}, nil
+	case config.DriverSQLite:
+		db, err := sqliterepo.Open(cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		service := subscription.NewService(sqliterepo.NewSubscriptionRepo(db), logger)
+		return service, func() { _ = db.Close() }, nil
+	}
+
+	db, err := repo.Open(cfg.DSN)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolver := catalog.NewService(repo.NewCatalogRepo(db), logger)
+	service := subscription.NewService(repo.NewSubscriptionRepo(db), logger, subscription.WithServiceResolver(resolver))
+	return service, func() { _ = db.Close() }, nil
+}
+
+func subsCommand(args []string) error {
+	if len(args) == 0 {
+		usage()
+		return fmt.Errorf("usage: %s subs list|get|create|delete", appName)
+	}
+
+	switch args[0] {
+	case "list":
+		return subsList(args[1:])
+	case "get":
+		return subsGet(args[1:])
+	case "create":
+		return subsCreate(args[1:])
+	case "delete":
+		return subsDelete(args[1:])
+	default:
+		usage()
+		return fmt.Errorf("unknown subs command %q", args[0])
+	}
+}
+
+// filterFlags are shared by every command that narrows subscriptions down
+// by user and service.
+type filterFlags struct {
+	userID      string
+	serviceName string
+}
+
+func bindFilterFlags(fs *flag.FlagSet) *filterFlags {
+	f := &filterFlags{}
+	fs.StringVar(&f.userID, "user-id", "", "filter by user id")
+	fs.StringVar(&f.serviceName, "service-name", "", "filter by service name")
+	return f
+}
+
+func (f *filterFlags) parse() (*uuid.UUID, *string, error) {
+	var (
+		uid  *uuid.UUID
+		name *string
+	)
+	if v := strings.TrimSpace(f.userID); v != "" {
+		parsed, err := uuid.Parse(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid user-id: %w", err)
+		}
+		uid = &parsed
+	}
+	if v := strings.TrimSpace(f.serviceName); v != "" {
+		name = &v
+	}
+	return uid, name, nil
+}
+
+func bindOutputFlag(fs *flag.FlagSet, def string) *string {
+	return fs.String("output", def, "output format: table, json or csv")
+}
+
+// splitID allows the id to be given before the flags, as in
+// "subs get <id> --output json".
+func splitID(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+func parseIDArg(fs *flag.FlagSet, id string) (uuid.UUID, error) {
+	if id == "" {
+		id = fs.Arg(0)
+	}
+	if id == "" {
+		return uuid.Nil, fmt.Errorf("usage: %s %s <id>", appName, fs.Name())
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid id: %w", err)
+	}
+	return parsed, nil
+}
+
+func subsList(args []string) error {
+	fs := flag.NewFlagSet("subs list", flag.ContinueOnError)
+	filter := bindFilterFlags(fs)
+	output := bindOutputFlag(fs, formatTable)
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*output); err != nil {
+		return err
+	}
+
+	uid, name, err := filter.parse()
+	if err != nil {
+		return err
+	}
+
+	service, closeDB, err := openService(cfg, false)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	items, err := service.List(context.Background(), subscription.ListFilter{UserID: uid, ServiceName: name})
+	if err != nil {
+		return err
+	}
+	return writeSubscriptions(os.Stdout, *output, items)
+}
+
+func subsGet(args []string) error {
+	id, args := splitID(args)
+	fs := flag.NewFlagSet("subs get", flag.ContinueOnError)
+	output := bindOutputFlag(fs, formatTable)
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*output); err != nil {
+		return err
+	}
+
+	subID, err := parseIDArg(fs, id)
+	if err != nil {
+		return err
+	}
+
+	service, closeDB, err := openService(cfg, false)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	sub, err := service.Get(context.Background(), subID)
+	if err != nil {
+		return err
+	}
+	return writeSubscriptions(os.Stdout, *output, []entity.Subscription{sub})
+}
+
+func subsCreate(args []string) error {
+	fs := flag.NewFlagSet("subs create", flag.ContinueOnError)
+	var (
+		serviceName = fs.String("service-name", "", "service name")
+		price       = fs.Int("price", 0, "monthly price in rubles; 0 takes the catalog default")
+		userID      = fs.String("user-id", "", "user id")
+		startDate   = fs.String("start-date", "", "start date, MM-YYYY")
+		endDate     = fs.String("end-date", "", "optional end date, MM-YYYY")
+		output      = bindOutputFlag(fs, formatTable)
+	)
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*output); err != nil {
+		return err
+	}
+
+	uid, err := uuid.Parse(strings.TrimSpace(*userID))
+	if err != nil {
+		return fmt.Errorf("invalid user-id: %w", err)
+	}
+	start, err := entity.ParseMonthYear(strings.TrimSpace(*startDate))
+	if err != nil {
+		return fmt.Errorf("invalid start-date: %w", err)
+	}
+	sub := entity.Subscription{
+		ServiceName: strings.TrimSpace(*serviceName),
+		Price:       *price,
+		UserID:      uid,
+		StartDate:   start,
+	}
+	if v := strings.TrimSpace(*endDate); v != "" {
+		end, err := entity.ParseMonthYear(v)
+		if err != nil {
+			return fmt.Errorf("invalid end-date: %w", err)
+		}
+		sub.EndDate = &end
+	}
+
+	// Validate before touching the database so bad input fails fast even
+	// when the database is unreachable.
+	if err := subscription.Validate(sub); err != nil {
+		return fmt.Errorf("%w: %s", subscription.ErrValidation, err)
+	}
+
+	service, closeDB, err := openService(cfg, true)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	created, err := service.Create(context.Background(), sub)
+	if err != nil {
+		return err
+	}
+	return writeSubscriptions(os.Stdout, *output, []entity.Subscription{created})
+}
+
+func subsDelete(args []string) error {
+	id, args := splitID(args)
+	fs := flag.NewFlagSet("subs delete", flag.ContinueOnError)
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	subID, err := parseIDArg(fs, id)
+	if err != nil {
+		return err
+	}
+
+	service, closeDB, err := openService(cfg, true)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if err := service.Delete(context.Background(), subID); err != nil {
+		return err
+	}
+	fmt.Printf("deleted %s\n", subID)
+	return nil
+}
+
+func summaryCommand(args []string) error {
+	fs := flag.NewFlagSet("summary", flag.ContinueOnError)
+	filter := bindFilterFlags(fs)
+	var (
+		startDate = fs.String("start-date", "", "period start, MM-YYYY")
+		endDate   = fs.String("end-date", "", "period end, MM-YYYY")
+		output    = bindOutputFlag(fs, formatTable)
+	)
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*output); err != nil {
+		return err
+	}
+
+	start, err := entity.ParseMonthYear(strings.TrimSpace(*startDate))
+	if err != nil {
+		return fmt.Errorf("invalid start-date: %w", err)
+	}
+	end, err := entity.ParseMonthYear(strings.TrimSpace(*endDate))
+	if err != nil {
+		return fmt.Errorf("invalid end-date: %w", err)
+	}
+	// The period covers the whole end month, as in the HTTP summary.
+	end = end.AddDate(0, 1, -1)
+	if end.Before(start) {
+		return fmt.Errorf("end-date before start-date")
+	}
+
+	uid, name, err := filter.parse()
+	if err != nil {
+		return err
+	}
+
+	service, closeDB, err := openService(cfg, false)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	total, err := service.Sum(context.Background(), subscription.SummaryFilter{
+		UserID:      uid,
+		ServiceName: name,
+		StartDate:   start,
+		EndDate:     end,
+	})
+	if err != nil {
+		return err
+	}
+	return writeTotal(os.Stdout, *output, total)
+}
+
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	filter := bindFilterFlags(fs)
+	var (
+		output = bindOutputFlag(fs, formatCSV)
+		file   = fs.String("file", "", "write to this file instead of stdout")
+	)
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*output); err != nil {
+		return err
+	}
+
+	uid, name, err := filter.parse()
+	if err != nil {
+		return err
+	}
+
+	service, closeDB, err := openService(cfg, false)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	items, err := service.List(context.Background(), subscription.ListFilter{UserID: uid, ServiceName: name})
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		err = writeSubscriptions(os.Stdout, *output, items)
+	} else {
+		err = writeExportFile(*file, *output, items)
+	}
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d subscriptions at %s\n", len(items), time.Now().UTC().Format(time.RFC3339))
+	return nil
+}
+
+// writeExportFile reports a failed close too: the last buffered write may
+// only fail there.
+func writeExportFile(path, format string, items []entity.Subscription) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create export file: %w", err)
+	}
+	if err := writeSubscriptions(f, format, items); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close export file: %w", err)
+	}
+	return nil
+}
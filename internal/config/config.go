@@ -1,12 +1,23 @@
 package config
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"net/netip"
+	"net/url"
 	"os"
+	"strings"
+	"time"
+
+	"restservice/internal/ratelimit"
 )
 
 const (
-	envHTTPPort = "HTTP_PORT"
+	envConfigFile = "CONFIG_FILE"
+
+	envHTTPPort  = "HTTP_PORT"
+	envAdminPort = "ADMIN_PORT"
 
 	envDBHost     = "DB_HOST"
 	envDBPort     = "DB_PORT"
@@ -14,47 +25,473 @@ const (
 	envDBPassword = "DB_PASSWORD"
 	envDBName     = "DB_NAME"
 	envDBSSLMode  = "DB_SSL_MODE"
+
+	envMigrateOnStart = "MIGRATE_ON_START"
+
+	envCORSAllowedOrigins   = "CORS_ALLOWED_ORIGINS"
+	envCORSAllowedMethods   = "CORS_ALLOWED_METHODS"
+	envCORSAllowedHeaders   = "CORS_ALLOWED_HEADERS"
+	envCORSAllowCredentials = "CORS_ALLOW_CREDENTIALS"
+	envCORSMaxAge           = "CORS_MAX_AGE"
+
+	envRateLimitBackend = "RATE_LIMIT_BACKEND"
+	envRateLimitDefault = "RATE_LIMIT_DEFAULT"
+	envRateLimitRead    = "RATE_LIMIT_READ"
+	envRateLimitHeavy   = "RATE_LIMIT_HEAVY"
+	envRateLimitRoutes  = "RATE_LIMIT_ROUTES"
+	envRateLimitKey     = "RATE_LIMIT_KEY"
+	envTrustedProxies   = "TRUSTED_PROXIES"
+
+	envWebhookInterval    = "WEBHOOK_DISPATCH_INTERVAL"
+	envWebhookMaxAttempts = "WEBHOOK_MAX_ATTEMPTS"
+	envWebhookTimeout     = "WEBHOOK_TIMEOUT"
+
+	envDBDriver          = "DB_DRIVER"
+	envSQLitePath        = "SQLITE_PATH"
+	envSnapshotPath      = "MEMORY_SNAPSHOT_PATH"
+	envSnapshotInterval  = "MEMORY_SNAPSHOT_INTERVAL"
+	envDBPool            = "DB_POOL"
+	envDBHealthCheckTime = "DB_HEALTH_CHECK_PERIOD"
+	envDBReplicaDSNs     = "DB_REPLICA_DSNS"
+	envDBReplicaInterval = "DB_REPLICA_CHECK_INTERVAL"
+	envDBReplicaMaxLag   = "DB_REPLICA_MAX_LAG"
+
+	envCacheSize          = "CACHE_SIZE"
+	envCacheTTL           = "CACHE_TTL"
+	envCacheStatsInterval = "CACHE_STATS_INTERVAL"
+
+	envEventPublisher = "EVENT_PUBLISHER"
+	envEventFile      = "EVENT_FILE"
+
+	// fileSuffix marks a variant of a setting whose value is read from the
+	// named file, e.g. DB_PASSWORD_FILE=/run/secrets/db_password.
+	fileSuffix = "_FILE"
+
+	redactedValue = "******"
+)
+
+// Rate limiter backends accepted by RATE_LIMIT_BACKEND.
+const (
+	RateLimitOff      = "off"
+	RateLimitMemory   = "memory"
+	RateLimitPostgres = "postgres"
+)
+
+// Client keys accepted by RATE_LIMIT_KEY.
+const (
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyAPIKey = "api_key"
+	RateLimitKeyUserID = "user_id"
+)
+
+// Storage backends accepted by DB_DRIVER.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Connection pools accepted by DB_POOL.
+const (
+	DBPoolStdlib  = "stdlib"
+	DBPoolPgxpool = "pgxpool"
+)
+
+// Domain event publishers accepted by EVENT_PUBLISHER.
+const (
+	PublisherOff  = "off"
+	PublisherLog  = "log"
+	PublisherFile = "file"
 )
 
 const (
 	defaultHTTPPort  = "8080"
+	defaultAdminPort = ""
 	defaultDBHost    = "db"
 	defaultDBPort    = "5432"
 	defaultDBUser    = "postgres"
 	defaultDBPass    = "postgres"
 	defaultDBName    = "subscriptions"
 	defaultDBSSLMode = "disable"
+
+	defaultMigrateOnStart = "false"
+
+	defaultCORSAllowedOrigins   = ""
+	defaultCORSAllowedMethods   = "GET,HEAD,POST,PUT,DELETE"
+	defaultCORSAllowedHeaders   = "Content-Type,Authorization"
+	defaultCORSAllowCredentials = "false"
+	defaultCORSMaxAge           = "10m"
+
+	defaultRateLimitBackend = RateLimitMemory
+	defaultRateLimitDefault = "100/m"
+	defaultRateLimitRead    = "300/m"
+	defaultRateLimitHeavy   = "20/m"
+	defaultRateLimitRoutes  = ""
+	defaultRateLimitKey     = RateLimitKeyIP
+	defaultTrustedProxies   = ""
+
+	defaultWebhookInterval    = "5s"
+	defaultWebhookMaxAttempts = "8"
+	defaultWebhookTimeout     = "10s"
+
+	defaultDBDriver          = DriverPostgres
+	defaultSQLitePath        = "subscriptions.db"
+	defaultSnapshotPath      = ""
+	defaultSnapshotInterval  = "1m"
+	defaultDBPool            = DBPoolStdlib
+	defaultDBHealthCheckTime = "1m"
+	defaultDBReplicaDSNs     = ""
+	defaultDBReplicaInterval = "10s"
+	defaultDBReplicaMaxLag   = "30s"
+
+	defaultCacheSize          = "0"
+	defaultCacheTTL           = "30s"
+	defaultCacheStatsInterval = "5m"
+
+	defaultEventPublisher = PublisherOff
+	defaultEventFile      = "events.jsonl"
 )
 
 type Config struct {
 	HTTPPort string
-	DSN      string
+	// AdminPort, when set, serves operational endpoints such as cache
+	// stats on a listener of its own, apart from the API.
+	AdminPort string
+
+	DBHost     string
+	DBPort     string
+	DBUser     string
+	DBPassword string
+	DBName     string
+	DBSSLMode  string
+
+	// MigrateOnStart applies pending migrations before the server starts.
+	MigrateOnStart bool
+
+	// CORSAllowedOrigins enables CORS for the listed origins; "*" allows
+	// any. An empty list leaves CORS off.
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	// RateLimitBackend is one of RateLimitOff, RateLimitMemory or
+	// RateLimitPostgres. The memory backend limits each replica on its own.
+	RateLimitBackend string
+	RateLimitDefault ratelimit.Limit
+	RateLimitRead    ratelimit.Limit
+	RateLimitHeavy   ratelimit.Limit
+	// RateLimitRoutes override the class limits of single routes, keyed by
+	// method and path without the version prefix.
+	RateLimitRoutes map[string]ratelimit.Limit
+	// RateLimitKey is RateLimitKeyIP, RateLimitKeyAPIKey or
+	// RateLimitKeyUserID. The header keys fall back to the client IP.
+	RateLimitKey string
+	// TrustedProxies are the addresses and networks whose X-Forwarded-For
+	// is believed when looking for the client IP.
+	TrustedProxies []netip.Prefix
+
+	// WebhookInterval is how often the dispatcher looks for new events and
+	// due retries. After WebhookMaxAttempts failures a delivery is dead.
+	WebhookInterval    time.Duration
+	WebhookMaxAttempts int
+	WebhookTimeout     time.Duration
+
+	// DBDriver is DriverPostgres, DriverSQLite or DriverMemory. SQLite
+	// keeps subscriptions in the file at SQLitePath. The memory driver
+	// keeps them in process and, when SnapshotPath is set, saves them there
+	// every SnapshotInterval and at shutdown; a zero interval saves only at
+	// shutdown. Neither has webhooks, the event stream or shared rate
+	// limits, which rely on Postgres.
+	DBDriver         string
+	SQLitePath       string
+	SnapshotPath     string
+	SnapshotInterval time.Duration
+
+	// DBPool is DBPoolStdlib for database/sql or DBPoolPgxpool for the
+	// native pgx pool, which then serves subscriptions and their outbox
+	// writes. DBHealthCheckPeriod is how often pgxpool checks idle
+	// connections.
+	DBPool              string
+	DBHealthCheckPeriod time.Duration
+
+	// DBReplicaDSNs are Postgres read replicas. Subscription reads go to
+	// them in turn while they pass health checks, run every
+	// DBReplicaCheckInterval, and to the primary otherwise. A replica more
+	// than DBReplicaMaxLag behind the primary fails the check; zero allows
+	// any lag. They need the stdlib pool.
+	DBReplicaDSNs          []string
+	DBReplicaCheckInterval time.Duration
+	DBReplicaMaxLag        time.Duration
+
+	// CacheSize is how many Get, List and Sum results are cached in
+	// process, each for CacheTTL; zero disables the cache. Cache hits and
+	// misses are logged every CacheStatsInterval.
+	CacheSize          int
+	CacheTTL           time.Duration
+	CacheStatsInterval time.Duration
+
+	// EventPublisher is one of PublisherOff, PublisherLog or PublisherFile.
+	// The file publisher appends JSON lines to EventFile.
+	EventPublisher string
+	EventFile      string
+
+	DSN string
 }
 
-func getenv(key, df string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// setting describes a single configuration value and every source it can
+// be read from. The file key is the lower-cased env name, so DB_HOST may be
+// written either as db_host or as host inside a db section.
+type setting struct {
+	env    string
+	flag   string
+	def    string
+	usage  string
+	secret bool
+	field  func(c *Config) flag.Value
+}
+
+var settings = []setting{
+	{env: envHTTPPort, flag: "http-port", def: defaultHTTPPort, usage: "HTTP listen port",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.HTTPPort) }},
+	{env: envAdminPort, flag: "admin-port", def: defaultAdminPort, usage: "admin listen port for /debug/cache; empty disables the admin listener",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.AdminPort) }},
+	{env: envDBHost, flag: "db-host", def: defaultDBHost, usage: "database host",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.DBHost) }},
+	{env: envDBPort, flag: "db-port", def: defaultDBPort, usage: "database port",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.DBPort) }},
+	{env: envDBUser, flag: "db-user", def: defaultDBUser, usage: "database user",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.DBUser) }},
+	{env: envDBPassword, flag: "db-password", def: defaultDBPass, usage: "database password", secret: true,
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.DBPassword) }},
+	{env: envDBName, flag: "db-name", def: defaultDBName, usage: "database name",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.DBName) }},
+	{env: envDBSSLMode, flag: "db-ssl-mode", def: defaultDBSSLMode, usage: "database sslmode",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.DBSSLMode) }},
+	{env: envMigrateOnStart, flag: "migrate-on-start", def: defaultMigrateOnStart, usage: "apply pending migrations on start",
+		field: func(c *Config) flag.Value { return (*boolValue)(&c.MigrateOnStart) }},
+	{env: envCORSAllowedOrigins, flag: "cors-allowed-origins", def: defaultCORSAllowedOrigins, usage: "comma-separated origins allowed by CORS, * for any",
+		field: func(c *Config) flag.Value { return (*listValue)(&c.CORSAllowedOrigins) }},
+	{env: envCORSAllowedMethods, flag: "cors-allowed-methods", def: defaultCORSAllowedMethods, usage: "comma-separated methods allowed by CORS",
+		field: func(c *Config) flag.Value { return (*listValue)(&c.CORSAllowedMethods) }},
+	{env: envCORSAllowedHeaders, flag: "cors-allowed-headers", def: defaultCORSAllowedHeaders, usage: "comma-separated request headers allowed by CORS, * for any",
+		field: func(c *Config) flag.Value { return (*listValue)(&c.CORSAllowedHeaders) }},
+	{env: envCORSAllowCredentials, flag: "cors-allow-credentials", def: defaultCORSAllowCredentials, usage: "allow credentialed CORS requests",
+		field: func(c *Config) flag.Value { return (*boolValue)(&c.CORSAllowCredentials) }},
+	{env: envCORSMaxAge, flag: "cors-max-age", def: defaultCORSMaxAge, usage: "how long browsers may cache preflight results",
+		field: func(c *Config) flag.Value { return (*durationValue)(&c.CORSMaxAge) }},
+	{env: envRateLimitBackend, flag: "rate-limit-backend", def: defaultRateLimitBackend, usage: "rate limiter backend: off, memory or postgres",
+		field: func(c *Config) flag.Value {
+			return &choiceValue{value: &c.RateLimitBackend, choices: []string{RateLimitOff, RateLimitMemory, RateLimitPostgres}}
+		}},
+	{env: envRateLimitDefault, flag: "rate-limit-default", def: defaultRateLimitDefault, usage: "per-client limit for lists and writes, e.g. 100/m",
+		field: func(c *Config) flag.Value { return (*limitValue)(&c.RateLimitDefault) }},
+	{env: envRateLimitRead, flag: "rate-limit-read", def: defaultRateLimitRead, usage: "per-client limit for reads by ID",
+		field: func(c *Config) flag.Value { return (*limitValue)(&c.RateLimitRead) }},
+	{env: envRateLimitHeavy, flag: "rate-limit-heavy", def: defaultRateLimitHeavy, usage: "per-client limit for summaries",
+		field: func(c *Config) flag.Value { return (*limitValue)(&c.RateLimitHeavy) }},
+	{env: envRateLimitRoutes, flag: "rate-limit-routes", def: defaultRateLimitRoutes, usage: "comma-separated per-route limits, e.g. GET /subscriptions/summary=10/m;burst=5",
+		field: func(c *Config) flag.Value { return (*routeLimitsValue)(&c.RateLimitRoutes) }},
+	{env: envRateLimitKey, flag: "rate-limit-key", def: defaultRateLimitKey, usage: "what identifies a client: ip, api_key or user_id",
+		field: func(c *Config) flag.Value {
+			return &choiceValue{value: &c.RateLimitKey, choices: []string{RateLimitKeyIP, RateLimitKeyAPIKey, RateLimitKeyUserID}}
+		}},
+	{env: envTrustedProxies, flag: "trusted-proxies", def: defaultTrustedProxies, usage: "comma-separated proxy addresses or networks whose X-Forwarded-For is trusted",
+		field: func(c *Config) flag.Value { return (*prefixListValue)(&c.TrustedProxies) }},
+	{env: envWebhookInterval, flag: "webhook-dispatch-interval", def: defaultWebhookInterval, usage: "how often webhook deliveries are dispatched",
+		field: func(c *Config) flag.Value { return (*durationValue)(&c.WebhookInterval) }},
+	{env: envWebhookMaxAttempts, flag: "webhook-max-attempts", def: defaultWebhookMaxAttempts, usage: "delivery attempts before a webhook delivery is dead",
+		field: func(c *Config) flag.Value { return (*intValue)(&c.WebhookMaxAttempts) }},
+	{env: envWebhookTimeout, flag: "webhook-timeout", def: defaultWebhookTimeout, usage: "timeout of a single webhook request",
+		field: func(c *Config) flag.Value { return (*durationValue)(&c.WebhookTimeout) }},
+	{env: envDBDriver, flag: "db-driver", def: defaultDBDriver, usage: "storage backend: postgres, sqlite or memory",
+		field: func(c *Config) flag.Value {
+			return &choiceValue{value: &c.DBDriver, choices: []string{DriverPostgres, DriverSQLite, DriverMemory}}
+		}},
+	{env: envSQLitePath, flag: "sqlite-path", def: defaultSQLitePath, usage: "database file of the sqlite backend",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.SQLitePath) }},
+	{env: envSnapshotPath, flag: "memory-snapshot-path", def: defaultSnapshotPath, usage: "snapshot file of the memory backend; empty keeps nothing",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.SnapshotPath) }},
+	{env: envSnapshotInterval, flag: "memory-snapshot-interval", def: defaultSnapshotInterval, usage: "how often the memory backend saves its snapshot",
+		field: func(c *Config) flag.Value { return (*durationValue)(&c.SnapshotInterval) }},
+	{env: envDBPool, flag: "db-pool", def: defaultDBPool, usage: "database client for subscriptions: stdlib or pgxpool",
+		field: func(c *Config) flag.Value {
+			return &choiceValue{value: &c.DBPool, choices: []string{DBPoolStdlib, DBPoolPgxpool}}
+		}},
+	{env: envDBHealthCheckTime, flag: "db-health-check-period", def: defaultDBHealthCheckTime, usage: "how often pgxpool checks idle connections",
+		field: func(c *Config) flag.Value { return (*durationValue)(&c.DBHealthCheckPeriod) }},
+	{env: envDBReplicaDSNs, flag: "db-replica-dsns", def: defaultDBReplicaDSNs, usage: "comma-separated DSNs of read replicas", secret: true,
+		field: func(c *Config) flag.Value { return (*listValue)(&c.DBReplicaDSNs) }},
+	{env: envDBReplicaInterval, flag: "db-replica-check-interval", def: defaultDBReplicaInterval, usage: "how often read replicas are checked",
+		field: func(c *Config) flag.Value { return (*durationValue)(&c.DBReplicaCheckInterval) }},
+	{env: envDBReplicaMaxLag, flag: "db-replica-max-lag", def: defaultDBReplicaMaxLag, usage: "replication lag beyond which a replica is not read from; 0 allows any",
+		field: func(c *Config) flag.Value { return (*durationValue)(&c.DBReplicaMaxLag) }},
+	{env: envCacheSize, flag: "cache-size", def: defaultCacheSize, usage: "number of cached subscription reads; 0 disables the cache",
+		field: func(c *Config) flag.Value { return (*intValue)(&c.CacheSize) }},
+	{env: envCacheTTL, flag: "cache-ttl", def: defaultCacheTTL, usage: "how long a cached subscription read is served",
+		field: func(c *Config) flag.Value { return (*durationValue)(&c.CacheTTL) }},
+	{env: envCacheStatsInterval, flag: "cache-stats-interval", def: defaultCacheStatsInterval, usage: "how often cache hit and miss counts are logged",
+		field: func(c *Config) flag.Value { return (*durationValue)(&c.CacheStatsInterval) }},
+	{env: envEventPublisher, flag: "event-publisher", def: defaultEventPublisher, usage: "domain event publisher: off, log or file",
+		field: func(c *Config) flag.Value {
+			return &choiceValue{value: &c.EventPublisher, choices: []string{PublisherOff, PublisherLog, PublisherFile}}
+		}},
+	{env: envEventFile, flag: "event-file", def: defaultEventFile, usage: "file the file event publisher appends to",
+		field: func(c *Config) flag.Value { return (*stringValue)(&c.EventFile) }},
+}
+
+// Flags holds command-line overrides bound to a flag set. They take
+// precedence over the config file and the environment.
+type Flags struct {
+	fs         *flag.FlagSet
+	configFile string
+	values     map[string]*string
+}
+
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs, values: make(map[string]*string, len(settings))}
+	fs.StringVar(&f.configFile, "config", "", "path to a YAML or TOML config file (env "+envConfigFile+")")
+	for _, s := range settings {
+		f.values[s.flag] = fs.String(s.flag, "", s.usage+" (env "+s.env+")")
 	}
+	return f
+}
 
-	return df
+func (f *Flags) visited() map[string]string {
+	set := make(map[string]string)
+	if f == nil || f.fs == nil {
+		return set
+	}
+	f.fs.Visit(func(fl *flag.Flag) {
+		if v, ok := f.values[fl.Name]; ok {
+			set[fl.Name] = *v
+		}
+	})
+	return set
+}
+
+// Load builds the effective configuration. Sources are applied in order of
+// increasing precedence: defaults, config file, environment, flags. Passing
+// nil flags skips the last step.
+func Load(f *Flags) (*Config, error) {
+	cfg := &Config{}
+	for _, s := range settings {
+		if err := s.set(cfg, s.def); err != nil {
+			return nil, err
+		}
+	}
+
+	path := getenv(envConfigFile, "")
+	if f != nil && f.configFile != "" {
+		path = f.configFile
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range settings {
+			key := strings.ToLower(s.env)
+			v, err := lookup(fileGetter(values), key, key+strings.ToLower(fileSuffix))
+			if err != nil {
+				return nil, err
+			}
+			if v == "" {
+				continue
+			}
+			if err := s.set(cfg, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, s := range settings {
+		v, err := lookup(os.Getenv, s.env, s.env+fileSuffix)
+		if err != nil {
+			return nil, err
+		}
+		if v == "" {
+			continue
+		}
+		if err := s.set(cfg, v); err != nil {
+			return nil, err
+		}
+	}
+
+	set := f.visited()
+	for _, s := range settings {
+		v, ok := set[s.flag]
+		if !ok {
+			continue
+		}
+		if err := s.set(cfg, v); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.DSN = buildDSN(cfg, false)
+	return cfg, nil
 }
 
-func MustLoad() *Config {
-	port := getenv(envHTTPPort, defaultHTTPPort)
+// Print writes the effective configuration as KEY=value lines. Secrets
+// are masked when redacted is set.
+func (c *Config) Print(w io.Writer, redacted bool) error {
+	for _, s := range settings {
+		v := s.field(c).String()
+		if redacted && s.secret && v != "" {
+			v = redactedValue
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", s.env, v); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "DSN=%s\n", buildDSN(c, redacted))
+	return err
+}
 
-	dbhost := getenv(envDBHost, defaultDBHost)
-	dbport := getenv(envDBPort, defaultDBPort)
-	dbuser := getenv(envDBUser, defaultDBUser)
-	dbpass := getenv(envDBPassword, defaultDBPass)
-	dbname := getenv(envDBName, defaultDBName)
-	dbsslmode := getenv(envDBSSLMode, defaultDBSSLMode)
+func buildDSN(c *Config, redacted bool) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.DBUser, c.DBPassword),
+		Host:     c.DBHost + ":" + c.DBPort,
+		Path:     "/" + c.DBName,
+		RawQuery: url.Values{"sslmode": {c.DBSSLMode}}.Encode(),
+	}
+	if redacted {
+		return u.Redacted()
+	}
+	return u.String()
+}
 
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s", dbuser, dbpass, dbhost, dbport, dbname, dbsslmode,
-	)
+func (s setting) set(c *Config, v string) error {
+	if err := s.field(c).Set(v); err != nil {
+		return fmt.Errorf("invalid %s %q: %w", s.env, v, err)
+	}
+	return nil
+}
 
-	return &Config{
-		HTTPPort: port,
-		DSN:      dsn,
+// lookup resolves a setting from its raw key and its *_FILE variant.
+// Setting both is ambiguous and rejected.
+func lookup(get func(string) string, key, fileKey string) (string, error) {
+	raw, file := get(key), get(fileKey)
+	if file == "" {
+		return raw, nil
 	}
+	if raw != "" {
+		return "", fmt.Errorf("both %s and %s are set", key, fileKey)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", fileKey, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func getenv(key, df string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return df
 }
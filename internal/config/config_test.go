@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := "http_port: 9000\ndb:\n  host: file-host\n  name: file-db\n  user: file-user\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	t.Setenv(envConfigFile, path)
+	t.Setenv(envDBHost, "env-host")
+	t.Setenv(envDBName, "env-db")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := BindFlags(fs)
+	if err := fs.Parse([]string{"--db-name", "flag-db"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	cfg, err := Load(flags)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if cfg.HTTPPort != "9000" {
+		t.Fatalf("unexpected http port: %s", cfg.HTTPPort)
+	}
+	if cfg.DBUser != "file-user" {
+		t.Fatalf("unexpected db user: %s", cfg.DBUser)
+	}
+	if cfg.DBHost != "env-host" {
+		t.Fatalf("unexpected db host: %s", cfg.DBHost)
+	}
+	if cfg.DBName != "flag-db" {
+		t.Fatalf("unexpected db name: %s", cfg.DBName)
+	}
+	if cfg.DBSSLMode != defaultDBSSLMode {
+		t.Fatalf("unexpected ssl mode: %s", cfg.DBSSLMode)
+	}
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db_password")
+	if err := os.WriteFile(secret, []byte("s3cr:et@\n"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	t.Setenv(envDBPassword+fileSuffix, secret)
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.DBPassword != "s3cr:et@" {
+		t.Fatalf("unexpected password: %q", cfg.DBPassword)
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out, true); err != nil {
+		t.Fatalf("print: %v", err)
+	}
+	if strings.Contains(out.String(), "s3cr") {
+		t.Fatalf("secret leaked in redacted output:\n%s", out.String())
+	}
+
+	t.Setenv(envDBPassword, "raw")
+	if _, err := Load(nil); err == nil {
+		t.Fatal("expected error when both raw and file variants are set")
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := "http_port = 7000\n[db]\nport = 6543\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := BindFlags(fs)
+	if err := fs.Parse([]string{"--config", path}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	cfg, err := Load(flags)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.HTTPPort != "7000" || cfg.DBPort != "6543" {
+		t.Fatalf("unexpected config: port=%s db_port=%s", cfg.HTTPPort, cfg.DBPort)
+	}
+}
+
+func TestLoadRateLimitRoutes(t *testing.T) {
+	t.Setenv(envRateLimitRoutes, "GET /subscriptions/summary=10/m;burst=5, POST /subscriptions=50/m")
+	t.Setenv(envTrustedProxies, "10.0.0.0/8,192.168.1.7")
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	summary := cfg.RateLimitRoutes["GET /subscriptions/summary"]
+	if summary.Requests != 10 || summary.Period != time.Minute || summary.Burst != 5 || len(cfg.RateLimitRoutes) != 2 {
+		t.Fatalf("unexpected route limits: %v", cfg.RateLimitRoutes)
+	}
+	if got := (*prefixListValue)(&cfg.TrustedProxies).String(); got != "10.0.0.0/8,192.168.1.7/32" {
+		t.Fatalf("unexpected trusted proxies: %s", got)
+	}
+
+	t.Setenv(envRateLimitRoutes, "/subscriptions=10/m")
+	if _, err := Load(nil); err == nil {
+		t.Fatal("expected a route without a method to be rejected")
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile decodes a YAML or TOML config file into flat lower-case keys.
+// Nested sections are joined with an underscore, and lists become
+// comma-separated values, matching the environment variable format.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file %q, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %q: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", raw, values)
+	return values, nil
+}
+
+func flatten(prefix string, in map[string]any, out map[string]string) {
+	for k, v := range in {
+		key := strings.ToLower(k)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			flatten(key, val, out)
+		case []any:
+			items := make([]string, 0, len(val))
+			for _, item := range val {
+				items = append(items, fmt.Sprint(item))
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+		default:
+			out[key] = fmt.Sprint(val)
+		}
+	}
+}
+
+func fileGetter(values map[string]string) func(string) string {
+	return func(key string) string {
+		return values[key]
+	}
+}
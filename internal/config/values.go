@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"restservice/internal/ratelimit"
+)
+
+// stringValue and friends adapt Config fields to flag.Value so that every
+// source can assign them through the same textual Set.
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string { return string(*v) }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+// listValue holds comma-separated items; blanks around items are dropped.
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*v = items
+	return nil
+}
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+type limitValue ratelimit.Limit
+
+func (v *limitValue) Set(s string) error {
+	l, err := ratelimit.ParseLimit(s)
+	if err != nil {
+		return err
+	}
+	*v = limitValue(l)
+	return nil
+}
+
+func (v *limitValue) String() string { return ratelimit.Limit(*v).String() }
+
+// routeLimitsValue holds comma-separated "METHOD /path=limit" items.
+type routeLimitsValue map[string]ratelimit.Limit
+
+func (v *routeLimitsValue) Set(s string) error {
+	limits := make(map[string]ratelimit.Limit)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		route, raw, ok := strings.Cut(item, "=")
+		method, path, _ := strings.Cut(strings.TrimSpace(route), " ")
+		path = strings.TrimSpace(path)
+		if !ok || method != strings.ToUpper(method) || !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("route limit %q: expected METHOD /path=limit", item)
+		}
+		l, err := ratelimit.ParseLimit(raw)
+		if err != nil {
+			return err
+		}
+		limits[method+" "+path] = l
+	}
+	*v = limits
+	return nil
+}
+
+func (v *routeLimitsValue) String() string {
+	var items []string
+	for _, route := range slices.Sorted(maps.Keys(*v)) {
+		items = append(items, route+"="+(*v)[route].String())
+	}
+	return strings.Join(items, ",")
+}
+
+// prefixListValue holds comma-separated networks; a bare address is a
+// network of its own.
+type prefixListValue []netip.Prefix
+
+func (v *prefixListValue) Set(s string) error {
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	*v = prefixes
+	return nil
+}
+
+func (v *prefixListValue) String() string {
+	items := make([]string, 0, len(*v))
+	for _, prefix := range *v {
+		items = append(items, prefix.String())
+	}
+	return strings.Join(items, ",")
+}
+
+// choiceValue is a string restricted to a fixed set of values.
+type choiceValue struct {
+	value   *string
+	choices []string
+}
+
+func (v *choiceValue) Set(s string) error {
+	if !slices.Contains(v.choices, s) {
+		return fmt.Errorf("expected one of %s", strings.Join(v.choices, ", "))
+	}
+	*v.value = s
+	return nil
+}
+
+func (v *choiceValue) String() string { return *v.value }
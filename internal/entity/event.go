@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription lifecycle event types, as stored in the outbox and sent to
+// webhooks.
+const (
+	EventSubscriptionCreated = "subscription.created"
+	EventSubscriptionUpdated = "subscription.updated"
+	EventSubscriptionDeleted = "subscription.deleted"
+	EventSubscriptionEnded   = "subscription.ended"
+	// EventSubscriptionPriceChanged accompanies subscription.updated when
+	// the price changes.
+	EventSubscriptionPriceChanged = "subscription.price_changed"
+)
+
+// EventTypes lists every event type a webhook may subscribe to.
+var EventTypes = []string{
+	EventSubscriptionCreated,
+	EventSubscriptionUpdated,
+	EventSubscriptionDeleted,
+	EventSubscriptionEnded,
+	EventSubscriptionPriceChanged,
+}
+
+// OutboxEvent is a change recorded in the same transaction as the row it
+// describes. ID grows monotonically and doubles as the event ID.
+type OutboxEvent struct {
+	ID             int64
+	Type           string
+	SubscriptionID uuid.UUID
+	Payload        json.RawMessage
+	CreatedAt      time.Time
+}
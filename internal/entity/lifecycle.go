@@ -0,0 +1,79 @@
+package entity
+
+import "time"
+
+// Status is where a subscription is in its lifecycle. Subscriptions are
+// billed by the month; BilledMonths tells how many months of a period a
+// subscription costs its price in.
+type Status string
+
+const (
+	// StatusTrial is free up to TrialEndDate and active from then on.
+	StatusTrial  Status = "trial"
+	StatusActive Status = "active"
+	// StatusPaused is not billed from the start of its open pause.
+	StatusPaused Status = "paused"
+	// StatusCancelled is billed through the month of CancelledAt, which is
+	// also its EndDate.
+	StatusCancelled Status = "cancelled"
+)
+
+// Pause is a run of unbilled months from the month of Start up to, but not
+// including, the month of End. The pause of a paused subscription has no
+// End yet. Repositories store pauses as JSON in this form.
+type Pause struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// StatusAt is the status at t. A trial is active from its end date on, and
+// an empty status, as in rows that predate the lifecycle, is active.
+func (s Subscription) StatusAt(t time.Time) Status {
+	switch {
+	case s.Status == "":
+		return StatusActive
+	case s.Status == StatusTrial && s.TrialEndDate != nil && !t.Before(*s.TrialEndDate):
+		return StatusActive
+	}
+	return s.Status
+}
+
+// BilledIn reports whether s costs anything in the period from the month
+// of start through the month of end.
+func (s Subscription) BilledIn(start, end time.Time) bool {
+	return s.BilledMonths(start, end) > 0
+}
+
+// BilledMonths is how many months of the period from the month of start
+// through the month of end s costs its price in: the months it runs in the
+// period, past its trial and outside its pauses.
+func (s Subscription) BilledMonths(start, end time.Time) int {
+	lo := max(MonthNumber(s.StartDate), MonthNumber(start))
+	if s.TrialEndDate != nil {
+		lo = max(lo, MonthNumber(*s.TrialEndDate))
+	}
+	hi := MonthNumber(end)
+	if s.EndDate != nil {
+		hi = min(hi, MonthNumber(*s.EndDate))
+	}
+
+	billed := hi - lo + 1
+	for _, p := range s.Pauses {
+		last := hi
+		if p.End != nil {
+			last = min(hi, MonthNumber(*p.End)-1)
+		}
+		if paused := last - max(lo, MonthNumber(p.Start)) + 1; paused > 0 {
+			billed -= paused
+		}
+	}
+	return max(billed, 0)
+}
+
+// MonthNumber numbers months consecutively, so that a run of months is a
+// range of integers. The SQL repositories compute the same number as
+// year * 12 + month.
+func MonthNumber(t time.Time) int {
+	t = t.UTC()
+	return t.Year()*12 + int(t.Month())
+}
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Service is a catalog entry that free-text subscription service names
+// resolve to.
+type Service struct {
+	ID uuid.UUID
+	// Name is the canonical name stored on resolved subscriptions.
+	Name      string
+	Category  string
+	VendorURL string
+	// DefaultPrice is used for subscriptions created without a price;
+	// zero means none.
+	DefaultPrice int
+	Aliases      []ServiceAlias
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type AliasMatch string
+
+const (
+	// AliasExact matches the whole name.
+	AliasExact AliasMatch = "exact"
+	// AliasPrefix matches names that start with the pattern followed by a
+	// space, such as "netflix premium" for "netflix".
+	AliasPrefix AliasMatch = "prefix"
+)
+
+// ServiceAlias is a matching rule. Patterns are compared with names
+// lowercased and with runs of whitespace collapsed.
+type ServiceAlias struct {
+	Pattern string
+	Match   AliasMatch
+}
@@ -13,4 +13,19 @@ type Subscription struct {
 	UserID      uuid.UUID
 	StartDate   time.Time
 	EndDate     *time.Time
+	// Category groups spend, such as "streaming"; empty means none.
+	Category string
+	// Tags are free-form labels such as cost centers, sorted and without
+	// duplicates.
+	Tags []string
+	// Status, TrialEndDate, Pauses and CancelledAt follow the lifecycle;
+	// they change through subscription.Service transitions only.
+	Status       Status
+	TrialEndDate *time.Time
+	// Pauses are ordered by start and do not overlap; only the last one
+	// may be open.
+	Pauses      []Pause
+	CancelledAt *time.Time
+	// UpdatedAt is set by the repository on every create and update.
+	UpdatedAt time.Time
 }
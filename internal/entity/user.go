@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID   uuid.UUID
+	Name string
+	// Email is optional and unique regardless of case.
+	Email string
+	// Timezone is an IANA zone name such as "Europe/Moscow".
+	Timezone string
+	// Currency is the ISO 4217 code the user's amounts default to.
+	Currency  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
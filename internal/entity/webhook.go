@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Webhook struct {
+	ID     uuid.UUID
+	URL    string
+	Events []string
+	// Secret signs every delivery. It is only shown to the client when the
+	// webhook is created.
+	Secret    string
+	CreatedAt time.Time
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead marks a delivery that ran out of attempts. It stays
+	// dead until redelivered by hand.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// WebhookDelivery is one event queued for one webhook.
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      uuid.UUID
+	EventID        int64
+	EventType      string
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+}
@@ -0,0 +1,234 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+
+	// minCompressSize is the smallest body worth compressing; below it the
+	// encoding overhead outweighs the savings.
+	minCompressSize = 1024
+)
+
+var (
+	gzipPool = sync.Pool{New: func() any {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	}}
+	zstdPool = sync.Pool{New: func() any {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return w
+	}}
+)
+
+// compress encodes responses with gzip or zstd as negotiated through
+// Accept-Encoding. Only JSON and YAML bodies of at least minCompressSize
+// are compressed.
+func (h *Handler) compress(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+		defer func() {
+			if err := cw.close(); err != nil {
+				h.logger.Error("compress response failed", "error", err)
+			}
+		}()
+		next(cw, r)
+	}
+}
+
+// negotiateEncoding picks the preferred supported coding from an
+// Accept-Encoding header, favouring zstd on equal weight. It returns ""
+// when the body should be sent as is.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			name = encodingGzip
+		}
+		if name != encodingGzip && name != encodingZstd || q <= 0 {
+			continue
+		}
+		if q > bestQ || q == bestQ && name == encodingZstd {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a body until it knows whether the
+// response is large and compressible enough, then either switches to an
+// encoder or passes everything through unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	status   int
+
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	enc         io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified || status < http.StatusOK {
+		cw.passThrough()
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) < minCompressSize {
+		return len(p), nil
+	}
+	if cw.compressible() {
+		cw.startEncoder()
+	} else {
+		cw.passThrough()
+	}
+	if err := cw.flushBuffer(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends whatever has been encoded so far, so streaming handlers keep
+// working behind the middleware.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.passThrough()
+		_ = cw.flushBuffer()
+	}
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) compressible() bool {
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json", "application/yaml":
+		return true
+	}
+	return false
+}
+
+func (cw *compressWriter) startEncoder() {
+	cw.decided = true
+	header := cw.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", cw.encoding)
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	switch cw.encoding {
+	case encodingZstd:
+		enc := zstdPool.Get().(*zstd.Encoder)
+		enc.Reset(cw.ResponseWriter)
+		cw.enc = enc
+	default:
+		enc := gzipPool.Get().(*gzip.Writer)
+		enc.Reset(cw.ResponseWriter)
+		cw.enc = enc
+	}
+}
+
+func (cw *compressWriter) passThrough() {
+	if cw.decided {
+		return
+	}
+	cw.decided = true
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+func (cw *compressWriter) flushBuffer() error {
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	buf := cw.buf
+	cw.buf = nil
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close finishes the response: small bodies still in the buffer go out
+// uncompressed, and an active encoder is flushed and returned to its pool.
+func (cw *compressWriter) close() error {
+	if !cw.wroteHeader {
+		return nil
+	}
+	if !cw.decided {
+		cw.passThrough()
+	}
+	if err := cw.flushBuffer(); err != nil {
+		return err
+	}
+	if cw.enc == nil {
+		return nil
+	}
+
+	err := cw.enc.Close()
+	switch enc := cw.enc.(type) {
+	case *gzip.Writer:
+		gzipPool.Put(enc)
+	case *zstd.Encoder:
+		zstdPool.Put(enc)
+	}
+	cw.enc = nil
+	return err
+}
@@ -0,0 +1,222 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo/memory"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                       "",
+		"identity":               "",
+		"gzip":                   encodingGzip,
+		"gzip, deflate, br":      encodingGzip,
+		"gzip, zstd":             encodingZstd,
+		"zstd;q=0.5, gzip":       encodingGzip,
+		"gzip;q=0, zstd;q=0":     "",
+		"*":                      encodingGzip,
+		"GZIP;q=0.8, br;q=1.0":   encodingGzip,
+		"zstd;q=bad, gzip;q=0.1": encodingGzip,
+	}
+	for header, want := range cases {
+		if got := negotiateEncoding(header); got != want {
+			t.Fatalf("%q: got %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	userID := uuid.New()
+	subs := make([]entity.Subscription, 0, 20)
+	for i := 0; i < 20; i++ {
+		subs = append(subs, entity.Subscription{
+			ID:          uuid.New(),
+			ServiceName: "Netflix",
+			Price:       500,
+			UserID:      userID,
+			StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+	handler := newTestHandler(memory.NewSubscriptionRepo(subs...))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	get := func(target, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	plain := get(openapiJSONPath, "")
+	if plain.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("unexpected encoding without Accept-Encoding: %q", plain.Header().Get("Content-Encoding"))
+	}
+
+	decoders := map[string]func(io.Reader) ([]byte, error){
+		encodingGzip: func(r io.Reader) ([]byte, error) {
+			zr, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(zr)
+		},
+		encodingZstd: func(r io.Reader) ([]byte, error) {
+			zr, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			defer zr.Close()
+			return io.ReadAll(zr)
+		},
+	}
+	for encoding, decode := range decoders {
+		w := get(openapiJSONPath, encoding)
+		if got := w.Header().Get("Content-Encoding"); got != encoding {
+			t.Fatalf("%s: unexpected Content-Encoding %q", encoding, got)
+		}
+		if w.Header().Get("Vary") == "" {
+			t.Fatalf("%s: missing Vary header", encoding)
+		}
+		body, err := decode(w.Body)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", encoding, err)
+		}
+		if !bytes.Equal(body, plain.Body.Bytes()) {
+			t.Fatalf("%s: decoded body differs from the plain one", encoding)
+		}
+	}
+
+	if got := get(subscriptionsPath, encodingZstd).Header().Get("Content-Encoding"); got != encodingZstd {
+		t.Fatalf("list: unexpected Content-Encoding %q", got)
+	}
+
+	small := get(subscriptionsPath+"/"+uuid.NewString(), encodingGzip)
+	if small.Code != http.StatusNotFound || small.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("small body should not be compressed: %d %q", small.Code, small.Header().Get("Content-Encoding"))
+	}
+	var errBody errorResponse
+	if err := json.Unmarshal(small.Body.Bytes(), &errBody); err != nil {
+		t.Fatalf("decode small body: %v", err)
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	updatedAt := time.Date(2026, time.March, 3, 10, 30, 0, 0, time.UTC)
+	existing := entity.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:   updatedAt,
+	}
+	repo := memory.NewSubscriptionRepo(existing)
+	handler := newTestHandler(repo)
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, target := range []string{
+		subscriptionsPath + "/" + existing.ID.String(),
+		v2Prefix + "/subscriptions/" + existing.ID.String(),
+		subscriptionsPath,
+		v2Prefix + "/subscriptions",
+		summaryPath + "?start_date=07-2025&end_date=08-2025",
+		v2Prefix + "/subscriptions/summary?start_date=2025-07-01&end_date=2025-08-01",
+	} {
+		first := get(target, nil)
+		etag := first.Header().Get("ETag")
+		if first.Code != http.StatusOK || etag == "" {
+			t.Fatalf("%s: unexpected first response: %d, etag %q", target, first.Code, etag)
+		}
+
+		revalidated := get(target, http.Header{"If-None-Match": {etag}})
+		if revalidated.Code != http.StatusNotModified || revalidated.Body.Len() != 0 {
+			t.Fatalf("%s: expected empty 304, got %d", target, revalidated.Code)
+		}
+		if stale := get(target, http.Header{"If-None-Match": {`W/"stale"`}}); stale.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200 for a stale etag, got %d", target, stale.Code)
+		}
+	}
+
+	item := subscriptionsPath + "/" + existing.ID.String()
+	if got := get(item, nil).Header().Get("Last-Modified"); got != updatedAt.Format(http.TimeFormat) {
+		t.Fatalf("unexpected Last-Modified: %q", got)
+	}
+	if w := get(item, http.Header{"If-Modified-Since": {updatedAt.Format(http.TimeFormat)}}); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for If-Modified-Since, got %d", w.Code)
+	}
+	before := updatedAt.Add(-time.Minute).Format(http.TimeFormat)
+	if w := get(item, http.Header{"If-Modified-Since": {before}}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for an older If-Modified-Since, got %d", w.Code)
+	}
+	if w := get(subscriptionsPath, http.Header{"If-Modified-Since": {updatedAt.Format(http.TimeFormat)}}); w.Code != http.StatusOK {
+		t.Fatalf("lists must not be revalidated by date, got %d", w.Code)
+	}
+
+	// Changing the row changes the ETag.
+	etag := get(item, nil).Header().Get("ETag")
+	existing.Price = 600
+	if _, err := repo.Update(context.Background(), existing.ID, existing); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if w := get(item, http.Header{"If-None-Match": {etag}}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 after an update, got %d", w.Code)
+	}
+}
+
+// A trial that ended after the last write changed the v2 status then, so
+// it must not be revalidated by the write time.
+func TestLastModifiedAfterTrialEnd(t *testing.T) {
+	updatedAt := time.Date(2025, time.June, 20, 10, 30, 0, 0, time.UTC)
+	trialEnd := time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)
+	trial := entity.Subscription{
+		ID:           uuid.New(),
+		ServiceName:  "Netflix",
+		Price:        500,
+		UserID:       uuid.New(),
+		StartDate:    time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		Status:       entity.StatusTrial,
+		TrialEndDate: &trialEnd,
+		UpdatedAt:    updatedAt,
+	}
+	handler := newTestHandler(memory.NewSubscriptionRepo(trial))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	req := httptest.NewRequest(http.MethodGet, v2Prefix+"/subscriptions/"+trial.ID.String(), nil)
+	req.Header.Set("If-Modified-Since", updatedAt.Format(http.TimeFormat))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 after the trial ended, got %d", w.Code)
+	}
+	if got := w.Header().Get("Last-Modified"); got != trialEnd.Format(http.TimeFormat) {
+		t.Fatalf("unexpected Last-Modified: %q", got)
+	}
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// writeCacheable writes a 200 JSON response that clients may revalidate.
+// The ETag is a hash of the encoded body, so it changes whenever anything
+// in the payload does. It is weak because compression changes the bytes on
+// the wire but not the content.
+//
+// lastModified is only meaningful for a single row: a list or a summary
+// also changes when a row is deleted, which no updated_at would reflect,
+// so callers pass the zero time and rely on the ETag alone.
+func (h *Handler) writeCacheable(w http.ResponseWriter, r *http.Request, payload any, lastModified time.Time) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		h.logger.Error("encode response failed", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	body = append(body, '\n')
+
+	sum := sha256.Sum256(body)
+	etag := `W/"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "no-cache")
+	if !lastModified.IsZero() {
+		lastModified = lastModified.UTC().Truncate(time.Second)
+		header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		header.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		h.logger.Error("write response failed", "error", err)
+	}
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since as RFC 9110
+// section 13.2.2 orders them: when If-None-Match is present,
+// If-Modified-Since is ignored.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(since)
+}
+
+// etagMatches uses the weak comparison If-None-Match calls for.
+func etagMatches(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"restservice/internal/usecase/subscription"
+)
+
+// readYourWritesHeader asks for reads from the primary database. Replicas
+// may not have caught up with a write the client has just made, so a
+// client that must see it sends "X-Read-Your-Writes: true" on the reads
+// that follow.
+const readYourWritesHeader = "X-Read-Your-Writes"
+
+// readYourWritesCookie does the same for clients that keep cookies. It is
+// set by every successful write and expires once replicas that are still
+// read from must have caught up with it.
+const readYourWritesCookie = "read_your_writes"
+
+// WithReadYourWrites sets the read-your-writes cookie on successful
+// writes for window, which should cover the longest replication lag of a
+// replica still in use. Without it only the header is honoured.
+func WithReadYourWrites(window time.Duration) Option {
+	return func(h *Handler) { h.readYourWritesWindow = window }
+}
+
+func (h *Handler) readYourWrites(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if wantsPrimaryReads(r) {
+			r = r.WithContext(subscription.WithPrimaryReads(r.Context()))
+		}
+		if h.readYourWritesWindow > 0 && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w = &readYourWritesWriter{ResponseWriter: w, window: h.readYourWritesWindow}
+		}
+		next(w, r)
+	}
+}
+
+func wantsPrimaryReads(r *http.Request) bool {
+	if v, err := strconv.ParseBool(r.Header.Get(readYourWritesHeader)); err == nil && v {
+		return true
+	}
+	_, err := r.Cookie(readYourWritesCookie)
+	return err == nil
+}
+
+// readYourWritesWriter adds the read-your-writes cookie to a successful
+// response.
+type readYourWritesWriter struct {
+	http.ResponseWriter
+	window      time.Duration
+	wroteHeader bool
+}
+
+func (rw *readYourWritesWriter) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		if status >= http.StatusOK && status < http.StatusMultipleChoices {
+			http.SetCookie(rw.ResponseWriter, &http.Cookie{
+				Name:     readYourWritesCookie,
+				Value:    "1",
+				Path:     "/",
+				MaxAge:   int(math.Ceil(rw.window.Seconds())),
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *readYourWritesWriter) Write(p []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.ResponseWriter.Write(p)
+}
+
+func (rw *readYourWritesWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
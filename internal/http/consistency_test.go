@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo/memory"
+	"restservice/internal/usecase/subscription"
+)
+
+// primaryReadsRepo records whether the last List asked for primary reads.
+type primaryReadsRepo struct {
+	*memory.SubscriptionRepo
+	primary bool
+}
+
+func (r *primaryReadsRepo) List(ctx context.Context, filter subscription.ListFilter) ([]entity.Subscription, error) {
+	r.primary = subscription.PrimaryReads(ctx)
+	return r.SubscriptionRepo.List(ctx, filter)
+}
+
+func TestReadYourWrites(t *testing.T) {
+	repo := &primaryReadsRepo{SubscriptionRepo: memory.NewSubscriptionRepo()}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewHandler(subscription.NewService(repo, logger), logger)
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	for _, tc := range []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"true", true},
+		{"1", true},
+		{"false", false},
+		{"maybe", false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, v2Prefix+"/subscriptions", nil)
+		if tc.header != "" {
+			req.Header.Set(readYourWritesHeader, tc.header)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: unexpected status %d", tc.header, w.Code)
+		}
+		if repo.primary != tc.want {
+			t.Fatalf("%q: primary reads %v, want %v", tc.header, repo.primary, tc.want)
+		}
+	}
+}
+
+func TestReadYourWritesCookie(t *testing.T) {
+	userID := uuid.New()
+	repo := &primaryReadsRepo{SubscriptionRepo: memory.NewSubscriptionRepo()}
+	repo.AddUsers(entity.User{ID: userID})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewHandler(subscription.NewService(repo, logger), logger, WithReadYourWrites(30*time.Second))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	body := `{"service_name":"Netflix","price":{"amount":500,"currency":"RUB"},"user_id":"` + userID.String() + `","start_date":"2025-07-01"}`
+	req := httptest.NewRequest(http.MethodPost, v2Prefix+"/subscriptions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != readYourWritesCookie || cookies[0].MaxAge != 30 {
+		t.Fatalf("a write must set the read-your-writes cookie: %v", cookies)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, v2Prefix+"/subscriptions", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !repo.primary {
+		t.Fatalf("a read with the cookie must go to the primary: status %d, primary %v", w.Code, repo.primary)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Fatal("reads must not set the cookie")
+	}
+
+	// A failed write does not.
+	req = httptest.NewRequest(http.MethodPost, v2Prefix+"/subscriptions", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || len(w.Result().Cookies()) != 0 {
+		t.Fatalf("a rejected write must not set the cookie: status %d", w.Code)
+	}
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corsExposedHeaders are response headers a browser client may read in
+// addition to the CORS-safelisted ones.
+var corsExposedHeaders = []string{
+	"Deprecation", "Sunset", "Link", "Allow", "Retry-After",
+	"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+}
+
+// CORSConfig describes which cross-origin browser clients may call the
+// API. CORS is off while AllowedOrigins is empty; "*" allows any origin
+// and, in AllowedHeaders, any request header.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// WithCORS enables CORS handling with the given configuration.
+func WithCORS(cfg CORSConfig) Option {
+	return func(h *Handler) {
+		if len(cfg.AllowedOrigins) > 0 {
+			h.cors = newCORSPolicy(cfg)
+		}
+	}
+}
+
+type corsPolicy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	methods     map[string]bool
+	anyHeader   bool
+	headers     map[string]bool
+	credentials bool
+	maxAge      string
+	expose      string
+}
+
+func newCORSPolicy(cfg CORSConfig) *corsPolicy {
+	p := &corsPolicy{
+		origins:     make(map[string]bool),
+		methods:     make(map[string]bool),
+		headers:     make(map[string]bool),
+		credentials: cfg.AllowCredentials,
+		expose:      strings.Join(corsExposedHeaders, ", "),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			p.anyOrigin = true
+			continue
+		}
+		p.origins[strings.TrimRight(origin, "/")] = true
+	}
+	for _, method := range cfg.AllowedMethods {
+		p.methods[strings.ToUpper(method)] = true
+	}
+	for _, header := range cfg.AllowedHeaders {
+		if header == "*" {
+			p.anyHeader = true
+			continue
+		}
+		p.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.MaxAge / time.Second))
+	}
+	return p
+}
+
+// allowOrigin writes the origin headers shared by preflight and actual
+// responses and reports whether the origin is allowed.
+func (p *corsPolicy) allowOrigin(w http.ResponseWriter, origin string) bool {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	if origin == "" || (!p.anyOrigin && !p.origins[origin]) {
+		return false
+	}
+	// A wildcard cannot be combined with credentials, so the origin is
+	// echoed back instead.
+	if p.anyOrigin && !p.credentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	return true
+}
+
+// requestHeadersAllowed checks an Access-Control-Request-Headers list.
+func (p *corsPolicy) requestHeadersAllowed(list string) bool {
+	if p.anyHeader {
+		return true
+	}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !p.headers[http.CanonicalHeaderKey(name)] {
+			return false
+		}
+	}
+	return true
+}
+
+// corsHeaders adds CORS headers to actual (non-preflight) responses.
+func (h *Handler) corsHeaders(next http.HandlerFunc) http.HandlerFunc {
+	if h.cors == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.cors.allowOrigin(w, r.Header.Get("Origin")) {
+			w.Header().Set("Access-Control-Expose-Headers", h.cors.expose)
+		}
+		next(w, r)
+	}
+}
+
+// preflight answers a CORS preflight for a path implementing the given
+// methods. The allowed methods are those both configured and implemented,
+// so a browser is never invited to send a request that would get a 405.
+// Rejected preflights still get 204, just without the CORS headers.
+func (h *Handler) preflight(w http.ResponseWriter, r *http.Request, implemented map[string]bool) {
+	p := h.cors
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	var methods []string
+	for method := range implemented {
+		if p.methods[method] {
+			methods = append(methods, method)
+		}
+	}
+	requestHeaders := r.Header.Get("Access-Control-Request-Headers")
+	if !slices.Contains(methods, r.Header.Get("Access-Control-Request-Method")) || !p.requestHeadersAllowed(requestHeaders) {
+		w.Header().Add("Vary", "Origin")
+		return
+	}
+	if !p.allowOrigin(w, r.Header.Get("Origin")) {
+		return
+	}
+
+	slices.Sort(methods)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if requestHeaders != "" {
+		w.Header().Set("Access-Control-Allow-Headers", requestHeaders)
+	}
+	if p.maxAge != "" {
+		w.Header().Set("Access-Control-Max-Age", p.maxAge)
+	}
+}
+
+// corsPreflight answers preflights for a path itself and hands any other
+// OPTIONS request to next.
+func (h *Handler) corsPreflight(implemented map[string]bool, next http.HandlerFunc) http.HandlerFunc {
+	if h.cors == nil {
+		return next
+	}
+	actual := h.corsHeaders(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isPreflight(r) {
+			actual(w, r)
+			return
+		}
+		h.preflight(w, r, implemented)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"restservice/internal/repo/memory"
+	"restservice/internal/usecase/subscription"
+)
+
+func TestCORS(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewHandler(subscription.NewService(memory.NewSubscriptionRepo(), logger), logger, WithCORS(CORSConfig{
+		AllowedOrigins:   []string{"https://dashboard.example.com"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	const origin = "https://dashboard.example.com"
+	item := v2Prefix + "/subscriptions/" + "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+
+	cases := []struct {
+		name           string
+		method         string
+		target         string
+		origin         string
+		requestMethod  string
+		requestHeaders string
+		status         int
+		allowOrigin    string
+		allowMethods   string
+	}{
+		{"preflight collection", http.MethodOptions, subscriptionsPath, origin, "POST", "content-type", http.StatusNoContent, origin, "GET, POST"},
+		{"preflight item", http.MethodOptions, item, origin, "PUT", "", http.StatusNoContent, origin, "DELETE, GET, PUT"},
+		{"preflight summary", http.MethodOptions, summaryPath, origin, "GET", "", http.StatusNoContent, origin, "GET"},
+		{"preflight unimplemented method", http.MethodOptions, summaryPath, origin, "DELETE", "", http.StatusNoContent, "", ""},
+		{"preflight unknown origin", http.MethodOptions, subscriptionsPath, "https://evil.example.com", "GET", "", http.StatusNoContent, "", ""},
+		{"preflight disallowed header", http.MethodOptions, subscriptionsPath, origin, "GET", "X-Debug", http.StatusNoContent, "", ""},
+		{"actual request", http.MethodGet, subscriptionsPath, origin, "", "", http.StatusOK, origin, ""},
+		{"actual method not allowed", http.MethodPatch, subscriptionsPath, origin, "", "", http.StatusMethodNotAllowed, origin, ""},
+		{"actual unknown origin", http.MethodGet, subscriptionsPath, "https://evil.example.com", "", "", http.StatusOK, "", ""},
+		{"plain options", http.MethodOptions, subscriptionsPath, "", "", "", http.StatusNoContent, "", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tc.requestMethod)
+			}
+			if tc.requestHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tc.requestHeaders)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Fatalf("unexpected status: %d", w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tc.allowOrigin {
+				t.Fatalf("unexpected Access-Control-Allow-Origin: %q", got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != tc.allowMethods {
+				t.Fatalf("unexpected Access-Control-Allow-Methods: %q", got)
+			}
+			if tc.allowOrigin == "" {
+				return
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+				t.Fatalf("unexpected Access-Control-Allow-Credentials: %q", got)
+			}
+			if tc.allowMethods != "" && w.Header().Get("Access-Control-Max-Age") != "600" {
+				t.Fatalf("unexpected Access-Control-Max-Age: %q", w.Header().Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo/memory"
+	"restservice/internal/usecase/subscription"
+)
+
+type memoryEventLog struct {
+	mu       sync.Mutex
+	events   []subscription.StreamEvent
+	appended chan struct{}
+}
+
+func (l *memoryEventLog) append(eventType string, userID uuid.UUID) {
+	l.mu.Lock()
+	n := int64(len(l.events) + 1)
+	payload, _ := json.Marshal(subscription.EventData{ID: uuid.New(), UserID: userID, ServiceName: "Netflix"})
+	l.events = append(l.events, subscription.StreamEvent{
+		Position: subscription.Position{TxID: 100 + n, EventID: n},
+		Event:    entity.OutboxEvent{ID: n, Type: eventType, Payload: payload},
+	})
+	l.mu.Unlock()
+	l.appended <- struct{}{}
+}
+
+func (l *memoryEventLog) Head(context.Context) (subscription.Position, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.events) == 0 {
+		return subscription.Position{}, nil
+	}
+	return l.events[len(l.events)-1].Position, nil
+}
+
+func (l *memoryEventLog) ReadAfter(_ context.Context, pos subscription.Position, filter subscription.StreamFilter, limit int) ([]subscription.StreamEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []subscription.StreamEvent
+	for _, e := range l.events {
+		if e.Position.EventID <= pos.EventID || len(out) == limit {
+			continue
+		}
+		var data subscription.EventData
+		_ = json.Unmarshal(e.Event.Payload, &data)
+		if filter.UserID != nil && data.UserID != *filter.UserID {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (l *memoryEventLog) Listen(ctx context.Context, notify func()) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.appended:
+			notify()
+		}
+	}
+}
+
+func TestSubscriptionEvents(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	log := &memoryEventLog{appended: make(chan struct{}, 16)}
+	stream := subscription.NewStream(log, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stream.Run(ctx)
+
+	handler := NewHandler(subscription.NewService(memory.NewSubscriptionRepo(), logger), logger, WithEventStream(stream))
+	handler.eventsHeartbeat = 50 * time.Millisecond
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	alice, bob := uuid.New(), uuid.New()
+	log.append(entity.EventSubscriptionCreated, alice)
+	log.append(entity.EventSubscriptionCreated, bob)
+
+	open := func(target, lastEventID string) (*http.Response, <-chan string) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+target, nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("open stream: %v", err)
+		}
+		lines := make(chan string)
+		go func() {
+			defer close(lines)
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+		}()
+		return resp, lines
+	}
+	expect := func(lines <-chan string, prefix string) string {
+		t.Helper()
+		timeout := time.After(2 * time.Second)
+		for {
+			select {
+			case line := <-lines:
+				if strings.HasPrefix(line, prefix) {
+					return line
+				}
+			case <-timeout:
+				t.Fatalf("no line starting with %q", prefix)
+			}
+		}
+	}
+
+	resp, lines := open(v2Prefix+"/subscriptions/events?user_id="+alice.String(), "0-0")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// The backlog after Last-Event-ID is replayed, filtered by user.
+	if got := expect(lines, "id: "); got != "id: 101-1" {
+		t.Fatalf("unexpected first event: %q", got)
+	}
+	if got := expect(lines, "data: "); !strings.Contains(got, `"type":"subscription.created"`) {
+		t.Fatalf("unexpected data: %q", got)
+	}
+
+	// New events are pushed once the log notifies; bob's are skipped.
+	log.append(entity.EventSubscriptionUpdated, bob)
+	log.append(entity.EventSubscriptionDeleted, alice)
+	if got := expect(lines, "id: "); got != "id: 104-4" {
+		t.Fatalf("unexpected live event: %q", got)
+	}
+	expect(lines, ": heartbeat")
+
+	// Without Last-Event-ID the stream starts at the head of the log.
+	legacy, legacyLines := open("/api/subscriptions/events", "")
+	defer legacy.Body.Close()
+	if legacy.Header.Get("Deprecation") == "" {
+		t.Fatal("expected the unversioned stream to be deprecated")
+	}
+	log.append(entity.EventSubscriptionCreated, bob)
+	if got := expect(legacyLines, "id: "); got != "id: 105-5" {
+		t.Fatalf("unexpected event after head: %q", got)
+	}
+
+	bad, _ := open(v2Prefix+"/subscriptions/events", "garbage")
+	defer bad.Body.Close()
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected status for invalid Last-Event-ID: %d", bad.StatusCode)
+	}
+}
@@ -2,43 +2,221 @@ package http
 
 import (
 	"encoding/json"
-	httpSwagger "github.com/swaggo/http-swagger/v2"
 	"log/slog"
 	"net/http"
+	"time"
+
+	httpSwagger "github.com/swaggo/http-swagger/v2"
 
+	"restservice/internal/ratelimit"
+	"restservice/internal/usecase/catalog"
 	"restservice/internal/usecase/subscription"
+	"restservice/internal/usecase/user"
+	"restservice/internal/usecase/webhook"
 )
 
 const (
-	subscriptionsPath = "/api/subscriptions"
-	summaryPath       = "/api/subscriptions/summary"
+	apiPath = "/api/"
+
+	// legacyPrefix serves the original unversioned contract, which is
+	// identical to v1.
+	legacyPrefix = "/api"
+	v1Prefix     = "/api/v1"
+	v2Prefix     = "/api/v2"
+
+	subscriptionsPath = legacyPrefix + "/subscriptions"
+	summaryPath       = legacyPrefix + "/subscriptions/summary"
 	swaggerPath       = "/swagger/"
 	dateLayout        = "01-2006"
 )
 
 type Handler struct {
-	service *subscription.Service
-	logger  *slog.Logger
+	service   *subscription.Service
+	logger    *slog.Logger
+	validator *requestValidator
+	cors      *corsPolicy
+	limiter   ratelimit.Limiter
+	webhooks  *webhook.Service
+	users     *user.Service
+	catalog   *catalog.Service
+	events    *subscription.Stream
+
+	rateLimits           RateLimits
+	eventsHeartbeat      time.Duration
+	readYourWritesWindow time.Duration
 }
 
-func NewHandler(service *subscription.Service, logger *slog.Logger) *Handler {
-	return &Handler{service: service, logger: logger}
+// Option configures optional Handler behaviour.
+type Option func(*Handler)
+
+// WithWebhooks serves the webhooks resource backed by service.
+func WithWebhooks(service *webhook.Service) Option {
+	return func(h *Handler) { h.webhooks = service }
+}
+
+// WithUsers serves the users resource backed by service, together with
+// the per-user subscription list and summary.
+func WithUsers(service *user.Service) Option {
+	return func(h *Handler) { h.users = service }
+}
+
+// WithCatalog serves the services catalog backed by service.
+func WithCatalog(service *catalog.Service) Option {
+	return func(h *Handler) { h.catalog = service }
+}
+
+// WithEventStream serves the subscription change stream from stream.
+func WithEventStream(stream *subscription.Stream) Option {
+	return func(h *Handler) { h.events = stream }
+}
+
+func NewHandler(service *subscription.Service, logger *slog.Logger, opts ...Option) *Handler {
+	validator, err := newRequestValidator()
+	if err != nil {
+		// The spec is embedded at build time, so this only fails if the
+		// binary itself is broken.
+		panic(err)
+	}
+	h := &Handler{
+		service:         service,
+		logger:          logger,
+		validator:       validator,
+		eventsHeartbeat: defaultEventsHeartbeat,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 func (h *Handler) Register(mux *http.ServeMux) {
-	mux.HandleFunc(summaryPath, h.handleSummary)
-	mux.HandleFunc(subscriptionsPath, h.handleSubscriptions)
-	mux.HandleFunc(subscriptionsPath+"/", h.handleSubscriptionByID)
+	var all []route
+	for _, g := range h.apiRoutes() {
+		h.registerRoutes(mux, g.routes, g.wrap)
+		all = append(all, g.routes...)
+	}
+
+	specRoutes := []route{
+		{http.MethodGet, openapiJSONPath, h.handleOpenAPIJSON, rateDefault},
+		{http.MethodGet, openapiYAMLPath, h.handleOpenAPIYAML, rateDefault},
+		{http.MethodGet, swaggerPath + "swagger.json", h.handleOpenAPIJSON, rateDefault},
+		{http.MethodGet, swaggerPath + "swagger.yaml", h.handleOpenAPIYAML, rateDefault},
+	}
+	h.registerRoutes(mux, specRoutes, nil)
+	h.checkRateRoutes(append(all, specRoutes...))
+
+	mux.Handle("GET "+swaggerPath, httpSwagger.Handler(httpSwagger.URL(openapiJSONPath)))
+	mux.HandleFunc(apiPath, h.corsHeaders(h.handleNotFound))
+}
 
-	mux.HandleFunc("/swagger/swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "./Swagger/swagger.yaml")
-	})
+// routeGroup is a set of routes registered with the same middleware.
+type routeGroup struct {
+	routes []route
+	wrap   middleware
+}
+
+// apiRoutes are the routes of the API contract. Each of them must be an
+// operation in openapi.yaml and the other way round, which
+// TestRoutesMatchSpec checks against this same list.
+func (h *Handler) apiRoutes() []routeGroup {
+	deprecated := chain(h.deprecated, h.validate)
+	groups := []routeGroup{
+		{h.v1Routes(legacyPrefix), deprecated},
+		{h.v1Routes(v1Prefix), deprecated},
+		{h.v2Routes(v2Prefix), h.validate},
+	}
+	if h.events != nil {
+		groups = append(groups,
+			routeGroup{h.eventRoutes(legacyPrefix), deprecated},
+			routeGroup{h.eventRoutes(v1Prefix), deprecated},
+			routeGroup{h.eventRoutes(v2Prefix), h.validate},
+		)
+	}
+	// Users are new in v2 and are not added to v1. They are also served
+	// under the unversioned prefix, in the v2 format and not deprecated,
+	// where /api/users/{id}/subscriptions was first asked for.
+	if h.users != nil {
+		groups = append(groups,
+			routeGroup{h.userRoutes(legacyPrefix), h.validate},
+			routeGroup{h.userRoutes(v2Prefix), h.validate},
+		)
+	}
+	if h.catalog != nil {
+		groups = append(groups, routeGroup{h.catalogRoutes(v2Prefix), h.validate})
+	}
+	if h.webhooks != nil {
+		groups = append(groups, routeGroup{h.webhookRoutes(v2Prefix), h.validate})
+	}
+	return groups
+}
 
-	mux.HandleFunc("/swagger/swagger.json", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "./Swagger/swagger.yaml")
-	})
+func (h *Handler) v1Routes(prefix string) []route {
+	return []route{
+		{http.MethodGet, prefix + "/subscriptions", h.handleListSubscriptions, rateDefault},
+		{http.MethodPost, prefix + "/subscriptions", h.handleCreateSubscription, rateDefault},
+		{http.MethodGet, prefix + "/subscriptions/summary", h.handleSummary, rateHeavy},
+		{http.MethodGet, prefix + "/subscriptions/{id}", h.handleGetSubscription, rateRead},
+		{http.MethodPut, prefix + "/subscriptions/{id}", h.handleUpdateSubscription, rateDefault},
+		{http.MethodDelete, prefix + "/subscriptions/{id}", h.handleDeleteSubscription, rateDefault},
+	}
+}
 
-	mux.Handle(swaggerPath, httpSwagger.WrapHandler)
+func (h *Handler) v2Routes(prefix string) []route {
+	return []route{
+		{http.MethodGet, prefix + "/subscriptions", h.handleListSubscriptionsV2, rateDefault},
+		{http.MethodPost, prefix + "/subscriptions", h.handleCreateSubscriptionV2, rateDefault},
+		{http.MethodGet, prefix + "/subscriptions/summary", h.handleSummaryV2, rateHeavy},
+		{http.MethodGet, prefix + "/subscriptions/{id}", h.handleGetSubscriptionV2, rateRead},
+		{http.MethodPut, prefix + "/subscriptions/{id}", h.handleUpdateSubscriptionV2, rateDefault},
+		{http.MethodDelete, prefix + "/subscriptions/{id}", h.handleDeleteSubscription, rateDefault},
+		{http.MethodPost, prefix + "/subscriptions/{id}/pause", h.handleTransitionV2("pause", h.service.Pause), rateDefault},
+		{http.MethodPost, prefix + "/subscriptions/{id}/resume", h.handleTransitionV2("resume", h.service.Resume), rateDefault},
+		{http.MethodPost, prefix + "/subscriptions/{id}/cancel", h.handleTransitionV2("cancel", h.service.Cancel), rateDefault},
+	}
+}
+
+// eventRoutes are the same for every version: the stream carries the
+// version-neutral event payload.
+func (h *Handler) eventRoutes(prefix string) []route {
+	return []route{
+		{http.MethodGet, prefix + "/subscriptions/events", h.handleSubscriptionEvents, rateDefault},
+	}
+}
+
+// userRoutes serve the same users in every version; only the nested
+// subscription list and summary use the version's format, through list
+// and summary.
+func (h *Handler) userRoutes(prefix string) []route {
+	return []route{
+		{http.MethodGet, prefix + "/users", h.handleListUsers, rateDefault},
+		{http.MethodPost, prefix + "/users", h.handleCreateUser, rateDefault},
+		{http.MethodGet, prefix + "/users/{id}", h.handleGetUser, rateRead},
+		{http.MethodPut, prefix + "/users/{id}", h.handleUpdateUser, rateDefault},
+		{http.MethodDelete, prefix + "/users/{id}", h.handleDeleteUser, rateDefault},
+		{http.MethodGet, prefix + "/users/{id}/subscriptions", h.forUser(h.handleListSubscriptionsV2), rateDefault},
+		{http.MethodGet, prefix + "/users/{id}/summary", h.forUser(h.handleSummaryV2), rateHeavy},
+	}
+}
+
+func (h *Handler) catalogRoutes(prefix string) []route {
+	return []route{
+		{http.MethodGet, prefix + "/services", h.handleListServices, rateDefault},
+		{http.MethodPost, prefix + "/services", h.handleCreateService, rateDefault},
+		{http.MethodGet, prefix + "/services/{id}", h.handleGetService, rateRead},
+		{http.MethodPut, prefix + "/services/{id}", h.handleUpdateService, rateDefault},
+		{http.MethodDelete, prefix + "/services/{id}", h.handleDeleteService, rateDefault},
+	}
+}
+
+func (h *Handler) webhookRoutes(prefix string) []route {
+	return []route{
+		{http.MethodGet, prefix + "/webhooks", h.handleListWebhooks, rateDefault},
+		{http.MethodPost, prefix + "/webhooks", h.handleCreateWebhook, rateDefault},
+		{http.MethodGet, prefix + "/webhooks/{id}", h.handleGetWebhook, rateRead},
+		{http.MethodDelete, prefix + "/webhooks/{id}", h.handleDeleteWebhook, rateDefault},
+		{http.MethodGet, prefix + "/webhooks/{id}/deliveries", h.handleListWebhookDeliveries, rateDefault},
+		{http.MethodPost, prefix + "/webhooks/{id}/deliveries/{delivery_id}/redeliver", h.handleRedeliverWebhook, rateDefault},
+	}
 }
 
 func (h *Handler) writeJSON(w http.ResponseWriter, status int, payload any) {
@@ -3,36 +3,16 @@ package http
 import (
 	"errors"
 	"net/http"
-	"strings"
-
-	"github.com/google/uuid"
 
 	"restservice/internal/usecase/subscription"
 )
 
-// DeleteSubscription godoc
-// @Summary Удалить подписку
-// @Description Удаляет подписку по идентификатору.
-// @Tags subscriptions
-// @Accept json
-// @Produce json
-// @Param id path string true "ID подписки"
-// @Success 204
-// @Failure 400 {object} errorResponse
-// @Failure 404 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Router /api/subscriptions/{id} [delete]
+// handleDeleteSubscription serves DELETE /api/subscriptions/{id}.
 func (h *Handler) handleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("delete subscription request", "method", r.Method, "path", r.URL.Path)
-	id := strings.TrimPrefix(r.URL.Path, subscriptionsPath+"/")
-	if strings.TrimSpace(id) == "" {
-		h.writeError(w, http.StatusBadRequest, "missing id")
-		return
-	}
-
-	subID, err := uuid.Parse(id)
+	subID, err := parseSubscriptionID(r)
 	if err != nil {
-		h.writeError(w, http.StatusBadRequest, "invalid id")
+		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"restservice/internal/usecase/subscription"
+)
+
+const (
+	defaultEventsHeartbeat = 15 * time.Second
+	// eventsRetry is the reconnect delay suggested to EventSource clients.
+	eventsRetry = 3 * time.Second
+)
+
+// streamEvent is the data of every SSE message, shaped like a webhook body.
+type streamEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// handleSubscriptionEvents serves GET /api/subscriptions/events as a
+// Server-Sent Events stream. It resumes after Last-Event-ID when given and
+// otherwise starts at the current head of the log.
+func (h *Handler) handleSubscriptionEvents(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("subscription events request", "method", r.Method, "path", r.URL.Path)
+	filter, err := parseSubscriptionFilter(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	var pos subscription.Position
+	if raw := strings.TrimSpace(r.Header.Get("Last-Event-ID")); raw != "" {
+		if pos, err = subscription.ParsePosition(raw); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+	} else if pos, err = h.events.Head(ctx); err != nil {
+		h.logger.Error("subscription events failed", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The server write timeout is meant for ordinary responses and would
+	// cut the stream off.
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", eventsRetry.Milliseconds()); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.eventsHeartbeat)
+	defer heartbeat.Stop()
+	streamFilter := subscription.StreamFilter{UserID: filter.UserID, ServiceName: filter.ServiceName}
+	for {
+		changed := h.events.Changed()
+		for {
+			events, err := h.events.ReadAfter(ctx, pos, streamFilter)
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Error("subscription events failed", "error", err)
+				}
+				return
+			}
+			if len(events) == 0 {
+				break
+			}
+			for _, e := range events {
+				if err := writeStreamEvent(w, e); err != nil {
+					return
+				}
+				pos = e.Position
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.events.Done():
+			return
+		case <-changed:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, e subscription.StreamEvent) error {
+	data, err := json.Marshal(streamEvent{
+		ID:        e.Event.ID,
+		Type:      e.Event.Type,
+		CreatedAt: e.Event.CreatedAt.UTC(),
+		Data:      e.Event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.Position, e.Event.Type, data)
+	return err
+}
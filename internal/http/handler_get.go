@@ -3,36 +3,16 @@ package http
 import (
 	"errors"
 	"net/http"
-	"strings"
-
-	"github.com/google/uuid"
 
 	"restservice/internal/usecase/subscription"
 )
 
-// GetSubscription godoc
-// @Summary Получить подписку
-// @Description Возвращает подписку по идентификатору.
-// @Tags subscriptions
-// @Accept json
-// @Produce json
-// @Param id path string true "ID подписки"
-// @Success 200 {object} subscriptionResponse
-// @Failure 400 {object} errorResponse
-// @Failure 404 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Router /api/subscriptions/{id} [get]
+// handleGetSubscription serves GET /api/subscriptions/{id}.
 func (h *Handler) handleGetSubscription(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("get subscription request", "method", r.Method, "path", r.URL.Path)
-	id := strings.TrimPrefix(r.URL.Path, subscriptionsPath+"/")
-	if strings.TrimSpace(id) == "" {
-		h.writeError(w, http.StatusBadRequest, "missing id")
-		return
-	}
-
-	uid, err := uuid.Parse(id)
+	uid, err := parseSubscriptionID(r)
 	if err != nil {
-		h.writeError(w, http.StatusBadRequest, "invalid id")
+		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -46,5 +26,5 @@ func (h *Handler) handleGetSubscription(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, sub)
+	h.writeCacheable(w, r, toSubscriptionResponse(sub), sub.UpdatedAt)
 }
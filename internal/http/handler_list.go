@@ -2,52 +2,31 @@ package http
 
 import (
 	"net/http"
-	"strings"
-
-	"github.com/google/uuid"
+	"time"
 
 	"restservice/internal/usecase/subscription"
 )
 
-// ListSubscriptions godoc
-// @Summary Список подписок
-// @Description Возвращает список подписок с фильтрами.
-// @Tags subscriptions
-// @Accept json
-// @Produce json
-// @Param user_id query string false "ID пользователя"
-// @Param service_name query string false "Название сервиса"
-// @Success 200 {array} subscriptionResponse
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Router /api/subscriptions [get]
+// handleListSubscriptions serves GET /api/subscriptions.
 func (h *Handler) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("list subscriptions request", "method", r.Method, "path", r.URL.Path)
-	query := r.URL.Query()
-	userID := strings.TrimSpace(query.Get("user_id"))
-	serviceName := strings.TrimSpace(query.Get("service_name"))
-
-	var (
-		uid  *uuid.UUID
-		name *string
-	)
-
-	if userID != "" {
-		parsed, err := uuid.Parse(userID)
-		if err != nil {
-			h.writeError(w, http.StatusBadRequest, "invalid user_id")
-			return
-		}
-		uid = &parsed
+	filter, err := parseSubscriptionFilter(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if serviceName != "" {
-		name = &serviceName
+	limit, offset, err := parsePage(r.URL.Query())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	items, err := h.service.List(r.Context(), subscription.ListFilter{
-		UserID:      uid,
-		ServiceName: name,
+		UserID:      filter.UserID,
+		ServiceName: filter.ServiceName,
+		Limit:       limit,
+		Offset:      offset,
 	})
 	if err != nil {
 		h.writeError(w, http.StatusInternalServerError, "internal error")
@@ -59,5 +38,5 @@ func (h *Handler) handleListSubscriptions(w http.ResponseWriter, r *http.Request
 		resp = append(resp, toSubscriptionResponse(item))
 	}
 
-	h.writeJSON(w, http.StatusOK, resp)
+	h.writeCacheable(w, r, resp, time.Time{})
 }
@@ -22,7 +22,13 @@ type subscriptionResponse struct {
 }
 
 type errorResponse struct {
-	Error string `json:"error"`
+	Error  string       `json:"error"`
+	Fields []fieldError `json:"fields,omitempty"`
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type summaryResponse struct {
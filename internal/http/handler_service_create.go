@@ -0,0 +1,48 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"restservice/internal/usecase/catalog"
+)
+
+// handleCreateService serves POST /api/v2/services.
+func (h *Handler) handleCreateService(w http.ResponseWriter, r *http.Request) {
+	var req serviceRequest
+	h.logger.Info("create service request", "method", r.Method, "path", r.URL.Path)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.logger.Info("create service decode failed", "error", err)
+		h.writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	svc, err := req.toEntity()
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := h.catalog.Create(r.Context(), svc)
+	if err != nil {
+		h.writeCatalogError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, toServiceResponse(created))
+}
+
+// writeCatalogError answers the errors the catalog service returns.
+func (h *Handler) writeCatalogError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, catalog.ErrValidation):
+		h.writeError(w, http.StatusBadRequest, strings.TrimPrefix(err.Error(), catalog.ErrValidation.Error()+": "))
+	case errors.Is(err, catalog.ErrNotFound):
+		h.writeError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, catalog.ErrConflict):
+		h.writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
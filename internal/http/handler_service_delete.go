@@ -0,0 +1,22 @@
+package http
+
+import (
+	"net/http"
+)
+
+// handleDeleteService serves DELETE /api/v2/services/{id}.
+func (h *Handler) handleDeleteService(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("delete service request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.catalog.Delete(r.Context(), id); err != nil {
+		h.writeCatalogError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
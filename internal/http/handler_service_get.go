@@ -0,0 +1,23 @@
+package http
+
+import (
+	"net/http"
+)
+
+// handleGetService serves GET /api/v2/services/{id}.
+func (h *Handler) handleGetService(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("get service request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	svc, err := h.catalog.Get(r.Context(), id)
+	if err != nil {
+		h.writeCatalogError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toServiceResponse(svc))
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+
+	"restservice/internal/usecase/catalog"
+)
+
+// handleListServices serves GET /api/v2/services.
+func (h *Handler) handleListServices(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("list services request", "method", r.Method, "path", r.URL.Path)
+	items, err := h.catalog.List(r.Context(), catalog.ListFilter{Category: r.URL.Query().Get("category")})
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := serviceListResponse{Data: make([]serviceResponse, 0, len(items))}
+	for _, item := range items {
+		resp.Data = append(resp.Data, toServiceResponse(item))
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"errors"
+	"time"
+
+	"restservice/internal/entity"
+)
+
+type serviceAliasJSON struct {
+	Pattern string `json:"pattern"`
+	Match   string `json:"match"`
+}
+
+type serviceRequest struct {
+	Name      string `json:"name"`
+	Category  string `json:"category,omitempty"`
+	VendorURL string `json:"vendor_url,omitempty"`
+	// DefaultPrice is null or omitted for services without one.
+	DefaultPrice *moneyV2           `json:"default_price,omitempty"`
+	Aliases      []serviceAliasJSON `json:"aliases,omitempty"`
+}
+
+type serviceResponse struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Category     *string            `json:"category"`
+	VendorURL    *string            `json:"vendor_url"`
+	DefaultPrice *moneyV2           `json:"default_price"`
+	Aliases      []serviceAliasJSON `json:"aliases"`
+	CreatedAt    string             `json:"created_at"`
+	UpdatedAt    string             `json:"updated_at"`
+}
+
+type serviceListResponse struct {
+	Data []serviceResponse `json:"data"`
+}
+
+func (req serviceRequest) toEntity() (entity.Service, error) {
+	svc := entity.Service{
+		Name:      req.Name,
+		Category:  req.Category,
+		VendorURL: req.VendorURL,
+	}
+	if req.DefaultPrice != nil {
+		if req.DefaultPrice.Currency != currencyRUB {
+			return entity.Service{}, errors.New("unsupported currency")
+		}
+		svc.DefaultPrice = req.DefaultPrice.Amount
+	}
+	for _, alias := range req.Aliases {
+		svc.Aliases = append(svc.Aliases, entity.ServiceAlias{Pattern: alias.Pattern, Match: entity.AliasMatch(alias.Match)})
+	}
+	return svc, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func toServiceResponse(svc entity.Service) serviceResponse {
+	resp := serviceResponse{
+		ID:        svc.ID.String(),
+		Name:      svc.Name,
+		Category:  optionalString(svc.Category),
+		VendorURL: optionalString(svc.VendorURL),
+		Aliases:   make([]serviceAliasJSON, 0, len(svc.Aliases)),
+		CreatedAt: svc.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: svc.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if svc.DefaultPrice > 0 {
+		resp.DefaultPrice = &moneyV2{Amount: svc.DefaultPrice, Currency: currencyRUB}
+	}
+	for _, alias := range svc.Aliases {
+		resp.Aliases = append(resp.Aliases, serviceAliasJSON{Pattern: alias.Pattern, Match: string(alias.Match)})
+	}
+	return resp
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+)
+
+// handleUpdateService serves PUT /api/v2/services/{id}. The aliases in the
+// body replace the stored ones.
+func (h *Handler) handleUpdateService(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("update service request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req serviceRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.logger.Info("update service decode failed", "error", err)
+		h.writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	svc, err := req.toEntity()
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := h.catalog.Update(r.Context(), id, svc)
+	if err != nil {
+		h.writeCatalogError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toServiceResponse(updated))
+}
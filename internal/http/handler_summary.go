@@ -2,82 +2,30 @@ package http
 
 import (
 	"net/http"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
-
+	"restservice/internal/entity"
 	"restservice/internal/usecase/subscription"
 )
 
-// SummarySubscriptions godoc
-// @Summary Сумма подписок
-// @Description Считает стоимость подписок за период с фильтрацией.
-// @Tags subscriptions
-// @Accept json
-// @Produce json
-// @Param user_id query string false "ID пользователя"
-// @Param service_name query string false "Название сервиса"
-// @Param start_date query string true "Дата начала (MM-YYYY)"
-// @Param end_date query string true "Дата окончания (MM-YYYY)"
-// @Success 200 {object} summaryResponse
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Router /api/subscriptions/summary [get]
+// handleSummary serves GET /api/subscriptions/summary.
 func (h *Handler) handleSummary(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("summary subscriptions request", "method", r.Method, "path", r.URL.Path)
-	query := r.URL.Query()
-	userID := strings.TrimSpace(query.Get("user_id"))
-	serviceName := strings.TrimSpace(query.Get("service_name"))
-	startDateRaw := strings.TrimSpace(query.Get("start_date"))
-	endDateRaw := strings.TrimSpace(query.Get("end_date"))
-
-	if startDateRaw == "" || endDateRaw == "" {
-		h.writeError(w, http.StatusBadRequest, "start_date and end_date are required")
-		return
-	}
-
-	startParsed, err := time.Parse(dateLayout, startDateRaw)
+	startDate, endDate, err := summaryPeriod(r.URL.Query(), entity.ParseMonthYear)
 	if err != nil {
-		h.writeError(w, http.StatusBadRequest, "invalid start_date")
+		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	endParsed, err := time.Parse(dateLayout, endDateRaw)
+	filter, err := parseSubscriptionFilter(r)
 	if err != nil {
-		h.writeError(w, http.StatusBadRequest, "invalid end_date")
-		return
-	}
-
-	startDate := time.Date(startParsed.Year(), startParsed.Month(), 1, 0, 0, 0, 0, time.UTC)
-	endDate := time.Date(endParsed.Year(), endParsed.Month()+1, 0, 0, 0, 0, 0, time.UTC)
-
-	if endDate.Before(startDate) {
-		h.writeError(w, http.StatusBadRequest, "end_date before start_date")
+		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	var (
-		uid  *uuid.UUID
-		name *string
-	)
-
-	if userID != "" {
-		parsed, err := uuid.Parse(userID)
-		if err != nil {
-			h.writeError(w, http.StatusBadRequest, "invalid user_id")
-			return
-		}
-		uid = &parsed
-	}
-
-	if serviceName != "" {
-		name = &serviceName
-	}
-
 	total, err := h.service.Sum(r.Context(), subscription.SummaryFilter{
-		UserID:      uid,
-		ServiceName: name,
+		UserID:      filter.UserID,
+		ServiceName: filter.ServiceName,
 		StartDate:   startDate,
 		EndDate:     endDate,
 	})
@@ -86,5 +34,5 @@ func (h *Handler) handleSummary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, summaryResponse{Total: total})
+	h.writeCacheable(w, r, summaryResponse{Total: total}, time.Time{})
 }
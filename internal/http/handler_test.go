@@ -2,10 +2,10 @@ package http
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,98 +14,26 @@ import (
 	"github.com/google/uuid"
 
 	"restservice/internal/entity"
+	"restservice/internal/repo/memory"
 	"restservice/internal/usecase/subscription"
 )
 
-type memoryRepo struct {
-	items map[uuid.UUID]entity.Subscription
-}
-
-func newMemoryRepo() *memoryRepo {
-	return &memoryRepo{items: make(map[uuid.UUID]entity.Subscription)}
-}
-
-func (r *memoryRepo) Create(ctx context.Context, s entity.Subscription) (entity.Subscription, error) {
-	if s.ID == uuid.Nil {
-		s.ID = uuid.New()
-	}
-	r.items[s.ID] = s
-	return s, nil
-}
-
-func (r *memoryRepo) Get(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
-	item, ok := r.items[id]
-	if !ok {
-		return entity.Subscription{}, subscription.ErrNotFound
-	}
-	return item, nil
-}
-
-func (r *memoryRepo) List(ctx context.Context, filter subscription.ListFilter) ([]entity.Subscription, error) {
-	result := make([]entity.Subscription, 0)
-	for _, item := range r.items {
-		if filter.UserID != nil && item.UserID != *filter.UserID {
-			continue
-		}
-		if filter.ServiceName != nil && item.ServiceName != *filter.ServiceName {
-			continue
-		}
-		result = append(result, item)
-	}
-	return result, nil
-}
-
-func (r *memoryRepo) Update(ctx context.Context, id uuid.UUID, s entity.Subscription) (entity.Subscription, error) {
-	if _, ok := r.items[id]; !ok {
-		return entity.Subscription{}, subscription.ErrNotFound
-	}
-	s.ID = id
-	r.items[id] = s
-	return s, nil
-}
-
-func (r *memoryRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	if _, ok := r.items[id]; !ok {
-		return subscription.ErrNotFound
-	}
-	delete(r.items, id)
-	return nil
-}
-
-func (r *memoryRepo) Sum(ctx context.Context, filter subscription.SummaryFilter) (int, error) {
-	total := 0
-	for _, item := range r.items {
-		if filter.UserID != nil && item.UserID != *filter.UserID {
-			continue
-		}
-		if filter.ServiceName != nil && item.ServiceName != *filter.ServiceName {
-			continue
-		}
-		if item.StartDate.After(filter.EndDate) {
-			continue
-		}
-		if item.EndDate != nil && item.EndDate.Before(filter.StartDate) {
-			continue
-		}
-		total += item.Price
-	}
-	return total, nil
-}
-
-func newTestHandler(repo *memoryRepo) *Handler {
+func newTestHandler(repo subscription.Repository) *Handler {
 	logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	service := subscription.NewService(repo, logger)
 	return NewHandler(service, logger)
 }
 
 func TestCreateSubscription(t *testing.T) {
-	repo := newMemoryRepo()
+	userID := uuid.New()
+	repo := memory.NewSubscriptionRepo()
+	repo.AddUsers(entity.User{ID: userID})
 	handler := newTestHandler(repo)
 
 	body := subscriptionRequest{
 		ServiceName: "Yandex Plus",
 		Price:       400,
-		UserID:      uuid.New().String(),
+		UserID:      userID.String(),
 		StartDate:   "07-2025",
 	}
 	payload, err := json.Marshal(body)
@@ -145,25 +73,25 @@ func TestCreateSubscription(t *testing.T) {
 }
 
 func TestSummarySubscriptions(t *testing.T) {
-	repo := newMemoryRepo()
-	handler := newTestHandler(repo)
-
 	userID := uuid.New()
-	repo.items[uuid.New()] = entity.Subscription{
-		ID:          uuid.New(),
-		ServiceName: "Netflix",
-		Price:       500,
-		UserID:      userID,
-		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
-	}
-	repo.items[uuid.New()] = entity.Subscription{
-		ID:          uuid.New(),
-		ServiceName: "Spotify",
-		Price:       300,
-		UserID:      userID,
-		StartDate:   time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC),
-		EndDate:     func() *time.Time { t := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC); return &t }(),
-	}
+	repo := memory.NewSubscriptionRepo(
+		entity.Subscription{
+			ID:          uuid.New(),
+			ServiceName: "Netflix",
+			Price:       500,
+			UserID:      userID,
+			StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		},
+		entity.Subscription{
+			ID:          uuid.New(),
+			ServiceName: "Spotify",
+			Price:       300,
+			UserID:      userID,
+			StartDate:   time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:     func() *time.Time { t := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC); return &t }(),
+		},
+	)
+	handler := newTestHandler(repo)
 
 	req := httptest.NewRequest(http.MethodGet, summaryPath+"?start_date=07-2025&end_date=08-2025&user_id="+userID.String(), nil)
 	w := httptest.NewRecorder()
@@ -179,7 +107,75 @@ func TestSummarySubscriptions(t *testing.T) {
 		t.Fatalf("decode response: %v", err)
 	}
 
-	if resp.Total != 800 {
+	// Netflix for July and August, Spotify for August.
+	if resp.Total != 2*500+300 {
 		t.Fatalf("unexpected total: %d", resp.Total)
 	}
 }
+
+func TestSummaryV2GroupsByTag(t *testing.T) {
+	userID := uuid.New()
+	start := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	repo := memory.NewSubscriptionRepo(
+		entity.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 500, UserID: userID, StartDate: start, Tags: []string{"family", "video"}},
+		entity.Subscription{ID: uuid.New(), ServiceName: "Spotify", Price: 300, UserID: userID, StartDate: start, Tags: []string{"family"}},
+		entity.Subscription{ID: uuid.New(), ServiceName: "Cloud", Price: 100, UserID: userID, StartDate: start},
+	)
+	mux := http.NewServeMux()
+	newTestHandler(repo).Register(mux)
+
+	req := httptest.NewRequest(http.MethodGet, v2Prefix+"/subscriptions/summary?start_date=2025-07-01&end_date=2025-07-31&group_by=tag", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var resp summaryResponseV2
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Total.Amount != 900 {
+		t.Fatalf("unexpected total: %d", resp.Total.Amount)
+	}
+	got := make(map[string]int)
+	for _, g := range resp.Groups {
+		key := "<untagged>"
+		if g.Key != nil {
+			key = *g.Key
+		}
+		got[key] = g.Total.Amount
+	}
+	want := map[string]int{"<untagged>": 100, "family": 800, "video": 500}
+	if !maps.Equal(got, want) {
+		t.Fatalf("unexpected groups: %v, want %v", got, want)
+	}
+}
+
+func TestUpdateV1KeepsLabels(t *testing.T) {
+	id, userID := uuid.New(), uuid.New()
+	repo := memory.NewSubscriptionRepo(entity.Subscription{
+		ID: id, ServiceName: "Netflix", Price: 500, UserID: userID,
+		StartDate: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		Category:  "video", Tags: []string{"family"},
+	})
+	mux := http.NewServeMux()
+	newTestHandler(repo).Register(mux)
+
+	body := `{"service_name":"Netflix","price":600,"user_id":"` + userID.String() + `","start_date":"07-2025"}`
+	req := httptest.NewRequest(http.MethodPut, v1Prefix+"/subscriptions/"+id.String(), bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d, body: %s", w.Code, w.Body.String())
+	}
+
+	got, err := repo.Get(req.Context(), id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Price != 600 || got.Category != "video" || len(got.Tags) != 1 || got.Tags[0] != "family" {
+		t.Fatalf("labels not kept: %+v", got)
+	}
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"restservice/internal/usecase/user"
+)
+
+// handleCreateUser serves POST /api/v2/users.
+func (h *Handler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	h.logger.Info("create user request", "method", r.Method, "path", r.URL.Path)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.logger.Info("create user decode failed", "error", err)
+		h.writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	created, err := h.users.Create(r.Context(), req.toEntity())
+	if err != nil {
+		h.writeUserError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, toUserResponse(created))
+}
+
+// writeUserError answers the errors the user service returns from its
+// writes and lookups.
+func (h *Handler) writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, user.ErrValidation):
+		h.writeError(w, http.StatusBadRequest, strings.TrimPrefix(err.Error(), user.ErrValidation.Error()+": "))
+	case errors.Is(err, user.ErrNotFound):
+		h.writeError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, user.ErrEmailTaken), errors.Is(err, user.ErrHasSubscriptions):
+		h.writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"net/http"
+)
+
+// handleDeleteUser serves DELETE /api/v2/users/{id}. Users that still have
+// subscriptions get a 409.
+func (h *Handler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("delete user request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.users.Delete(r.Context(), id); err != nil {
+		h.writeUserError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
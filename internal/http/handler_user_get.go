@@ -0,0 +1,23 @@
+package http
+
+import (
+	"net/http"
+)
+
+// handleGetUser serves GET /api/v2/users/{id}.
+func (h *Handler) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("get user request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	u, err := h.users.Get(r.Context(), id)
+	if err != nil {
+		h.writeUserError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toUserResponse(u))
+}
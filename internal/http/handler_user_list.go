@@ -0,0 +1,30 @@
+package http
+
+import (
+	"net/http"
+
+	"restservice/internal/usecase/user"
+)
+
+// handleListUsers serves GET /api/v2/users.
+func (h *Handler) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("list users request", "method", r.Method, "path", r.URL.Path)
+	limit, offset, err := parsePage(r.URL.Query())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, err := h.users.List(r.Context(), user.ListFilter{Limit: limit, Offset: offset})
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := userListResponse{Data: make([]userResponse, 0, len(items))}
+	for _, item := range items {
+		resp.Data = append(resp.Data, toUserResponse(item))
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"time"
+
+	"restservice/internal/entity"
+)
+
+type userRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	Currency string `json:"currency,omitempty"`
+}
+
+type userResponse struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Email     *string `json:"email"`
+	Timezone  string  `json:"timezone"`
+	Currency  string  `json:"currency"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+type userListResponse struct {
+	Data []userResponse `json:"data"`
+}
+
+func (req userRequest) toEntity() entity.User {
+	return entity.User{
+		Name:     req.Name,
+		Email:    req.Email,
+		Timezone: req.Timezone,
+		Currency: req.Currency,
+	}
+}
+
+func toUserResponse(u entity.User) userResponse {
+	resp := userResponse{
+		ID:        u.ID.String(),
+		Name:      u.Name,
+		Timezone:  u.Timezone,
+		Currency:  u.Currency,
+		CreatedAt: u.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: u.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if u.Email != "" {
+		email := u.Email
+		resp.Email = &email
+	}
+	return resp
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+)
+
+// forUser serves GET /api/v2/users/{id}/subscriptions and
+// /api/v2/users/{id}/summary by handing the request to the list or summary
+// handler with user_id set to the user from the path. Unknown users get
+// a 404 instead of an empty result.
+func (h *Handler) forUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := parsePathUUID(r, "id")
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if _, err := h.users.Get(r.Context(), id); err != nil {
+			h.writeUserError(w, err)
+			return
+		}
+
+		query := r.URL.Query()
+		query.Set("user_id", id.String())
+		r = r.Clone(r.Context())
+		r.URL.RawQuery = query.Encode()
+		next(w, r)
+	}
+}
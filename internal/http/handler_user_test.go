@@ -0,0 +1,201 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
+	"restservice/internal/usecase/user"
+)
+
+// userRepo keeps users in memory and enforces unique emails like the
+// Postgres repository.
+type userRepo struct {
+	mu    sync.Mutex
+	items map[uuid.UUID]entity.User
+}
+
+func newUserRepo(users ...entity.User) *userRepo {
+	r := &userRepo{items: make(map[uuid.UUID]entity.User)}
+	for _, u := range users {
+		r.items[u.ID] = u
+	}
+	return r
+}
+
+func (r *userRepo) emailTaken(id uuid.UUID, email string) bool {
+	for _, u := range r.items {
+		if u.ID != id && email != "" && strings.EqualFold(u.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *userRepo) Create(_ context.Context, u entity.User) (entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.emailTaken(uuid.Nil, u.Email) {
+		return entity.User{}, user.ErrEmailTaken
+	}
+	u.ID = uuid.New()
+	u.CreatedAt = time.Now().UTC()
+	u.UpdatedAt = u.CreatedAt
+	r.items[u.ID] = u
+	return u, nil
+}
+
+func (r *userRepo) Get(_ context.Context, id uuid.UUID) (entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.items[id]
+	if !ok {
+		return entity.User{}, user.ErrNotFound
+	}
+	return u, nil
+}
+
+func (r *userRepo) List(context.Context, user.ListFilter) ([]entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items := make([]entity.User, 0, len(r.items))
+	for _, u := range r.items {
+		items = append(items, u)
+	}
+	return items, nil
+}
+
+func (r *userRepo) Update(_ context.Context, id uuid.UUID, u entity.User) (entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.items[id]
+	if !ok {
+		return entity.User{}, user.ErrNotFound
+	}
+	if r.emailTaken(id, u.Email) {
+		return entity.User{}, user.ErrEmailTaken
+	}
+	u.ID, u.CreatedAt, u.UpdatedAt = id, existing.CreatedAt, time.Now().UTC()
+	r.items[id] = u
+	return u, nil
+}
+
+func (r *userRepo) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.items[id]; !ok {
+		return user.ErrNotFound
+	}
+	delete(r.items, id)
+	return nil
+}
+
+func TestUserRoutesConformToSpec(t *testing.T) {
+	router := loadSpecRouter(t)
+	f := newConformanceFixture()
+	alice := entity.User{ID: f.userID, Name: "Alice", Email: "alice@example.com", Timezone: "UTC", Currency: "RUB"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	users := user.NewService(newUserRepo(alice), logger)
+	handler := NewHandler(subscription.NewService(f.repo(), logger), logger, WithUsers(users))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	collection := v2Prefix + "/users"
+	byID := collection + "/" + f.userID.String()
+	missing := collection + "/" + f.missing.String()
+	period := "?start_date=2025-07-01&end_date=2025-12-31"
+
+	// The unversioned prefix serves the same resource, not deprecated; v1
+	// does not get it. Checked first, while Alice exists.
+	for _, tc := range []struct {
+		target string
+		status int
+	}{
+		{legacyPrefix + "/users/" + f.userID.String() + "/subscriptions", http.StatusOK},
+		{legacyPrefix + "/users/" + f.userID.String() + "/summary" + period, http.StatusOK},
+		{v1Prefix + "/users", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Fatalf("%s: unexpected status %d, body: %s", tc.target, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Deprecation") != "" {
+			t.Fatalf("%s: unexpected Deprecation header", tc.target)
+		}
+		if tc.status == http.StatusOK {
+			validateResponse(t, router, req, w)
+		}
+	}
+
+	for _, tc := range []conformanceCase{
+		{"create", http.MethodPost, collection, `{"name":"Bob","email":"bob@example.com","timezone":"Europe/Moscow","currency":"usd"}`, http.StatusCreated},
+		{"create defaults", http.MethodPost, collection, `{"name":"Carol"}`, http.StatusCreated},
+		{"create without name", http.MethodPost, collection, `{"email":"x@example.com"}`, http.StatusBadRequest},
+		{"create unknown timezone", http.MethodPost, collection, `{"name":"Dan","timezone":"Mars/Olympus"}`, http.StatusBadRequest},
+		{"create taken email", http.MethodPost, collection, `{"name":"Eve","email":"ALICE@example.com"}`, http.StatusConflict},
+		{"list", http.MethodGet, collection + "?limit=10", "", http.StatusOK},
+		{"get", http.MethodGet, byID, "", http.StatusOK},
+		{"get missing", http.MethodGet, missing, "", http.StatusNotFound},
+		{"update", http.MethodPut, byID, `{"name":"Alice B.","timezone":"Asia/Tokyo"}`, http.StatusOK},
+		{"update missing", http.MethodPut, missing, `{"name":"Nobody"}`, http.StatusNotFound},
+		{"subscriptions", http.MethodGet, byID + "/subscriptions", "", http.StatusOK},
+		{"subscriptions unknown user", http.MethodGet, missing + "/subscriptions", "", http.StatusNotFound},
+		{"summary", http.MethodGet, byID + "/summary" + period, "", http.StatusOK},
+		{"summary unknown user", http.MethodGet, missing + "/summary" + period, "", http.StatusNotFound},
+		{"delete", http.MethodDelete, byID, "", http.StatusNoContent},
+		{"delete missing", http.MethodDelete, missing, "", http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var body io.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			req := httptest.NewRequest(tc.method, tc.target, body)
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Fatalf("unexpected status: %d, body: %s", w.Code, w.Body.String())
+			}
+			validateResponse(t, router, req, w)
+		})
+	}
+
+}
+
+func TestUserSubscriptionsFilterByPathUser(t *testing.T) {
+	f := newConformanceFixture()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	other := entity.User{ID: uuid.New(), Name: "Other", Timezone: "UTC", Currency: "RUB"}
+	users := user.NewService(newUserRepo(entity.User{ID: f.userID, Name: "Alice", Timezone: "UTC", Currency: "RUB"}, other), logger)
+	handler := NewHandler(subscription.NewService(f.repo(), logger), logger, WithUsers(users))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	// A user_id in the query must not widen the result to another user.
+	req := httptest.NewRequest(http.MethodGet, v2Prefix+"/users/"+other.ID.String()+"/subscriptions?user_id="+f.userID.String(), nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d, body: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"count":0`) {
+		t.Fatalf("expected no subscriptions for the other user, got %s", w.Body.String())
+	}
+}
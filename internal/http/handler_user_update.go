@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http"
+)
+
+// handleUpdateUser serves PUT /api/v2/users/{id}. Omitted optional fields
+// are reset to their defaults, as on create.
+func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("update user request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req userRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.logger.Info("update user decode failed", "error", err)
+		h.writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	updated, err := h.users.Update(r.Context(), id, req.toEntity())
+	if err != nil {
+		h.writeUserError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toUserResponse(updated))
+}
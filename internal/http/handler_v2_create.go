@@ -0,0 +1,37 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"restservice/internal/usecase/subscription"
+)
+
+// handleCreateSubscriptionV2 serves POST /api/v2/subscriptions.
+func (h *Handler) handleCreateSubscriptionV2(w http.ResponseWriter, r *http.Request) {
+	var req subscriptionRequestV2
+	h.logger.Info("create subscription request", "method", r.Method, "path", r.URL.Path)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.logger.Info("create subscription decode failed", "error", err)
+		h.writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	sub, err := req.toEntity()
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := h.service.Create(r.Context(), sub)
+	if err != nil {
+		if errors.Is(err, subscription.ErrValidation) {
+			h.writeError(w, http.StatusBadRequest, validationErrorMessage)
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, toSubscriptionResponseV2(created))
+}
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"restservice/internal/usecase/subscription"
+)
+
+// handleGetSubscriptionV2 serves GET /api/v2/subscriptions/{id}.
+func (h *Handler) handleGetSubscriptionV2(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("get subscription request", "method", r.Method, "path", r.URL.Path)
+	uid, err := parseSubscriptionID(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sub, err := h.service.Get(r.Context(), uid)
+	if err != nil {
+		if errors.Is(err, subscription.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	h.writeCacheable(w, r, toSubscriptionResponseV2(sub), lastModifiedV2(sub, time.Now()))
+}
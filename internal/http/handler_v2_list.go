@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"restservice/internal/usecase/subscription"
+)
+
+// handleListSubscriptionsV2 serves GET /api/v2/subscriptions. Unlike v1 the
+// items are wrapped in an envelope with paging metadata.
+func (h *Handler) handleListSubscriptionsV2(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("list subscriptions request", "method", r.Method, "path", r.URL.Path)
+	filter, err := parseSubscriptionFilter(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, offset, err := parsePage(r.URL.Query())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	category, tag := parseLabelFilter(r)
+	items, err := h.service.List(r.Context(), subscription.ListFilter{
+		UserID:      filter.UserID,
+		ServiceName: filter.ServiceName,
+		Category:    category,
+		Tag:         tag,
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := listResponseV2{
+		Data: make([]subscriptionResponseV2, 0, len(items)),
+		Meta: listMetaV2{Count: len(items), Limit: limit, Offset: offset},
+	}
+	for _, item := range items {
+		resp.Data = append(resp.Data, toSubscriptionResponseV2(item))
+	}
+
+	h.writeCacheable(w, r, resp, time.Time{})
+}
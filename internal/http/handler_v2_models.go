@@ -0,0 +1,226 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
+)
+
+const (
+	isoDateLayout = "2006-01-02"
+	currencyRUB   = "RUB"
+)
+
+// moneyV2 is a price with an explicit currency. Amounts are whole units of
+// the currency; only RUB is supported for now.
+type moneyV2 struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type subscriptionRequestV2 struct {
+	ServiceName string `json:"service_name"`
+	// Price may be omitted for services whose catalog entry has a default
+	// price.
+	Price     *moneyV2 `json:"price,omitempty"`
+	UserID    string   `json:"user_id"`
+	StartDate string   `json:"start_date"`
+	EndDate   *string  `json:"end_date,omitempty"`
+	// Category may be omitted for services whose catalog entry has one.
+	Category *string  `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	// TrialEndDate starts the subscription as a trial, free until the
+	// month of this date.
+	TrialEndDate *string `json:"trial_end_date,omitempty"`
+}
+
+type subscriptionResponseV2 struct {
+	ID          string  `json:"id"`
+	ServiceName string  `json:"service_name"`
+	Price       moneyV2 `json:"price"`
+	UserID      string  `json:"user_id"`
+	StartDate   string  `json:"start_date"`
+	EndDate     *string `json:"end_date"`
+	// Category is null when the subscription has none.
+	Category *string  `json:"category"`
+	Tags     []string `json:"tags"`
+	// Status is the status today: a trial past its end date is active.
+	Status       entity.Status `json:"status"`
+	TrialEndDate *string       `json:"trial_end_date"`
+	CancelledAt  *string       `json:"cancelled_at"`
+	Pauses       []pauseV2     `json:"pauses"`
+}
+
+type pauseV2 struct {
+	StartDate string  `json:"start_date"`
+	EndDate   *string `json:"end_date"`
+}
+
+// transitionRequestV2 is the optional body of pause, resume and cancel.
+// The date defaults to today.
+type transitionRequestV2 struct {
+	Date string `json:"date"`
+}
+
+type listMetaV2 struct {
+	Count  int `json:"count"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type listResponseV2 struct {
+	Data []subscriptionResponseV2 `json:"data"`
+	Meta listMetaV2               `json:"meta"`
+}
+
+type periodV2 struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+type summaryResponseV2 struct {
+	Total  moneyV2  `json:"total"`
+	Period periodV2 `json:"period"`
+	// Groups is only present when the request asks for a grouping.
+	Groups []groupTotalV2 `json:"groups,omitzero"`
+}
+
+// groupTotalV2 is the total of one category or tag. Key is null for
+// subscriptions without a category, or without tags.
+type groupTotalV2 struct {
+	Key   *string `json:"key"`
+	Total moneyV2 `json:"total"`
+}
+
+// parseISODate parses a YYYY-MM-DD date. Subscriptions are billed per
+// month, so the date is moved to the first day of its month.
+func parseISODate(raw string) (time.Time, error) {
+	t, err := time.Parse(isoDateLayout, strings.TrimSpace(raw))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+func (req subscriptionRequestV2) toEntity() (entity.Subscription, error) {
+	var price int
+	if req.Price != nil {
+		if req.Price.Currency != currencyRUB {
+			return entity.Subscription{}, errors.New("unsupported currency")
+		}
+		price = req.Price.Amount
+	}
+
+	userID, err := uuid.Parse(strings.TrimSpace(req.UserID))
+	if err != nil {
+		return entity.Subscription{}, errors.New("invalid user_id")
+	}
+
+	startDate, err := parseISODate(req.StartDate)
+	if err != nil {
+		return entity.Subscription{}, errors.New("invalid start_date")
+	}
+
+	var endDate *time.Time
+	if req.EndDate != nil && strings.TrimSpace(*req.EndDate) != "" {
+		end, err := parseISODate(*req.EndDate)
+		if err != nil {
+			return entity.Subscription{}, errors.New("invalid end_date")
+		}
+		endDate = &end
+	}
+
+	var trialEnd *time.Time
+	if req.TrialEndDate != nil && strings.TrimSpace(*req.TrialEndDate) != "" {
+		end, err := parseISODate(*req.TrialEndDate)
+		if err != nil {
+			return entity.Subscription{}, errors.New("invalid trial_end_date")
+		}
+		trialEnd = &end
+	}
+
+	var category string
+	if req.Category != nil {
+		category = *req.Category
+	}
+
+	return entity.Subscription{
+		ServiceName:  strings.TrimSpace(req.ServiceName),
+		Price:        price,
+		UserID:       userID,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Category:     category,
+		Tags:         req.Tags,
+		TrialEndDate: trialEnd,
+	}, nil
+}
+
+func toSubscriptionResponseV2(sub entity.Subscription) subscriptionResponseV2 {
+	pauses := make([]pauseV2, 0, len(sub.Pauses))
+	for _, p := range sub.Pauses {
+		pauses = append(pauses, pauseV2{StartDate: p.Start.UTC().Format(isoDateLayout), EndDate: formatISODate(p.End)})
+	}
+
+	tags := sub.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return subscriptionResponseV2{
+		ID:           sub.ID.String(),
+		ServiceName:  sub.ServiceName,
+		Price:        moneyV2{Amount: sub.Price, Currency: currencyRUB},
+		UserID:       sub.UserID.String(),
+		StartDate:    sub.StartDate.UTC().Format(isoDateLayout),
+		EndDate:      formatISODate(sub.EndDate),
+		Category:     nonEmpty(sub.Category),
+		Tags:         tags,
+		Status:       sub.StatusAt(time.Now()),
+		TrialEndDate: formatISODate(sub.TrialEndDate),
+		CancelledAt:  formatISODate(sub.CancelledAt),
+		Pauses:       pauses,
+	}
+}
+
+// lastModifiedV2 is when the v2 representation of sub last changed. Its
+// status is the one at now, so a trial that has ended since the last write
+// changed when it ended, without a write.
+func lastModifiedV2(sub entity.Subscription, now time.Time) time.Time {
+	if sub.Status == entity.StatusTrial && sub.StatusAt(now) != entity.StatusTrial && sub.TrialEndDate.After(sub.UpdatedAt) {
+		return *sub.TrialEndDate
+	}
+	return sub.UpdatedAt
+}
+
+func formatISODate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.UTC().Format(isoDateLayout)
+	return &formatted
+}
+
+func toGroupTotalsV2(groups []subscription.GroupTotal) []groupTotalV2 {
+	resp := make([]groupTotalV2, 0, len(groups))
+	for _, g := range groups {
+		resp = append(resp, groupTotalV2{
+			Key:   nonEmpty(g.Key),
+			Total: moneyV2{Amount: g.Total, Currency: currencyRUB},
+		})
+	}
+	return resp
+}
+
+// nonEmpty returns nil for an empty s, which the JSON shows as null.
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"restservice/internal/usecase/subscription"
+)
+
+// handleSummaryV2 serves GET /api/v2/subscriptions/summary. The period is
+// given as ISO dates and covers whole months, from the month of start_date
+// through the last day of the month of end_date. With group_by the total is
+// also split by category or tag; a subscription with several tags counts
+// in each of their groups.
+func (h *Handler) handleSummaryV2(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("summary subscriptions request", "method", r.Method, "path", r.URL.Path)
+	query := r.URL.Query()
+	startDate, endDate, err := summaryPeriod(query, parseISODate)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseSubscriptionFilter(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	category, tag := parseLabelFilter(r)
+	summaryFilter := subscription.SummaryFilter{
+		UserID:      filter.UserID,
+		ServiceName: filter.ServiceName,
+		Category:    category,
+		Tag:         tag,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	}
+
+	total, err := h.service.Sum(r.Context(), summaryFilter)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := summaryResponseV2{
+		Total: moneyV2{Amount: total, Currency: currencyRUB},
+		Period: periodV2{
+			StartDate: startDate.Format(isoDateLayout),
+			EndDate:   endDate.Format(isoDateLayout),
+		},
+	}
+
+	if by := query.Get("group_by"); by != "" {
+		groups, err := h.service.SumGroups(r.Context(), summaryFilter, subscription.GroupBy(by))
+		if err != nil {
+			if errors.Is(err, subscription.ErrValidation) {
+				h.writeError(w, http.StatusBadRequest, "invalid group_by")
+				return
+			}
+			h.writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		resp.Groups = toGroupTotalsV2(groups)
+	}
+
+	h.writeCacheable(w, r, resp, time.Time{})
+}
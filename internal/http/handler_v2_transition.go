@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
+)
+
+type transitionFunc func(ctx context.Context, id uuid.UUID, at time.Time) (entity.Subscription, error)
+
+// handleTransitionV2 serves POST /api/v2/subscriptions/{id}/pause, /resume
+// and /cancel with the matching service method.
+func (h *Handler) handleTransitionV2(name string, transition transitionFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.logger.Info(name+" subscription request", "method", r.Method, "path", r.URL.Path)
+		subID, err := parseSubscriptionID(r)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req transitionRequestV2
+		if err := decodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			h.logger.Info(name+" subscription decode failed", "error", err)
+			h.writeError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		at := time.Now().UTC()
+		if raw := strings.TrimSpace(req.Date); raw != "" {
+			if at, err = time.Parse(isoDateLayout, raw); err != nil {
+				h.writeError(w, http.StatusBadRequest, "invalid date")
+				return
+			}
+		}
+
+		updated, err := transition(r.Context(), subID, at)
+		if err != nil {
+			switch {
+			case errors.Is(err, subscription.ErrNotFound):
+				h.writeError(w, http.StatusNotFound, "not found")
+			case errors.Is(err, subscription.ErrInvalidTransition):
+				h.writeError(w, http.StatusConflict, err.Error())
+			case errors.Is(err, subscription.ErrValidation):
+				h.writeError(w, http.StatusBadRequest, strings.TrimPrefix(err.Error(), subscription.ErrValidation.Error()+": "))
+			default:
+				h.writeError(w, http.StatusInternalServerError, "internal error")
+			}
+			return
+		}
+
+		h.writeJSON(w, http.StatusOK, toSubscriptionResponseV2(updated))
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"restservice/internal/usecase/subscription"
+)
+
+// handleUpdateSubscriptionV2 serves PUT /api/v2/subscriptions/{id}.
+func (h *Handler) handleUpdateSubscriptionV2(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("update subscription request", "method", r.Method, "path", r.URL.Path)
+	subID, err := parseSubscriptionID(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req subscriptionRequestV2
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.logger.Info("update subscription decode failed", "error", err)
+		h.writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	sub, err := req.toEntity()
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sub.ID = subID
+
+	updated, err := h.service.Update(r.Context(), subID, sub)
+	if err != nil {
+		if errors.Is(err, subscription.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if errors.Is(err, subscription.ErrValidation) {
+			h.writeError(w, http.StatusBadRequest, validationErrorMessage)
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toSubscriptionResponseV2(updated))
+}
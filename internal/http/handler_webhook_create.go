@@ -0,0 +1,40 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/webhook"
+)
+
+// handleCreateWebhook serves POST /api/v2/webhooks. The response is the
+// only one that includes the signing secret.
+func (h *Handler) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	h.logger.Info("create webhook request", "method", r.Method, "path", r.URL.Path)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.logger.Info("create webhook decode failed", "error", err)
+		h.writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	created, err := h.webhooks.Create(r.Context(), entity.Webhook{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+	})
+	if err != nil {
+		if errors.Is(err, webhook.ErrValidation) {
+			h.writeError(w, http.StatusBadRequest, strings.TrimPrefix(err.Error(), webhook.ErrValidation.Error()+": "))
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := toWebhookResponse(created)
+	resp.Secret = created.Secret
+	h.writeJSON(w, http.StatusCreated, resp)
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"restservice/internal/usecase/webhook"
+)
+
+// handleDeleteWebhook serves DELETE /api/v2/webhooks/{id}.
+func (h *Handler) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("delete webhook request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.webhooks.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/webhook"
+)
+
+// handleListWebhookDeliveries serves GET /api/v2/webhooks/{id}/deliveries,
+// newest first, optionally filtered by status.
+func (h *Handler) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("list webhook deliveries request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := queryInt(query, "limit")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, err := h.webhooks.ListDeliveries(r.Context(), id, webhook.DeliveryFilter{
+		Status: entity.DeliveryStatus(query.Get("status")),
+		Limit:  limit,
+	})
+	if err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := webhookDeliveryListResponse{Data: make([]webhookDeliveryResponse, 0, len(items))}
+	for _, item := range items {
+		resp.Data = append(resp.Data, toWebhookDeliveryResponse(item))
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"restservice/internal/usecase/webhook"
+)
+
+// handleGetWebhook serves GET /api/v2/webhooks/{id}.
+func (h *Handler) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("get webhook request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hook, err := h.webhooks.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toWebhookResponse(hook))
+}
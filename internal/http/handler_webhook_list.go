@@ -0,0 +1,22 @@
+package http
+
+import (
+	"net/http"
+)
+
+// handleListWebhooks serves GET /api/v2/webhooks.
+func (h *Handler) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("list webhooks request", "method", r.Method, "path", r.URL.Path)
+	items, err := h.webhooks.List(r.Context())
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := webhookListResponse{Data: make([]webhookResponse, 0, len(items))}
+	for _, item := range items {
+		resp.Data = append(resp.Data, toWebhookResponse(item))
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"time"
+
+	"restservice/internal/entity"
+)
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+type webhookResponse struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret is only filled in the response to create.
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type webhookListResponse struct {
+	Data []webhookResponse `json:"data"`
+}
+
+type webhookDeliveryResponse struct {
+	ID             int64   `json:"id"`
+	WebhookID      string  `json:"webhook_id"`
+	EventID        int64   `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	NextAttemptAt  string  `json:"next_attempt_at"`
+	LastStatusCode *int    `json:"last_status_code"`
+	LastError      *string `json:"last_error"`
+	DeliveredAt    *string `json:"delivered_at"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+type webhookDeliveryListResponse struct {
+	Data []webhookDeliveryResponse `json:"data"`
+}
+
+func toWebhookResponse(w entity.Webhook) webhookResponse {
+	return webhookResponse{
+		ID:        w.ID.String(),
+		URL:       w.URL,
+		Events:    w.Events,
+		CreatedAt: w.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func toWebhookDeliveryResponse(d entity.WebhookDelivery) webhookDeliveryResponse {
+	resp := webhookDeliveryResponse{
+		ID:            d.ID,
+		WebhookID:     d.WebhookID.String(),
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		Status:        string(d.Status),
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt.UTC().Format(time.RFC3339),
+		CreatedAt:     d.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if d.LastStatusCode != 0 {
+		code := d.LastStatusCode
+		resp.LastStatusCode = &code
+	}
+	if d.LastError != "" {
+		msg := d.LastError
+		resp.LastError = &msg
+	}
+	if d.DeliveredAt != nil {
+		at := d.DeliveredAt.UTC().Format(time.RFC3339)
+		resp.DeliveredAt = &at
+	}
+	return resp
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"restservice/internal/usecase/webhook"
+)
+
+// handleRedeliverWebhook serves
+// POST /api/v2/webhooks/{id}/deliveries/{delivery_id}/redeliver. The
+// delivery is queued again with a fresh attempt budget, including dead
+// ones.
+func (h *Handler) handleRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("redeliver webhook request", "method", r.Method, "path", r.URL.Path)
+	id, err := parsePathUUID(r, "id")
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	deliveryID, err := strconv.ParseInt(r.PathValue("delivery_id"), 10, 64)
+	if err != nil || deliveryID <= 0 {
+		h.writeError(w, http.StatusBadRequest, "invalid delivery_id")
+		return
+	}
+
+	delivery, err := h.webhooks.Redeliver(r.Context(), id, deliveryID)
+	if err != nil {
+		if errors.Is(err, webhook.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	h.writeJSON(w, http.StatusAccepted, toWebhookDeliveryResponse(delivery))
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	openapiJSONPath = "/openapi.json"
+	openapiYAMLPath = "/openapi.yaml"
+)
+
+// openapiYAML is the single source of the API contract. The JSON form is
+// derived from it once at startup so both always describe the same API.
+//
+//go:embed openapi.yaml
+var openapiYAML []byte
+
+var openapiJSON = mustYAMLToJSON(openapiYAML)
+
+func mustYAMLToJSON(data []byte) []byte {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		panic(fmt.Sprintf("parse embedded openapi.yaml: %v", err))
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		panic(fmt.Sprintf("convert embedded openapi.yaml to json: %v", err))
+	}
+	return out
+}
+
+func (h *Handler) handleOpenAPIJSON(w http.ResponseWriter, r *http.Request) {
+	h.writeSpec(w, r, "application/json", openapiJSON)
+}
+
+func (h *Handler) handleOpenAPIYAML(w http.ResponseWriter, r *http.Request) {
+	h.writeSpec(w, r, "application/yaml", openapiYAML)
+}
+
+func (h *Handler) writeSpec(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		h.logger.Error("write openapi spec failed", "error", err)
+	}
+}
@@ -0,0 +1,310 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo/memory"
+	"restservice/internal/usecase/catalog"
+	"restservice/internal/usecase/subscription"
+	"restservice/internal/usecase/user"
+	"restservice/internal/usecase/webhook"
+)
+
+func loadSpecRouter(t *testing.T) routers.Router {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData(openapiYAML)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("spec router: %v", err)
+	}
+	return router
+}
+
+type conformanceCase struct {
+	name   string
+	method string
+	target string
+	body   string
+	status int
+}
+
+// conformanceFixture seeds one subscription that the cases read, update
+// and finally delete.
+type conformanceFixture struct {
+	userID   uuid.UUID
+	existing uuid.UUID
+	missing  uuid.UUID
+}
+
+func newConformanceFixture() conformanceFixture {
+	return conformanceFixture{userID: uuid.New(), existing: uuid.New(), missing: uuid.New()}
+}
+
+// repo returns a repository holding the existing subscription.
+func (f conformanceFixture) repo() *memory.SubscriptionRepo {
+	return memory.NewSubscriptionRepo(entity.Subscription{
+		ID:          f.existing,
+		ServiceName: "Netflix",
+		Price:       500,
+		UserID:      f.userID,
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		Category:    "video",
+		Tags:        []string{"family"},
+	})
+}
+
+func v1ConformanceCases(prefix string, f conformanceFixture) []conformanceCase {
+	collection := prefix + "/subscriptions"
+	byID := collection + "/" + f.existing.String()
+	missing := collection + "/" + f.missing.String()
+	validBody := `{"service_name":"Yandex Plus","price":400,"user_id":"` + f.userID.String() + `","start_date":"07-2025","end_date":"09-2025"}`
+
+	return []conformanceCase{
+		{"create", http.MethodPost, collection, validBody, http.StatusCreated},
+		{"create invalid json", http.MethodPost, collection, `{`, http.StatusBadRequest},
+		{"create validation", http.MethodPost, collection, `{"service_name":"x","price":0,"user_id":"` + f.userID.String() + `","start_date":"07-2025"}`, http.StatusBadRequest},
+		{"list", http.MethodGet, collection + "?user_id=" + f.userID.String(), "", http.StatusOK},
+		{"list empty", http.MethodGet, collection + "?service_name=none", "", http.StatusOK},
+		{"list invalid user", http.MethodGet, collection + "?user_id=bad", "", http.StatusBadRequest},
+		{"get", http.MethodGet, byID, "", http.StatusOK},
+		{"get invalid id", http.MethodGet, collection + "/bad", "", http.StatusBadRequest},
+		{"get missing", http.MethodGet, missing, "", http.StatusNotFound},
+		{"update", http.MethodPut, byID, validBody, http.StatusOK},
+		{"update missing", http.MethodPut, missing, validBody, http.StatusNotFound},
+		{"summary", http.MethodGet, collection + "/summary?start_date=07-2025&end_date=12-2025", "", http.StatusOK},
+		{"summary missing dates", http.MethodGet, collection + "/summary", "", http.StatusBadRequest},
+		{"delete", http.MethodDelete, byID, "", http.StatusNoContent},
+		{"delete missing", http.MethodDelete, missing, "", http.StatusNotFound},
+	}
+}
+
+func v2ConformanceCases(f conformanceFixture) []conformanceCase {
+	collection := v2Prefix + "/subscriptions"
+	byID := collection + "/" + f.existing.String()
+	missing := collection + "/" + f.missing.String()
+	validBody := `{"service_name":"Yandex Plus","price":{"amount":400,"currency":"RUB"},"user_id":"` + f.userID.String() + `","start_date":"2025-07-01","end_date":"2025-09-15"}`
+
+	return []conformanceCase{
+		{"create", http.MethodPost, collection, validBody, http.StatusCreated},
+		{"create invalid json", http.MethodPost, collection, `{`, http.StatusBadRequest},
+		{"create v1 body", http.MethodPost, collection, `{"service_name":"x","price":400,"user_id":"` + f.userID.String() + `","start_date":"07-2025"}`, http.StatusBadRequest},
+		{"create with labels", http.MethodPost, collection, `{"service_name":"x","price":{"amount":5,"currency":"RUB"},"user_id":"` + f.userID.String() + `","start_date":"2025-07-01","category":"Music","tags":["family","Work"]}`, http.StatusCreated},
+		{"create tag with comma", http.MethodPost, collection, `{"service_name":"x","price":{"amount":5,"currency":"RUB"},"user_id":"` + f.userID.String() + `","start_date":"2025-07-01","tags":["a,b"]}`, http.StatusBadRequest},
+		{"create unsupported currency", http.MethodPost, collection, `{"service_name":"x","price":{"amount":5,"currency":"USD"},"user_id":"` + f.userID.String() + `","start_date":"2025-07-01"}`, http.StatusBadRequest},
+		{"list", http.MethodGet, collection + "?user_id=" + f.userID.String() + "&limit=10", "", http.StatusOK},
+		{"list invalid user", http.MethodGet, collection + "?user_id=bad", "", http.StatusBadRequest},
+		{"list by labels", http.MethodGet, collection + "?category=video&tag=family", "", http.StatusOK},
+		{"get", http.MethodGet, byID, "", http.StatusOK},
+		{"get missing", http.MethodGet, missing, "", http.StatusNotFound},
+		{"update", http.MethodPut, byID, validBody, http.StatusOK},
+		{"update missing", http.MethodPut, missing, validBody, http.StatusNotFound},
+		{"create with trial", http.MethodPost, collection, `{"service_name":"x","price":{"amount":5,"currency":"RUB"},"user_id":"` + f.userID.String() + `","start_date":"2025-07-01","trial_end_date":"2025-08-01"}`, http.StatusCreated},
+		{"pause", http.MethodPost, byID + "/pause", `{"date":"2025-08-01"}`, http.StatusOK},
+		{"pause paused", http.MethodPost, byID + "/pause", `{"date":"2025-08-01"}`, http.StatusConflict},
+		{"pause invalid date", http.MethodPost, byID + "/pause", `{"date":"08-2025"}`, http.StatusBadRequest},
+		{"resume", http.MethodPost, byID + "/resume", `{"date":"2025-09-01"}`, http.StatusOK},
+		{"resume missing", http.MethodPost, missing + "/resume", "", http.StatusNotFound},
+		{"cancel", http.MethodPost, byID + "/cancel", `{"date":"2025-09-10"}`, http.StatusOK},
+		{"cancel cancelled", http.MethodPost, byID + "/cancel", "", http.StatusConflict},
+		{"summary", http.MethodGet, collection + "/summary?start_date=2025-07-01&end_date=2025-12-31", "", http.StatusOK},
+		{"summary by category", http.MethodGet, collection + "/summary?start_date=2025-07-01&end_date=2025-12-31&group_by=category", "", http.StatusOK},
+		{"summary by tag", http.MethodGet, collection + "/summary?start_date=2025-07-01&end_date=2025-12-31&group_by=tag", "", http.StatusOK},
+		{"summary unknown grouping", http.MethodGet, collection + "/summary?start_date=2025-07-01&end_date=2025-12-31&group_by=service", "", http.StatusBadRequest},
+		{"summary v1 dates", http.MethodGet, collection + "/summary?start_date=07-2025&end_date=12-2025", "", http.StatusBadRequest},
+		{"delete", http.MethodDelete, byID, "", http.StatusNoContent},
+		{"delete missing", http.MethodDelete, missing, "", http.StatusNotFound},
+	}
+}
+
+func TestHandlersConformToSpec(t *testing.T) {
+	router := loadSpecRouter(t)
+
+	suites := []struct {
+		version    string
+		deprecated bool
+		cases      func(conformanceFixture) []conformanceCase
+	}{
+		{"unversioned", true, func(f conformanceFixture) []conformanceCase { return v1ConformanceCases(legacyPrefix, f) }},
+		{"v1", true, func(f conformanceFixture) []conformanceCase { return v1ConformanceCases(v1Prefix, f) }},
+		{"v2", false, v2ConformanceCases},
+	}
+
+	for _, suite := range suites {
+		t.Run(suite.version, func(t *testing.T) {
+			f := newConformanceFixture()
+			handler := newTestHandler(f.repo())
+			mux := http.NewServeMux()
+			handler.Register(mux)
+
+			for _, tc := range suite.cases(f) {
+				t.Run(tc.name, func(t *testing.T) {
+					req := httptest.NewRequest(tc.method, tc.target, bytes.NewBufferString(tc.body))
+					if tc.body != "" {
+						req.Header.Set("Content-Type", "application/json")
+					}
+					w := httptest.NewRecorder()
+					mux.ServeHTTP(w, req)
+
+					if w.Code != tc.status {
+						t.Fatalf("unexpected status: %d, body: %s", w.Code, w.Body.String())
+					}
+					if got := w.Header().Get("Sunset") != ""; got != suite.deprecated {
+						t.Fatalf("unexpected Sunset header presence: %v", got)
+					}
+					if got := w.Header().Get("Deprecation") != ""; got != suite.deprecated {
+						t.Fatalf("unexpected Deprecation header presence: %v", got)
+					}
+					validateResponse(t, router, req, w)
+				})
+			}
+		})
+	}
+}
+
+// TestRoutesMatchSpec checks the route table against openapi.yaml in
+// both directions: every registered route and method is an operation of
+// the spec, every operation is registered, and every successful response
+// other than 204 and the event stream declares a JSON schema. Together
+// with the conformance tests, which validate real responses against those
+// schemas, the spec cannot drift from the handlers.
+func TestRoutesMatchSpec(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData(openapiYAML)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewHandler(subscription.NewService(memory.NewSubscriptionRepo(), logger), logger,
+		WithUsers(user.NewService(nil, logger)),
+		WithCatalog(catalog.NewService(nil, logger)),
+		WithWebhooks(webhook.NewService(nil, logger)),
+		WithEventStream(subscription.NewStream(nil, logger)),
+	)
+
+	registered := make(map[string]bool)
+	for _, g := range handler.apiRoutes() {
+		for _, rt := range g.routes {
+			operation := rt.method + " " + rt.pattern
+			registered[operation] = true
+
+			item := doc.Paths.Value(rt.pattern)
+			if item == nil || item.GetOperation(rt.method) == nil {
+				t.Errorf("%s is registered but not in the spec", operation)
+				continue
+			}
+			checkResponseSchemas(t, operation, item.GetOperation(rt.method))
+		}
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			if !registered[method+" "+path] {
+				t.Errorf("%s %s is in the spec but not registered", method, path)
+			}
+		}
+	}
+}
+
+func checkResponseSchemas(t *testing.T, operation string, op *openapi3.Operation) {
+	t.Helper()
+	var success bool
+	for status, ref := range op.Responses.Map() {
+		if len(status) != 3 || status[0] != '2' {
+			continue
+		}
+		success = true
+		if status == "204" {
+			continue
+		}
+		content := ref.Value.Content
+		if content.Get("text/event-stream") != nil {
+			continue
+		}
+		if media := content.Get("application/json"); media == nil || media.Schema == nil {
+			t.Errorf("%s: response %s has no JSON schema", operation, status)
+		}
+	}
+	if !success {
+		t.Errorf("%s: no successful response in the spec", operation)
+	}
+}
+
+func validateResponse(t *testing.T, router routers.Router, req *http.Request, w *httptest.ResponseRecorder) {
+	t.Helper()
+	route, params, err := router.FindRoute(req)
+	if err != nil {
+		t.Fatalf("find route: %v", err)
+	}
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: params,
+			Route:      route,
+		},
+		Status: w.Code,
+		Header: w.Header(),
+		Body:   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+			MultiError:            true,
+		},
+	}
+	if err := openapi3filter.ValidateResponse(context.Background(), input); err != nil {
+		t.Fatalf("response does not match spec: %v", err)
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	handler := newTestHandler(memory.NewSubscriptionRepo())
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	for _, tc := range []struct {
+		path        string
+		contentType string
+		decode      func([]byte, any) error
+	}{
+		{openapiJSONPath, "application/json", json.Unmarshal},
+		{swaggerPath + "swagger.json", "application/json", json.Unmarshal},
+		{openapiYAMLPath, "application/yaml", yaml.Unmarshal},
+		{swaggerPath + "swagger.yaml", "application/yaml", yaml.Unmarshal},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status %d", tc.path, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != tc.contentType {
+			t.Fatalf("%s: unexpected content type %q", tc.path, ct)
+		}
+		var doc struct {
+			OpenAPI string `json:"openapi" yaml:"openapi"`
+		}
+		if err := tc.decode(w.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("%s: decode: %v", tc.path, err)
+		}
+		if doc.OpenAPI != "3.1.0" {
+			t.Fatalf("%s: unexpected openapi version %q", tc.path, doc.OpenAPI)
+		}
+	}
+}
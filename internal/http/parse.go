@@ -0,0 +1,151 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+// The request validator has already checked shapes and formats against
+// the spec by the time these helpers run; they only convert input into
+// domain values and report which field was at fault when they cannot.
+
+func decodeJSON(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func (req subscriptionRequest) toEntity() (entity.Subscription, error) {
+	userID, err := uuid.Parse(strings.TrimSpace(req.UserID))
+	if err != nil {
+		return entity.Subscription{}, errors.New("invalid user_id")
+	}
+
+	startDate, err := entity.ParseMonthYear(strings.TrimSpace(req.StartDate))
+	if err != nil {
+		return entity.Subscription{}, errors.New("invalid start_date")
+	}
+
+	var endDate *time.Time
+	if req.EndDate != nil && strings.TrimSpace(*req.EndDate) != "" {
+		end, err := entity.ParseMonthYear(strings.TrimSpace(*req.EndDate))
+		if err != nil {
+			return entity.Subscription{}, errors.New("invalid end_date")
+		}
+		endDate = &end
+	}
+
+	return entity.Subscription{
+		ServiceName: strings.TrimSpace(req.ServiceName),
+		Price:       req.Price,
+		UserID:      userID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	}, nil
+}
+
+// subscriptionFilter holds the user_id and service_name query filters
+// shared by list and summary.
+type subscriptionFilter struct {
+	UserID      *uuid.UUID
+	ServiceName *string
+}
+
+func parseSubscriptionFilter(r *http.Request) (subscriptionFilter, error) {
+	query := r.URL.Query()
+	var filter subscriptionFilter
+
+	if raw := query.Get("user_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			return subscriptionFilter{}, errors.New("invalid user_id")
+		}
+		filter.UserID = &parsed
+	}
+
+	if raw := strings.TrimSpace(query.Get("service_name")); raw != "" {
+		filter.ServiceName = &raw
+	}
+
+	return filter, nil
+}
+
+// parsePage reads the limit and offset of a list; missing ones are zero.
+func parsePage(query url.Values) (limit, offset int, err error) {
+	if limit, err = queryInt(query, "limit"); err != nil {
+		return 0, 0, err
+	}
+	if offset, err = queryInt(query, "offset"); err != nil {
+		return 0, 0, err
+	}
+	return limit, offset, nil
+}
+
+func queryInt(query url.Values, name string) (int, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, errors.New("invalid " + name)
+	}
+	return n, nil
+}
+
+// summaryPeriod reads the start_date and end_date of a summary with parse
+// and returns the period from the first day of the start month through
+// the last day of the end month.
+func summaryPeriod(query url.Values, parse func(string) (time.Time, error)) (start, end time.Time, err error) {
+	if start, err = parse(query.Get("start_date")); err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid start_date")
+	}
+	if end, err = parse(query.Get("end_date")); err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid end_date")
+	}
+	end = end.AddDate(0, 1, -1)
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("end_date before start_date")
+	}
+	return start, end, nil
+}
+
+// parseLabelFilter reads the category and tag filters of v2 list and
+// summary requests.
+func parseLabelFilter(r *http.Request) (category, tag *string) {
+	query := r.URL.Query()
+	if raw := strings.TrimSpace(query.Get("category")); raw != "" {
+		category = &raw
+	}
+	if raw := strings.TrimSpace(query.Get("tag")); raw != "" {
+		tag = &raw
+	}
+	return category, tag
+}
+
+func parseSubscriptionID(r *http.Request) (uuid.UUID, error) {
+	return parsePathUUID(r, "id")
+}
+
+func parsePathUUID(r *http.Request, name string) (uuid.UUID, error) {
+	id := strings.TrimSpace(r.PathValue(name))
+	if id == "" {
+		return uuid.Nil, errors.New("missing " + name)
+	}
+
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid " + name)
+	}
+	return parsed, nil
+}
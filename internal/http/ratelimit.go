@@ -0,0 +1,224 @@
+package http
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"restservice/internal/ratelimit"
+)
+
+// rateClass groups routes that share a limit. Each class has its own
+// bucket per client, so a burst of summaries does not eat into reads.
+type rateClass int
+
+const (
+	rateDefault rateClass = iota
+	rateRead
+	rateHeavy
+)
+
+func (c rateClass) String() string {
+	switch c {
+	case rateRead:
+		return "read"
+	case rateHeavy:
+		return "heavy"
+	default:
+		return "default"
+	}
+}
+
+// RateKey chooses what identifies a client to the rate limiter.
+type RateKey string
+
+const (
+	// RateKeyIP keys on the client IP.
+	RateKeyIP RateKey = "ip"
+	// RateKeyAPIKey keys on the X-API-Key header.
+	RateKeyAPIKey RateKey = "api_key"
+	// RateKeyUserID keys on the X-User-ID header.
+	RateKeyUserID RateKey = "user_id"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	userIDHeader = "X-User-ID"
+)
+
+// RateLimits are the per-client limits of each route class.
+type RateLimits struct {
+	// Default covers lists and writes.
+	Default ratelimit.Limit
+	// Read covers fetching a single subscription by ID.
+	Read ratelimit.Limit
+	// Heavy covers aggregate queries such as the summary.
+	Heavy ratelimit.Limit
+	// Routes override the class limit of single routes, keyed by method
+	// and path without the version prefix, as in
+	// "GET /subscriptions/summary". Such a route gets buckets of its own,
+	// shared by all versions.
+	Routes map[string]ratelimit.Limit
+	// Key chooses what identifies a client; the zero value is RateKeyIP.
+	// The service does not verify API keys or user IDs, so RateKeyAPIKey
+	// and RateKeyUserID are only safe behind a gateway that does. Requests
+	// without the header are keyed on their IP.
+	Key RateKey
+	// TrustedProxies are the proxies whose X-Forwarded-For is believed.
+	// The client IP is the nearest address in the header that is not one
+	// of them; without trusted proxies it is the peer address.
+	TrustedProxies []netip.Prefix
+}
+
+// WithRateLimit limits every API route per client using limiter.
+func WithRateLimit(limiter ratelimit.Limiter, limits RateLimits) Option {
+	return func(h *Handler) {
+		h.limiter = limiter
+		h.rateLimits = limits
+	}
+}
+
+// rateLimit rejects requests over the route limit with 429. If the
+// limiter itself fails the request is let through: an outage of the
+// limiter backend should not take the API down with it.
+func (h *Handler) rateLimit(rt route) middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if h.limiter == nil {
+			return next
+		}
+		bucket, limit := h.routeLimit(rt)
+		policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(int(limit.Period/time.Second))
+		if limit.Burst > 0 {
+			policy += ";burst=" + strconv.Itoa(limit.Burst)
+		}
+
+		return func(w http.ResponseWriter, r *http.Request) {
+			res, err := h.limiter.Allow(r.Context(), bucket+"|"+h.clientKey(r), limit)
+			if err != nil {
+				h.logger.Error("rate limit failed", "error", err)
+				next(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Policy", policy)
+			header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			header.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			if !res.Allowed {
+				header.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				h.writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// routeLimit returns the bucket name and limit of rt: its own if the
+// route is overridden, its class's otherwise.
+func (h *Handler) routeLimit(rt route) (string, ratelimit.Limit) {
+	name := rateRouteName(rt)
+	if limit, ok := h.rateLimits.Routes[name]; ok {
+		return name, limit
+	}
+	switch rt.limit {
+	case rateRead:
+		return rt.limit.String(), h.rateLimits.Read
+	case rateHeavy:
+		return rt.limit.String(), h.rateLimits.Heavy
+	default:
+		return rt.limit.String(), h.rateLimits.Default
+	}
+}
+
+// rateRouteName names rt the way RateLimits.Routes does.
+func rateRouteName(rt route) string {
+	path := rt.pattern
+	for _, prefix := range []string{v2Prefix, v1Prefix, legacyPrefix} {
+		if rest, ok := strings.CutPrefix(path, prefix+"/"); ok {
+			path = "/" + rest
+			break
+		}
+	}
+	return rt.method + " " + path
+}
+
+// checkRateRoutes logs route overrides that match no route, which are
+// most likely typos.
+func (h *Handler) checkRateRoutes(routes []route) {
+	if h.limiter == nil {
+		return
+	}
+	known := make(map[string]bool, len(routes))
+	for _, rt := range routes {
+		known[rateRouteName(rt)] = true
+	}
+	for name := range h.rateLimits.Routes {
+		if !known[name] {
+			h.logger.Warn("rate limit set for an unknown route", "route", name)
+		}
+	}
+}
+
+// clientKey identifies the caller by the configured key, falling back to
+// the client IP.
+func (h *Handler) clientKey(r *http.Request) string {
+	switch h.rateLimits.Key {
+	case RateKeyAPIKey:
+		if key := r.Header.Get(apiKeyHeader); key != "" {
+			return "key:" + key
+		}
+	case RateKeyUserID:
+		if id := r.Header.Get(userIDHeader); id != "" {
+			return "user:" + id
+		}
+	}
+	return "ip:" + clientIP(r, h.rateLimits.TrustedProxies)
+}
+
+// clientIP returns the peer address or, when the peer is a trusted proxy,
+// walks X-Forwarded-For from the nearest hop to the first address that is
+// not a trusted proxy. Hops further out were written by the client and
+// cannot be believed.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !isTrusted(addr, trusted) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop
+		if !isTrusted(hop, trusted) {
+			break
+		}
+	}
+	return addr.String()
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
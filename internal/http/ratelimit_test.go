@@ -0,0 +1,150 @@
+package http
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"restservice/internal/ratelimit"
+	"restservice/internal/repo/memory"
+	"restservice/internal/usecase/subscription"
+)
+
+func TestRateLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewHandler(subscription.NewService(memory.NewSubscriptionRepo(), logger), logger, WithRateLimit(ratelimit.NewMemory(), RateLimits{
+		Default: ratelimit.Limit{Requests: 5, Period: time.Minute},
+		Read:    ratelimit.Limit{Requests: 5, Period: time.Minute},
+		Heavy:   ratelimit.Limit{Requests: 1, Period: time.Minute},
+	}))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	do := func(target, remoteAddr, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	summary := v2Prefix + "/subscriptions/summary?start_date=2025-07-01&end_date=2025-08-01"
+	if w := do(summary, "10.0.0.1:1234", ""); w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	// The v1 alias shares the summary bucket with v2.
+	w := do(summaryPath+"?start_date=07-2025&end_date=08-2025", "10.0.0.1:5678", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("unexpected Retry-After: %q", w.Header().Get("Retry-After"))
+	}
+	if w.Header().Get("RateLimit-Remaining") != "0" || w.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("unexpected RateLimit headers: %v", w.Header())
+	}
+
+	// Other classes and other clients keep their own buckets.
+	if w := do(subscriptionsPath, "10.0.0.1:1234", ""); w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "4" {
+		t.Fatalf("unexpected list response: %d %v", w.Code, w.Header())
+	}
+	if w := do(summary, "10.0.0.2:1234", ""); w.Code != http.StatusOK {
+		t.Fatalf("unexpected status for another ip: %d", w.Code)
+	}
+	// Unverified keys and user ids do not buy a fresh bucket.
+	if w := do(summary, "10.0.0.1:1234", "secret"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status for an api key: %d", w.Code)
+	}
+	if w := do(summary+"&user_id=0b6f0e4c-3b0a-4c55-9a0e-2f1b7c9d1e11", "10.0.0.1:1234", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status for a user id: %d", w.Code)
+	}
+}
+
+func TestRateLimitKeysAndRoutes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	newMux := func(limits RateLimits) *http.ServeMux {
+		limits.Default = ratelimit.Limit{Requests: 1, Period: time.Minute}
+		limits.Read, limits.Heavy = limits.Default, limits.Default
+		handler := NewHandler(subscription.NewService(memory.NewSubscriptionRepo(), logger), logger, WithRateLimit(ratelimit.NewMemory(), limits))
+		mux := http.NewServeMux()
+		handler.Register(mux)
+		return mux
+	}
+	do := func(mux *http.ServeMux, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, subscriptionsPath, nil)
+		req.RemoteAddr = remoteAddr
+		for name, values := range header {
+			for _, v := range values {
+				req.Header.Add(name, v)
+			}
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("api key", func(t *testing.T) {
+		mux := newMux(RateLimits{Key: RateKeyAPIKey})
+		a := http.Header{apiKeyHeader: {"a"}}
+		if w := do(mux, "10.0.0.1:1", a); w.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d", w.Code)
+		}
+		if w := do(mux, "10.0.0.2:1", a); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("the key must share its bucket across addresses, got %d", w.Code)
+		}
+		if w := do(mux, "10.0.0.1:1", http.Header{apiKeyHeader: {"b"}}); w.Code != http.StatusOK {
+			t.Fatalf("another key must get its own bucket, got %d", w.Code)
+		}
+		// Without the header the client IP is used.
+		if w := do(mux, "10.0.0.1:1", nil); w.Code != http.StatusOK {
+			t.Fatalf("unexpected status without a key: %d", w.Code)
+		}
+	})
+
+	t.Run("trusted proxies", func(t *testing.T) {
+		mux := newMux(RateLimits{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}})
+		forwarded := func(chain string) http.Header { return http.Header{"X-Forwarded-For": {chain}} }
+		if w := do(mux, "10.0.0.9:1", forwarded("1.1.1.1, 203.0.113.5, 10.0.0.3")); w.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d", w.Code)
+		}
+		// The hop before the trusted ones is the client; what it forwarded
+		// itself is not believed.
+		if w := do(mux, "10.0.0.8:1", forwarded("2.2.2.2, 203.0.113.5")); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected the same client behind another proxy, got %d", w.Code)
+		}
+		if w := do(mux, "10.0.0.8:1", forwarded("203.0.113.6")); w.Code != http.StatusOK {
+			t.Fatalf("expected another client behind the proxy, got %d", w.Code)
+		}
+		// An untrusted peer cannot pick its address.
+		if w := do(mux, "198.51.100.1:1", forwarded("203.0.113.7")); w.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d", w.Code)
+		}
+		if w := do(mux, "198.51.100.1:1", forwarded("203.0.113.8")); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected X-Forwarded-For of an untrusted peer to be ignored, got %d", w.Code)
+		}
+	})
+
+	t.Run("route override", func(t *testing.T) {
+		mux := newMux(RateLimits{Routes: map[string]ratelimit.Limit{
+			"GET /subscriptions": {Requests: 1, Period: time.Minute, Burst: 2},
+		}})
+		w := do(mux, "10.0.0.1:1", nil)
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Policy") != "1;w=60;burst=2" {
+			t.Fatalf("unexpected response: %d %v", w.Code, w.Header())
+		}
+		if w := do(mux, "10.0.0.1:1", nil); w.Code != http.StatusOK {
+			t.Fatalf("expected the burst to allow a second request, got %d", w.Code)
+		}
+		if w := do(mux, "10.0.0.1:1", nil); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("unexpected status: %d", w.Code)
+		}
+	})
+}
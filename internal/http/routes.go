@@ -0,0 +1,112 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// routeMethods are the methods every registered path answers. Those a
+// path does not implement get a JSON 405 with an Allow header, and OPTIONS
+// lists the implemented ones. Explicit patterns are used instead of a
+// method-less fallback because "/api/subscriptions/summary" would
+// otherwise conflict with "PUT /api/subscriptions/{id}".
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+type route struct {
+	method  string
+	pattern string
+	handler http.HandlerFunc
+	limit   rateClass
+}
+
+type middleware func(http.HandlerFunc) http.HandlerFunc
+
+// chain applies middlewares so that the first one runs outermost.
+func chain(mws ...middleware) middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
+
+// registerRoutes registers every route as a "METHOD pattern" on mux,
+// wrapped in wrap, and fills in OPTIONS and 405 answers for the methods
+// each pattern lacks. GET patterns also serve HEAD. Rate limiting, CORS
+// and compression are applied outside wrap, so rejected requests are
+// counted, carry the CORS headers and get compressed too.
+func (h *Handler) registerRoutes(mux *http.ServeMux, routes []route, wrap middleware) {
+	byPattern := make(map[string][]route)
+	var patterns []string
+	for _, rt := range routes {
+		if _, ok := byPattern[rt.pattern]; !ok {
+			patterns = append(patterns, rt.pattern)
+		}
+		byPattern[rt.pattern] = append(byPattern[rt.pattern], rt)
+	}
+
+	for _, pattern := range patterns {
+		implemented := make(map[string]bool)
+		for _, rt := range byPattern[pattern] {
+			handler := rt.handler
+			if wrap != nil {
+				handler = wrap(handler)
+			}
+			handler = h.rateLimit(rt)(h.readYourWrites(handler))
+			mux.HandleFunc(rt.method+" "+pattern, h.compress(h.corsHeaders(handler)))
+			implemented[rt.method] = true
+		}
+		if implemented[http.MethodGet] {
+			implemented[http.MethodHead] = true
+		}
+
+		allow := allowHeader(implemented)
+		for _, method := range routeMethods {
+			if implemented[method] {
+				continue
+			}
+			if method == http.MethodOptions {
+				mux.HandleFunc(method+" "+pattern, h.corsPreflight(implemented, h.handleOptions(allow)))
+				continue
+			}
+			mux.HandleFunc(method+" "+pattern, h.corsHeaders(h.handleMethodNotAllowed(allow)))
+		}
+	}
+}
+
+func allowHeader(implemented map[string]bool) string {
+	methods := []string{http.MethodOptions}
+	for method := range implemented {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+func (h *Handler) handleOptions(allow string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) handleMethodNotAllowed(allow string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *Handler) handleNotFound(w http.ResponseWriter, r *http.Request) {
+	h.writeError(w, http.StatusNotFound, "not found")
+}
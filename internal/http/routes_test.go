@@ -0,0 +1,105 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo/memory"
+)
+
+func TestRouting(t *testing.T) {
+	existing := entity.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+	}
+	handler := newTestHandler(memory.NewSubscriptionRepo(existing))
+	mux := http.NewServeMux()
+	handler.Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	byID := subscriptionsPath + "/" + existing.ID.String()
+	summary := summaryPath + "?start_date=07-2025&end_date=08-2025"
+
+	const (
+		collectionAllow = "GET, HEAD, OPTIONS, POST"
+		itemAllow       = "DELETE, GET, HEAD, OPTIONS, PUT"
+		readOnlyAllow   = "GET, HEAD, OPTIONS"
+	)
+
+	cases := []struct {
+		method string
+		target string
+		status int
+		allow  string
+		body   bool
+	}{
+		{http.MethodGet, subscriptionsPath, http.StatusOK, "", true},
+		{http.MethodHead, subscriptionsPath, http.StatusOK, "", false},
+		{http.MethodOptions, subscriptionsPath, http.StatusNoContent, collectionAllow, false},
+		{http.MethodDelete, subscriptionsPath, http.StatusMethodNotAllowed, collectionAllow, true},
+
+		{http.MethodGet, summary, http.StatusOK, "", true},
+		{http.MethodHead, summary, http.StatusOK, "", false},
+		{http.MethodOptions, summaryPath, http.StatusNoContent, readOnlyAllow, false},
+		{http.MethodPut, summaryPath, http.StatusMethodNotAllowed, readOnlyAllow, true},
+		{http.MethodDelete, summaryPath, http.StatusMethodNotAllowed, readOnlyAllow, true},
+		{http.MethodGet, summaryPath + "/x", http.StatusNotFound, "", true},
+
+		{http.MethodGet, byID, http.StatusOK, "", true},
+		{http.MethodHead, byID, http.StatusOK, "", false},
+		{http.MethodOptions, byID, http.StatusNoContent, itemAllow, false},
+		{http.MethodPatch, byID, http.StatusMethodNotAllowed, itemAllow, true},
+		{http.MethodPost, byID, http.StatusMethodNotAllowed, itemAllow, true},
+		{http.MethodGet, subscriptionsPath + "/a/b", http.StatusNotFound, "", true},
+		{http.MethodGet, subscriptionsPath + "/", http.StatusNotFound, "", true},
+		{http.MethodGet, "/api/unknown", http.StatusNotFound, "", true},
+
+		{http.MethodGet, openapiJSONPath, http.StatusOK, "", true},
+		{http.MethodPost, openapiJSONPath, http.StatusMethodNotAllowed, readOnlyAllow, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.target, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, srv.URL+tc.target, nil)
+			if err != nil {
+				t.Fatalf("build request: %v", err)
+			}
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatalf("do request: %v", err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("read body: %v", err)
+			}
+
+			if resp.StatusCode != tc.status {
+				t.Fatalf("unexpected status: %d, body: %s", resp.StatusCode, body)
+			}
+			if got := resp.Header.Get("Allow"); got != tc.allow {
+				t.Fatalf("unexpected Allow: %q, want %q", got, tc.allow)
+			}
+			if (len(body) > 0) != tc.body {
+				t.Fatalf("unexpected body presence: %q", body)
+			}
+			if resp.StatusCode >= http.StatusBadRequest {
+				var errResp errorResponse
+				if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error == "" {
+					t.Fatalf("expected JSON error body, got %q", body)
+				}
+			}
+		})
+	}
+}
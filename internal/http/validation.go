@@ -0,0 +1,174 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/google/uuid"
+)
+
+const (
+	maxBodyBytes = 1 << 20
+
+	validationErrorMessage = "validation error"
+)
+
+func init() {
+	openapi3.DefineStringFormatValidator("uuid", openapi3.NewCallbackValidator(func(s string) error {
+		_, err := uuid.Parse(s)
+		return err
+	}))
+}
+
+// requestValidator checks requests against the embedded OpenAPI document
+// before they reach a handler, so handlers only see well-formed input.
+type requestValidator struct {
+	router routers.Router
+}
+
+func newRequestValidator() (*requestValidator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openapiYAML)
+	if err != nil {
+		return nil, fmt.Errorf("load openapi spec: %w", err)
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build openapi router: %w", err)
+	}
+	return &requestValidator{router: router}, nil
+}
+
+// validate wraps next with schema validation. Requests that match no
+// operation in the spec, such as unsupported methods, are passed through
+// so the handler can answer them itself. HEAD is checked as the GET it
+// stands for, so handlers never see unvalidated query parameters.
+func (h *Handler) validate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		specReq := r
+		if r.Method == http.MethodHead {
+			specReq = r.Clone(r.Context())
+			specReq.Method = http.MethodGet
+		}
+		route, params, err := h.validator.router.FindRoute(specReq)
+		if err != nil {
+			next(w, r)
+			return
+		}
+
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+			// Clients have historically omitted the header; the API only
+			// speaks JSON, so treat a missing one as application/json.
+			if r.Header.Get("Content-Type") == "" {
+				r.Header.Set("Content-Type", "application/json")
+			}
+		}
+
+		err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+			Request:    specReq,
+			PathParams: params,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		})
+		if err == nil {
+			next(w, r)
+			return
+		}
+
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			h.writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+
+		fields, malformed := fieldErrors(err)
+		if malformed {
+			h.writeError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		h.logger.Info("request validation failed", "path", r.URL.Path, "fields", len(fields))
+		h.writeJSON(w, http.StatusBadRequest, errorResponse{Error: validationErrorMessage, Fields: fields})
+	}
+}
+
+// fieldErrors flattens kin-openapi errors into one entry per offending
+// field. malformed reports a body that is not valid JSON at all.
+func fieldErrors(err error) ([]fieldError, bool) {
+	var (
+		fields    []fieldError
+		malformed bool
+	)
+
+	var walk func(err error, prefix string)
+	walk = func(err error, prefix string) {
+		switch e := err.(type) {
+		case openapi3.MultiError:
+			for _, item := range e {
+				walk(item, prefix)
+			}
+		case *openapi3filter.RequestError:
+			switch {
+			case e.Parameter != nil && e.Err != nil:
+				walk(e.Err, e.Parameter.Name)
+			case e.Parameter != nil:
+				fields = append(fields, fieldError{Field: e.Parameter.Name, Message: e.Reason})
+			case e.Err != nil:
+				var parseErr *openapi3filter.ParseError
+				if errors.As(e.Err, &parseErr) {
+					malformed = true
+					return
+				}
+				walk(e.Err, prefix)
+			default:
+				fields = append(fields, fieldError{Field: "body", Message: e.Reason})
+			}
+		case *openapi3.SchemaError:
+			path := append(splitPrefix(prefix), e.JSONPointer()...)
+			// Unknown properties are reported against the enclosing
+			// object; point at the property itself instead.
+			if e.SchemaField == "properties" {
+				if name := quotedProperty(e.Reason); name != "" {
+					path = append(path, name)
+				}
+			}
+			fields = append(fields, fieldError{Field: strings.Join(path, "."), Message: e.Reason})
+		default:
+			fields = append(fields, fieldError{Field: prefix, Message: err.Error()})
+		}
+	}
+	walk(err, "")
+
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return fields, malformed
+}
+
+func splitPrefix(prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+	return strings.Split(prefix, ".")
+}
+
+// quotedProperty extracts the property name from kin-openapi reasons such
+// as `property "price" is missing`.
+func quotedProperty(reason string) string {
+	start := strings.IndexByte(reason, '"')
+	if start < 0 {
+		return ""
+	}
+	end := strings.IndexByte(reason[start+1:], '"')
+	if end < 0 {
+		return ""
+	}
+	return reason[start+1 : start+1+end]
+}
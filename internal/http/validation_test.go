@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo/memory"
+)
+
+func TestRequestValidation(t *testing.T) {
+	userID := uuid.New()
+	repo := memory.NewSubscriptionRepo()
+	repo.AddUsers(entity.User{ID: userID})
+	handler := newTestHandler(repo)
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		error  string
+		fields []string
+	}{
+		{
+			name:   "unknown field",
+			method: http.MethodPost,
+			target: subscriptionsPath,
+			body:   `{"service_name":"Netflix","price":500,"user_id":"` + userID.String() + `","start_date":"07-2025","discount":10}`,
+			status: http.StatusBadRequest,
+			error:  validationErrorMessage,
+			fields: []string{"discount"},
+		},
+		{
+			name:   "missing and invalid fields",
+			method: http.MethodPost,
+			target: subscriptionsPath,
+			body:   `{"service_name":"Netflix","price":0,"start_date":"13-2025"}`,
+			status: http.StatusBadRequest,
+			error:  validationErrorMessage,
+			fields: []string{"price", "start_date", "user_id"},
+		},
+		{
+			name:   "malformed json",
+			method: http.MethodPost,
+			target: subscriptionsPath,
+			body:   `{"service_name":`,
+			status: http.StatusBadRequest,
+			error:  "invalid json",
+		},
+		{
+			name:   "body too large",
+			method: http.MethodPost,
+			target: subscriptionsPath,
+			body:   `{"service_name":"` + strings.Repeat("a", maxBodyBytes) + `"}`,
+			status: http.StatusRequestEntityTooLarge,
+			error:  "request body too large",
+		},
+		{
+			name:   "invalid query parameter",
+			method: http.MethodGet,
+			target: subscriptionsPath + "?user_id=nope&limit=5000",
+			status: http.StatusBadRequest,
+			error:  validationErrorMessage,
+			fields: []string{"limit", "user_id"},
+		},
+		{
+			name:   "summary requires dates",
+			method: http.MethodGet,
+			target: summaryPath + "?start_date=07-2025",
+			status: http.StatusBadRequest,
+			error:  validationErrorMessage,
+			fields: []string{"end_date"},
+		},
+		{
+			name:   "head is validated as get",
+			method: http.MethodHead,
+			target: subscriptionsPath + "?limit=5000",
+			status: http.StatusBadRequest,
+			error:  validationErrorMessage,
+			fields: []string{"limit"},
+		},
+		{
+			name:   "invalid path id",
+			method: http.MethodGet,
+			target: subscriptionsPath + "/not-a-uuid",
+			status: http.StatusBadRequest,
+			error:  validationErrorMessage,
+			fields: []string{"id"},
+		},
+		{
+			name:   "missing content type is accepted",
+			method: http.MethodPost,
+			target: subscriptionsPath,
+			body:   `{"service_name":"Netflix","price":500,"user_id":"` + userID.String() + `","start_date":"07-2025","end_date":null}`,
+			status: http.StatusCreated,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Fatalf("unexpected status: %d, body: %s", w.Code, w.Body.String())
+			}
+			if tc.error == "" {
+				return
+			}
+
+			var resp errorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Error != tc.error {
+				t.Fatalf("unexpected error: %q", resp.Error)
+			}
+
+			got := make([]string, 0, len(resp.Fields))
+			for _, f := range resp.Fields {
+				got = append(got, f.Field)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.fields, ",") {
+				t.Fatalf("unexpected fields: %+v", resp.Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// v1DeprecatedAt and v1Sunset are advertised on every v1 response so
+// clients can plan the move to v2.
+var (
+	v1DeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	v1Sunset       = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// deprecated marks responses of the v1 contract with the Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers and links to the successor.
+func (h *Handler) deprecated(next http.HandlerFunc) http.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(v1DeprecatedAt.Unix(), 10)
+	sunset := v1Sunset.Format(http.TimeFormat)
+	link := "<" + v2Prefix + "/subscriptions>; rel=\"successor-version\""
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Set("Sunset", sunset)
+		w.Header().Add("Link", link)
+		next(w, r)
+	}
+}
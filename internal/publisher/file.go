@@ -0,0 +1,52 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"restservice/internal/usecase/subscription"
+)
+
+// File appends every event as a JSON line to a file, which is handy for
+// watching events locally with tail -f.
+type File struct {
+	mu  sync.Mutex
+	f   *os.File
+	now func() time.Time
+}
+
+func NewFile(path string) (*File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open event file: %w", err)
+	}
+	return &File{f: f, now: time.Now}, nil
+}
+
+func (p *File) Publish(_ context.Context, events []subscription.Event) error {
+	var buf []byte
+	now := p.now()
+	for _, e := range events {
+		line, err := json.Marshal(newRecord(e, now))
+		if err != nil {
+			return fmt.Errorf("encode %s event: %w", e.EventType(), err)
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	// One write per batch keeps the lines of concurrent publishes whole.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.f.Write(buf); err != nil {
+		return fmt.Errorf("write event file: %w", err)
+	}
+	return nil
+}
+
+func (p *File) Close() error {
+	return p.f.Close()
+}
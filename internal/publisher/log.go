@@ -0,0 +1,27 @@
+package publisher
+
+import (
+	"context"
+	"log/slog"
+
+	"restservice/internal/usecase/subscription"
+)
+
+// Log writes every event to a logger at info level.
+type Log struct {
+	logger *slog.Logger
+}
+
+func NewLog(logger *slog.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (p *Log) Publish(ctx context.Context, events []subscription.Event) error {
+	for _, e := range events {
+		p.logger.InfoContext(ctx, "domain event",
+			"type", e.EventType(),
+			"subscription_id", e.SubscriptionID(),
+			"data", e.Payload())
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+// Package publisher provides subscription.Publisher implementations for
+// running the service without a message broker.
+package publisher
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/usecase/subscription"
+)
+
+// record is how a published event is written out.
+type record struct {
+	Type           string    `json:"type"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	PublishedAt    time.Time `json:"published_at"`
+	Data           any       `json:"data"`
+}
+
+func newRecord(e subscription.Event, now time.Time) record {
+	return record{
+		Type:           e.EventType(),
+		SubscriptionID: e.SubscriptionID(),
+		PublishedAt:    now.UTC(),
+		Data:           e.Payload(),
+	}
+}
@@ -0,0 +1,125 @@
+// Package ratelimit implements token-bucket rate limiting with an
+// in-memory backend for a single instance and a Postgres backend that
+// shares buckets across replicas.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period, refilled continuously. A client may
+// burst up to Burst requests at once, or up to Requests when Burst is
+// zero.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// ParseLimit parses "100/1m" style limits. The period may omit its count,
+// as in "10/s" or "100/m", and may be followed by a burst, as in
+// "100/m;burst=20".
+func ParseLimit(s string) (Limit, error) {
+	quota, burst, hasBurst := strings.Cut(s, ";")
+	count, period, ok := strings.Cut(quota, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q: expected <requests>/<period>", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("limit %q: requests must be a positive integer", s)
+	}
+	period = strings.TrimSpace(period)
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("limit %q: invalid period", s)
+	}
+	limit := Limit{Requests: n, Period: d}
+	if hasBurst {
+		key, raw, _ := strings.Cut(burst, "=")
+		if limit.Burst, err = strconv.Atoi(strings.TrimSpace(raw)); strings.TrimSpace(key) != "burst" || err != nil || limit.Burst <= 0 {
+			return Limit{}, fmt.Errorf("limit %q: burst must be a positive integer", s)
+		}
+	}
+	return limit, nil
+}
+
+func (l Limit) String() string {
+	period := l.Period.String()
+	switch l.Period {
+	case time.Second:
+		period = "s"
+	case time.Minute:
+		period = "m"
+	case time.Hour:
+		period = "h"
+	}
+	s := strconv.Itoa(l.Requests) + "/" + period
+	if l.Burst > 0 {
+		s += ";burst=" + strconv.Itoa(l.Burst)
+	}
+	return s
+}
+
+// Capacity is the size of the bucket: how many requests a client may make
+// at once.
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate is the refill speed in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result describes the state of a bucket after a request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request would be allowed; it
+	// is zero when Allowed is set.
+	RetryAfter time.Duration
+}
+
+// Limiter takes one token from the bucket identified by key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take refills a bucket holding tokens for elapsed time and removes one
+// token if possible. It is shared by all backends so they agree exactly.
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	capacity := float64(limit.Capacity())
+	if elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed.Seconds()*limit.rate())
+	}
+
+	res := Result{Limit: limit.Capacity()}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - tokens) / limit.rate())
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = secondsToDuration((capacity - tokens) / limit.rate())
+	return tokens, res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Limit
+		ok   bool
+	}{
+		{"100/m", Limit{100, time.Minute, 0}, true},
+		{"10/1s", Limit{10, time.Second, 0}, true},
+		{" 5 / 30s", Limit{5, 30 * time.Second, 0}, true},
+		{"100/m;burst=20", Limit{100, time.Minute, 20}, true},
+		{"100/m; burst = 20", Limit{100, time.Minute, 20}, true},
+		{"100/m;burst=0", Limit{}, false},
+		{"100/m;20", Limit{}, false},
+		{"100", Limit{}, false},
+		{"0/m", Limit{}, false},
+		{"10/x", Limit{}, false},
+	}
+	for _, tc := range cases {
+		got, err := ParseLimit(tc.in)
+		if (err == nil) != tc.ok {
+			t.Fatalf("%q: unexpected error: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: got %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryTokenBucket(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		res, _ := m.Allow(ctx, "client", limit)
+		if !res.Allowed || res.Remaining != i {
+			t.Fatalf("request %d: unexpected result %+v", 3-i, res)
+		}
+	}
+
+	res, _ := m.Allow(ctx, "client", limit)
+	if res.Allowed {
+		t.Fatal("expected the fourth request to be rejected")
+	}
+	if res.RetryAfter != time.Second {
+		t.Fatalf("unexpected retry after: %v", res.RetryAfter)
+	}
+	if res.Reset != 3*time.Second {
+		t.Fatalf("unexpected reset: %v", res.Reset)
+	}
+
+	if res, _ := m.Allow(ctx, "other", limit); !res.Allowed {
+		t.Fatal("buckets must be independent per key")
+	}
+
+	now = now.Add(time.Second)
+	if res, _ := m.Allow(ctx, "client", limit); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected one refilled token, got %+v", res)
+	}
+
+	now = now.Add(time.Hour)
+	m.Allow(ctx, "client", limit)
+	if _, ok := m.buckets["other"]; ok {
+		t.Fatal("expected idle bucket to be swept")
+	}
+}
+
+func TestMemoryBurst(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	limit := Limit{Requests: 1, Period: time.Second, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if res, _ := m.Allow(ctx, "client", limit); !res.Allowed || res.Limit != 3 {
+			t.Fatalf("request %d: expected the burst to allow it, got %+v", i+1, res)
+		}
+	}
+	res, _ := m.Allow(ctx, "client", limit)
+	if res.Allowed || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Fatalf("expected the bucket to refill at the rate, got %+v", res)
+	}
+}
@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory keeps buckets in process memory. Limits are per instance, so N
+// replicas together allow N times the configured rate.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket refills completely; after that it carries
+	// no state and can be dropped.
+	full time.Time
+}
+
+// memorySweepInterval bounds how often idle buckets are dropped.
+const memorySweepInterval = time.Minute
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*memoryBucket), now: time.Now}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(limit.Capacity()), updated: now}
+		m.buckets[key] = b
+	}
+	tokens, res := take(b.tokens, now.Sub(b.updated), limit)
+	b.tokens, b.updated, b.full = tokens, now, now.Add(res.Reset)
+	return res, nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// postgresPruneInterval bounds how often stale buckets are deleted.
+const postgresPruneInterval = time.Minute
+
+// Postgres keeps buckets in the rate_limit_buckets table so every replica
+// sees the same counts. Each call locks the bucket row, which serialises
+// requests of one client but not of different clients.
+type Postgres struct {
+	db *sql.DB
+
+	pruneMu   sync.Mutex
+	lastPrune time.Time
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+func (p *Postgres) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit: begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// The database clock is used throughout so replicas with drifting
+	// clocks still refill buckets consistently.
+	const lockQuery = `
+		insert into rate_limit_buckets (key, tokens, updated_at, full_at)
+		values ($1, $2, now(), now())
+		on conflict (key) do update set key = excluded.key
+		returning tokens, extract(epoch from now() - updated_at), now()
+	`
+	var (
+		tokens  float64
+		elapsed float64
+		now     time.Time
+	)
+	if err := tx.QueryRowContext(ctx, lockQuery, key, float64(limit.Capacity())).Scan(&tokens, &elapsed, &now); err != nil {
+		return Result{}, fmt.Errorf("rate limit: lock bucket: %w", err)
+	}
+
+	tokens, res := take(tokens, time.Duration(elapsed*float64(time.Second)), limit)
+
+	const updateQuery = `
+		update rate_limit_buckets
+		set tokens = $2, updated_at = $3, full_at = $4
+		where key = $1
+	`
+	if _, err := tx.ExecContext(ctx, updateQuery, key, tokens, now, now.Add(res.Reset)); err != nil {
+		return Result{}, fmt.Errorf("rate limit: update bucket: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Result{}, fmt.Errorf("rate limit: commit: %w", err)
+	}
+
+	p.prune(ctx)
+	return res, nil
+}
+
+// prune deletes buckets that have refilled completely, at most once per
+// postgresPruneInterval per instance. Failures are ignored; the next
+// prune catches up.
+func (p *Postgres) prune(ctx context.Context) {
+	if !p.pruneMu.TryLock() {
+		return
+	}
+	defer p.pruneMu.Unlock()
+
+	if time.Since(p.lastPrune) < postgresPruneInterval {
+		return
+	}
+	p.lastPrune = time.Now()
+	_, _ = p.db.ExecContext(ctx, `delete from rate_limit_buckets where full_at < now()`)
+}
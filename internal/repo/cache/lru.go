@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"restservice/internal/entity"
+)
+
+type kind int
+
+const (
+	kindGet kind = iota
+	kindList
+	kindSum
+	kinds
+)
+
+type entry struct {
+	key     string
+	kind    kind
+	value   any
+	expires time.Time
+	// affected reports whether a change to the row can change value.
+	affected func(entity.Subscription) bool
+}
+
+// lru holds at most size entries for ttl each, evicting the least recently
+// used first.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	now   func() time.Time
+	order *list.List // front is most recently used
+	items map[string]*list.Element
+	// generation counts invalidations. A value read from the repository
+	// before one is not stored after it, as it may predate the change.
+	generation uint64
+
+	hits, misses [kinds]uint64
+	evictions    uint64
+	expirations  uint64
+	invalidated  uint64
+}
+
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the cached value for key and the generation to pass to add
+// when there is none.
+func (c *lru) get(k kind, key string) (any, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		if c.now().Before(e.expires) {
+			c.order.MoveToFront(el)
+			c.hits[k]++
+			return e.value, c.generation, true
+		}
+		c.remove(el)
+		c.expirations++
+	}
+	c.misses[k]++
+	return nil, c.generation, false
+}
+
+// current returns the generation for a read that bypasses the cache.
+func (c *lru) current() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *lru) add(k kind, key string, value any, generation uint64, affected func(entity.Subscription) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	e := &entry{key: key, kind: k, value: value, expires: c.now().Add(c.ttl), affected: affected}
+	if el, ok := c.items[key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(e)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// invalidate drops the entries a change to any of rows can affect, or
+// every entry when rows is nil.
+func (c *lru) invalidate(rows []entity.Subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		e := el.Value.(*entry)
+		if rows == nil || affectsAny(e, rows) {
+			c.remove(el)
+			c.invalidated++
+		}
+		el = next
+	}
+}
+
+func affectsAny(e *entry, rows []entity.Subscription) bool {
+	for _, row := range rows {
+		if e.affected(row) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *lru) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
+
+func (c *lru) stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Get:           Counters{Hits: c.hits[kindGet], Misses: c.misses[kindGet]},
+		List:          Counters{Hits: c.hits[kindList], Misses: c.misses[kindList]},
+		Sum:           Counters{Hits: c.hits[kindSum], Misses: c.misses[kindSum]},
+		Entries:       c.order.Len(),
+		Evictions:     c.evictions,
+		Expirations:   c.expirations,
+		Invalidations: c.invalidated,
+	}
+}
@@ -0,0 +1,305 @@
+// Package cache decorates a subscription.Repository with an in-process
+// read-through cache for Get, List, Sum and SumGroups.
+//
+// Writes through the decorator drop exactly the entries they can change:
+// the subscription itself and the lists and summaries whose filters match
+// the row before or after the change. The row before is the one the caller
+// passes with subscription.Replacing, as the service does, and is only
+// read here when it does not. Inside a unit of work the entries
+// are dropped once it commits, and its reads bypass the cache, since they
+// may see changes other requests must not. Writes made elsewhere, by
+// another instance or directly in the database, are only seen once entries
+// expire, so the TTL bounds how stale a read can be.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
+)
+
+// Stats are cumulative since the cache was created.
+type Stats struct {
+	Get  Counters `json:"get"`
+	List Counters `json:"list"`
+	Sum  Counters `json:"sum"`
+	// Entries is the current number of cached values.
+	Entries int `json:"entries"`
+	// Evictions counts entries dropped to make room, Expirations those
+	// found past their TTL and Invalidations those dropped by writes.
+	Evictions     uint64 `json:"evictions"`
+	Expirations   uint64 `json:"expirations"`
+	Invalidations uint64 `json:"invalidations"`
+}
+
+type Counters struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// HitRatio is the share of lookups served from the cache.
+func (c Counters) HitRatio() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+type SubscriptionRepo struct {
+	next  subscription.Repository
+	cache *lru
+}
+
+// NewSubscriptionRepo caches up to size results of next for ttl each.
+func NewSubscriptionRepo(next subscription.Repository, size int, ttl time.Duration) *SubscriptionRepo {
+	return &SubscriptionRepo{next: next, cache: newLRU(size, ttl)}
+}
+
+func (r *SubscriptionRepo) Stats() Stats {
+	return r.cache.stats()
+}
+
+// LogStats logs the stats every interval until ctx is done.
+func (r *SubscriptionRepo) LogStats(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s := r.Stats()
+		logger.Info("subscription cache stats",
+			"entries", s.Entries,
+			"get_hits", s.Get.Hits, "get_misses", s.Get.Misses,
+			"list_hits", s.List.Hits, "list_misses", s.List.Misses,
+			"sum_hits", s.Sum.Hits, "sum_misses", s.Sum.Misses,
+			"evictions", s.Evictions,
+			"expirations", s.Expirations,
+			"invalidations", s.Invalidations,
+		)
+	}
+}
+
+// StatsHandler serves the stats as JSON, for the admin listener.
+func (r *SubscriptionRepo) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(r.Stats())
+	})
+}
+
+func (r *SubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	if subscription.InUnitOfWork(ctx) {
+		return r.next.Get(ctx, id)
+	}
+	key := "get:" + id.String()
+	v, gen, ok := r.lookup(ctx, kindGet, key)
+	if ok {
+		return v.(entity.Subscription), nil
+	}
+
+	sub, err := r.next.Get(ctx, id)
+	if err != nil {
+		return entity.Subscription{}, err
+	}
+	r.cache.add(kindGet, key, sub, gen, func(row entity.Subscription) bool { return row.ID == id })
+	return sub, nil
+}
+
+// GetForUpdate always reads through: the row is about to be replaced.
+func (r *SubscriptionRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	return r.next.GetForUpdate(ctx, id)
+}
+
+func (r *SubscriptionRepo) List(ctx context.Context, filter subscription.ListFilter) ([]entity.Subscription, error) {
+	if subscription.InUnitOfWork(ctx) {
+		return r.next.List(ctx, filter)
+	}
+	f := normalizeFilter(filter.UserID, filter.ServiceName, filter.Category, filter.Tag)
+	key := fmt.Sprintf("list:%s:%d:%d", f, max(filter.Limit, 0), max(filter.Offset, 0))
+	v, gen, ok := r.lookup(ctx, kindList, key)
+	if ok {
+		return slices.Clone(v.([]entity.Subscription)), nil
+	}
+
+	subs, err := r.next.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.add(kindList, key, slices.Clone(subs), gen, func(row entity.Subscription) bool {
+		return f.matches(row)
+	})
+	return subs, nil
+}
+
+func (r *SubscriptionRepo) Sum(ctx context.Context, filter subscription.SummaryFilter) (int, error) {
+	if subscription.InUnitOfWork(ctx) {
+		return r.next.Sum(ctx, filter)
+	}
+	key, affected := summaryKey("sum", filter)
+	v, gen, ok := r.lookup(ctx, kindSum, key)
+	if ok {
+		return v.(int), nil
+	}
+
+	total, err := r.next.Sum(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	r.cache.add(kindSum, key, total, gen, affected)
+	return total, nil
+}
+
+// SumGroups is cached and counted in the stats like Sum.
+func (r *SubscriptionRepo) SumGroups(ctx context.Context, filter subscription.SummaryFilter, by subscription.GroupBy) ([]subscription.GroupTotal, error) {
+	if subscription.InUnitOfWork(ctx) {
+		return r.next.SumGroups(ctx, filter, by)
+	}
+	key, affected := summaryKey("groups:"+string(by), filter)
+	v, gen, ok := r.lookup(ctx, kindSum, key)
+	if ok {
+		return slices.Clone(v.([]subscription.GroupTotal)), nil
+	}
+
+	groups, err := r.next.SumGroups(ctx, filter, by)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.add(kindSum, key, slices.Clone(groups), gen, affected)
+	return groups, nil
+}
+
+// summaryKey returns the key of a summary and the rows that change it:
+// those matching the filter that are billed in the period.
+func summaryKey(prefix string, filter subscription.SummaryFilter) (string, func(entity.Subscription) bool) {
+	f := normalizeFilter(filter.UserID, filter.ServiceName, filter.Category, filter.Tag)
+	start, end := filter.StartDate.UTC(), filter.EndDate.UTC()
+	key := fmt.Sprintf("%s:%s:%s:%s", prefix, f, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	return key, func(row entity.Subscription) bool {
+		return f.matches(row) && row.BilledIn(start, end)
+	}
+}
+
+func (r *SubscriptionRepo) Create(ctx context.Context, s entity.Subscription) (entity.Subscription, error) {
+	created, err := r.next.Create(ctx, s)
+	if err != nil {
+		return entity.Subscription{}, err
+	}
+	r.invalidate(ctx, []entity.Subscription{created})
+	return created, nil
+}
+
+func (r *SubscriptionRepo) Update(ctx context.Context, id uuid.UUID, s entity.Subscription) (entity.Subscription, error) {
+	before, known := r.current(ctx, id)
+	updated, err := r.next.Update(ctx, id, s)
+	if err != nil {
+		return entity.Subscription{}, err
+	}
+	if known {
+		r.invalidate(ctx, []entity.Subscription{before, updated})
+	} else {
+		r.invalidate(ctx, nil)
+	}
+	return updated, nil
+}
+
+func (r *SubscriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	before, known := r.current(ctx, id)
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	if known {
+		r.invalidate(ctx, []entity.Subscription{before})
+	} else {
+		r.invalidate(ctx, nil)
+	}
+	return nil
+}
+
+// invalidate drops the entries rows are part of, or every entry if rows
+// is nil, once the change is committed. Dropping them earlier would let a
+// concurrent read cache the old row again before the commit; the
+// generation check in add keeps reads that started before the commit from
+// storing it afterwards.
+func (r *SubscriptionRepo) invalidate(ctx context.Context, rows []entity.Subscription) {
+	subscription.AfterCommit(ctx, func() { r.cache.invalidate(rows) })
+}
+
+// lookup serves ctx from the cache unless it asks for primary reads: an
+// entry may hold a value read from a lagging replica. What the primary
+// returns is still cached.
+func (r *SubscriptionRepo) lookup(ctx context.Context, k kind, key string) (any, uint64, bool) {
+	if subscription.PrimaryReads(ctx) {
+		return nil, r.cache.current(), false
+	}
+	return r.cache.get(k, key)
+}
+
+// current returns the row a write is about to change, so that the entries
+// it was part of can be dropped: the one the caller passed with
+// subscription.Replacing, or else the stored one, bypassing the cache.
+// When reading it fails for any reason but a missing row, known is false
+// and the caller drops everything instead.
+func (r *SubscriptionRepo) current(ctx context.Context, id uuid.UUID) (sub entity.Subscription, known bool) {
+	if before, ok := subscription.Replaced(ctx, id); ok {
+		return before, true
+	}
+	sub, err := r.next.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, subscription.ErrNotFound) {
+			// The write fails too and changes nothing.
+			return entity.Subscription{ID: id}, true
+		}
+		return entity.Subscription{}, false
+	}
+	return sub, true
+}
+
+// filter is a list or summary filter with empty fields matching
+// everything.
+type filter struct {
+	userID, service, category, tag string
+}
+
+// normalizeFilter maps equivalent filters to one key, the way the
+// repositories read them: text filters are trimmed and blank ones match
+// everything.
+func normalizeFilter(userID *uuid.UUID, serviceName, category, tag *string) filter {
+	var f filter
+	if userID != nil {
+		f.userID = userID.String()
+	}
+	f.service, f.category, f.tag = trim(serviceName), trim(category), trim(tag)
+	return f
+}
+
+func trim(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(*s)
+}
+
+func (f filter) String() string {
+	return fmt.Sprintf("%s:%q:%q:%q", f.userID, f.service, f.category, f.tag)
+}
+
+func (f filter) matches(row entity.Subscription) bool {
+	return (f.userID == "" || row.UserID.String() == f.userID) &&
+		(f.service == "" || row.ServiceName == f.service) &&
+		(f.category == "" || row.Category == f.category) &&
+		(f.tag == "" || slices.Contains(row.Tags, f.tag))
+}
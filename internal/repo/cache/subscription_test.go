@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo/memory"
+	"restservice/internal/repo/repotest"
+	"restservice/internal/usecase/subscription"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(*testing.T) subscription.Repository {
+		mem := memory.NewSubscriptionRepo()
+		return repotest.EnsureUsers(NewSubscriptionRepo(mem, 100, time.Minute), func(ctx context.Context, id uuid.UUID) error {
+			if _, err := mem.Users().Get(ctx, id); err != nil {
+				mem.AddUsers(entity.User{ID: id})
+			}
+			return nil
+		})
+	})
+}
+
+func month(m time.Month) time.Time {
+	return time.Date(2025, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestInvalidation(t *testing.T) {
+	ctx := context.Background()
+	alice, bob := uuid.New(), uuid.New()
+	mem := memory.NewSubscriptionRepo()
+	mem.AddUsers(entity.User{ID: alice}, entity.User{ID: bob})
+	repo := NewSubscriptionRepo(mem, 100, time.Minute)
+
+	netflix, err := repo.Create(ctx, entity.Subscription{ServiceName: "Netflix", Price: 100, UserID: alice, StartDate: month(time.January)})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := repo.Create(ctx, entity.Subscription{ServiceName: "Spotify", Price: 10, UserID: bob, StartDate: month(time.January)}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	aliceList := subscription.ListFilter{UserID: &alice}
+	bobList := subscription.ListFilter{UserID: &bob}
+	bobSum := subscription.SummaryFilter{UserID: &bob, StartDate: month(time.January), EndDate: month(time.December)}
+	aliceSum := subscription.SummaryFilter{UserID: &alice, StartDate: month(time.January), EndDate: month(time.December)}
+	read := func() {
+		t.Helper()
+		if _, err := repo.Get(ctx, netflix.ID); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		for _, f := range []subscription.ListFilter{aliceList, bobList} {
+			if _, err := repo.List(ctx, f); err != nil {
+				t.Fatalf("list: %v", err)
+			}
+		}
+		for _, f := range []subscription.SummaryFilter{aliceSum, bobSum} {
+			if _, err := repo.Sum(ctx, f); err != nil {
+				t.Fatalf("sum: %v", err)
+			}
+		}
+	}
+
+	read()
+	read()
+	if s := repo.Stats(); s.Get != (Counters{1, 1}) || s.List != (Counters{2, 2}) || s.Sum != (Counters{2, 2}) {
+		t.Fatalf("unexpected stats after two reads: %+v", s)
+	}
+
+	// Changing Alice's subscription leaves Bob's entries cached.
+	netflix.Price = 200
+	if _, err := repo.Update(ctx, netflix.ID, netflix); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if s := repo.Stats(); s.Entries != 2 || s.Invalidations != 3 {
+		t.Fatalf("update must drop exactly Alice's three entries: %+v", s)
+	}
+	if got, _ := repo.Get(ctx, netflix.ID); got.Price != 200 {
+		t.Fatalf("stale get after update: %+v", got)
+	}
+	if total, _ := repo.Sum(ctx, aliceSum); total != 200*12 {
+		t.Fatalf("stale sum after update: %d", total)
+	}
+
+	// A subscription outside a cached period leaves its summary alone.
+	before := repo.Stats().Invalidations
+	old := month(time.January).AddDate(-2, 0, 0)
+	oldEnd := old.AddDate(0, 1, 0)
+	if _, err := repo.Create(ctx, entity.Subscription{ServiceName: "Netflix", Price: 1, UserID: alice, StartDate: old, EndDate: &oldEnd}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got := repo.Stats().Invalidations - before; got != 0 {
+		t.Fatalf("a row outside every cached entry dropped %d entries", got)
+	}
+
+	if err := repo.Delete(ctx, netflix.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if total, _ := repo.Sum(ctx, aliceSum); total != 0 {
+		t.Fatalf("stale sum after delete: %d", total)
+	}
+	if total, _ := repo.Sum(ctx, bobSum); total != 10*12 {
+		t.Fatalf("unexpected sum for bob: %d", total)
+	}
+}
+
+// checkedUnitOfWork runs check before and after fn, both before the
+// commit.
+type checkedUnitOfWork struct {
+	check func(ctx context.Context)
+}
+
+func (u checkedUnitOfWork) WithinTxLevel(ctx context.Context, _ sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	u.check(ctx)
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	u.check(ctx)
+	return nil
+}
+
+func (checkedUnitOfWork) Append(context.Context, entity.OutboxEvent) error { return nil }
+
+func TestUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	mem := memory.NewSubscriptionRepo()
+	mem.AddUsers(entity.User{ID: userID})
+	repo := NewSubscriptionRepo(mem, 100, time.Minute)
+
+	sub, err := repo.Create(ctx, entity.Subscription{ServiceName: "Netflix", Price: 100, UserID: userID, StartDate: month(time.January)})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := repo.Get(ctx, sub.ID); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	before := repo.Stats()
+	uow := checkedUnitOfWork{check: func(ctx context.Context) {
+		if _, err := repo.Get(ctx, sub.ID); err != nil {
+			t.Fatalf("get in unit of work: %v", err)
+		}
+		if s := repo.Stats(); s.Get != before.Get || s.Invalidations != before.Invalidations {
+			t.Fatalf("the cache was used before the commit: %+v", s)
+		}
+	}}
+	service := subscription.NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)), subscription.WithUnitOfWork(uow))
+
+	sub.Price = 200
+	if _, err := service.Update(ctx, sub.ID, sub); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if s := repo.Stats(); s.Invalidations != before.Invalidations+1 {
+		t.Fatalf("the commit must drop the cached row: %+v", s)
+	}
+	if got, _ := repo.Get(ctx, sub.ID); got.Price != 200 {
+		t.Fatalf("stale get after commit: %+v", got)
+	}
+}
+
+// countingGets counts the Get calls that reach the repository.
+type countingGets struct {
+	subscription.Repository
+	gets int
+}
+
+func (r *countingGets) Get(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	r.gets++
+	return r.Repository.Get(ctx, id)
+}
+
+func TestServiceWritesPassTheReplacedRow(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	mem := memory.NewSubscriptionRepo()
+	mem.AddUsers(entity.User{ID: userID})
+	next := &countingGets{Repository: mem}
+	repo := NewSubscriptionRepo(next, 100, time.Minute)
+	service := subscription.NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	sub, err := service.Create(ctx, entity.Subscription{ServiceName: "Netflix", Price: 100, UserID: userID, StartDate: month(time.January)})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	aliceSum := subscription.SummaryFilter{UserID: &userID, StartDate: month(time.January), EndDate: month(time.December)}
+	if _, err := repo.Sum(ctx, aliceSum); err != nil {
+		t.Fatalf("sum: %v", err)
+	}
+
+	sub.Price = 200
+	if _, err := service.Update(ctx, sub.ID, sub); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := service.Pause(ctx, sub.ID, month(time.March)); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if err := service.Delete(ctx, sub.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if next.gets != 0 {
+		t.Fatalf("writes read the row %d more times", next.gets)
+	}
+	if s := repo.Stats(); s.Entries != 0 {
+		t.Fatalf("the writes left %d entries cached", s.Entries)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	repo := NewSubscriptionRepo(memory.NewSubscriptionRepo(), 100, time.Minute)
+	if _, err := repo.Get(context.Background(), uuid.New()); err == nil {
+		t.Fatal("expected not found")
+	}
+
+	rec := httptest.NewRecorder()
+	repo.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/cache", nil))
+	var got Stats
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got != repo.Stats() || got.Get.Misses != 1 {
+		t.Fatalf("got %+v, want %+v", got, repo.Stats())
+	}
+}
+
+func TestLRU(t *testing.T) {
+	c := newLRU(2, time.Minute)
+	now := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	never := func(entity.Subscription) bool { return false }
+
+	c.add(kindGet, "a", 1, 0, never)
+	c.add(kindGet, "b", 2, 0, never)
+	c.get(kindGet, "a")
+	c.add(kindGet, "c", 3, 0, never)
+	if _, _, ok := c.get(kindGet, "b"); ok {
+		t.Fatal("the least recently used entry must be evicted")
+	}
+	if _, _, ok := c.get(kindGet, "a"); !ok {
+		t.Fatal("a recently used entry must stay")
+	}
+
+	now = now.Add(time.Minute)
+	if _, _, ok := c.get(kindGet, "a"); ok {
+		t.Fatal("an entry must expire after the TTL")
+	}
+
+	_, gen, _ := c.get(kindGet, "d")
+	c.invalidate(nil)
+	c.add(kindGet, "d", 4, gen, never)
+	if _, _, ok := c.get(kindGet, "d"); ok {
+		t.Fatal("a value read before an invalidation must not be stored")
+	}
+
+	if s := c.stats(); s.Evictions != 1 || s.Expirations != 1 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/catalog"
+)
+
+// CatalogRepo stores the services catalog and renames subscriptions to
+// its canonical names.
+type CatalogRepo struct {
+	db *sql.DB
+	tx *Transactor
+}
+
+func NewCatalogRepo(db *sql.DB) *CatalogRepo {
+	return &CatalogRepo{db: db, tx: NewTransactor(db)}
+}
+
+// serviceColumns selects a catalog entry with its aliases as a JSON array,
+// so one query loads both.
+const serviceColumns = `
+	s.id, s.name, s.category, s.vendor_url, s.default_price, s.created_at, s.updated_at,
+	coalesce((
+		select json_agg(json_build_object('pattern', a.pattern, 'match', a.match) order by a.match, a.pattern)
+		from service_aliases a
+		where a.service_id = s.id
+	), '[]')
+`
+
+type aliasRow struct {
+	Pattern string `json:"pattern"`
+	Match   string `json:"match"`
+}
+
+func scanService(row rowScanner) (entity.Service, error) {
+	var (
+		svc     entity.Service
+		aliases []byte
+	)
+	err := row.Scan(&svc.ID, &svc.Name, &svc.Category, &svc.VendorURL, &svc.DefaultPrice, &svc.CreatedAt, &svc.UpdatedAt, &aliases)
+	if err != nil {
+		return entity.Service{}, err
+	}
+	var rows []aliasRow
+	if err := json.Unmarshal(aliases, &rows); err != nil {
+		return entity.Service{}, fmt.Errorf("decode aliases: %w", err)
+	}
+	for _, a := range rows {
+		svc.Aliases = append(svc.Aliases, entity.ServiceAlias{Pattern: a.Pattern, Match: entity.AliasMatch(a.Match)})
+	}
+	svc.CreatedAt = svc.CreatedAt.UTC()
+	svc.UpdatedAt = svc.UpdatedAt.UTC()
+	return svc, nil
+}
+
+// insertAliases adds the aliases of service id. A pattern another entry
+// already uses is a conflict.
+func (r *CatalogRepo) insertAliases(ctx context.Context, id uuid.UUID, aliases []entity.ServiceAlias) error {
+	const q = `insert into service_aliases (service_id, pattern, match) values ($1, $2, $3)`
+	for _, alias := range aliases {
+		if _, err := conn(ctx, r.db).ExecContext(ctx, q, id, alias.Pattern, string(alias.Match)); err != nil {
+			if hasSQLState(err, sqlStateUniqueViolation) {
+				return catalog.ErrConflict
+			}
+			return fmt.Errorf("insert alias %q: %w", alias.Pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/catalog"
+)
+
+func (r *CatalogRepo) Create(ctx context.Context, svc entity.Service) (entity.Service, error) {
+	const q = `
+		insert into services (name, name_key, category, vendor_url, default_price)
+		values ($1, $2, $3, $4, $5)
+		returning id
+	`
+
+	var created entity.Service
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var id uuid.UUID
+		err := conn(ctx, r.db).QueryRowContext(ctx, q,
+			svc.Name, catalog.Key(svc.Name), svc.Category, svc.VendorURL, svc.DefaultPrice,
+		).Scan(&id)
+		if err != nil {
+			if hasSQLState(err, sqlStateUniqueViolation) {
+				return catalog.ErrConflict
+			}
+			return fmt.Errorf("create service: %w", err)
+		}
+		if err := r.insertAliases(ctx, id, svc.Aliases); err != nil {
+			return err
+		}
+		created, err = r.Get(ctx, id)
+		return err
+	})
+	if err != nil {
+		return entity.Service{}, err
+	}
+	return created, nil
+}
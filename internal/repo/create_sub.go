@@ -4,22 +4,45 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+
 	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
 )
 
 func (r *SubscriptionRepo) Create(ctx context.Context, s entity.Subscription) (entity.Subscription, error) {
+	// The tags are upserted and linked in the same statement, so the
+	// subscription is never stored without them.
 	const q = `
-		insert into subscriptions (service_name, price, user_id, start_date, end_date)
-		values ($1, $2, $3, $4, $5)
-		returning id
+		with sub as (
+			insert into subscriptions (service_name, price, user_id, start_date, end_date, category,
+				status, trial_end_date, cancelled_at, pauses)
+			values ($1, $2, $3, $4, $5, $6, coalesce(nullif($8, ''), 'active'), $9, $10, $11::jsonb)
+			returning id, updated_at, status
+		),
+		tag as (
+			insert into tags (name)
+			select unnest(string_to_array($7, ','))
+			on conflict (name) do update set name = excluded.name
+			returning id
+		),
+		link as (
+			insert into subscription_tags (subscription_id, tag_id)
+			select sub.id, tag.id from sub, tag
+		)
+		select id, updated_at, status from sub
 		`
 
 	var endDate sql.NullTime
 	if s.EndDate != nil {
 		endDate = sql.NullTime{Time: s.EndDate.UTC(), Valid: true}
 	}
+	pauses, err := encodePauses(s.Pauses)
+	if err != nil {
+		return entity.Subscription{}, err
+	}
 
-	err := r.db.QueryRowContext(
+	var status string
+	err = conn(ctx, r.db).QueryRowContext(
 		ctx,
 		q,
 		s.ServiceName,
@@ -27,11 +50,22 @@ func (r *SubscriptionRepo) Create(ctx context.Context, s entity.Subscription) (e
 		s.UserID,
 		s.StartDate.UTC(),
 		endDate,
-	).Scan(&s.ID)
+		s.Category,
+		joinTags(s.Tags),
+		string(s.Status),
+		nullTime(s.TrialEndDate),
+		nullTime(s.CancelledAt),
+		pauses,
+	).Scan(&s.ID, &s.UpdatedAt, &status)
 
 	if err != nil {
+		if hasSQLState(err, sqlStateForeignKeyViolation) {
+			return entity.Subscription{}, subscription.ErrUnknownUser
+		}
 		return entity.Subscription{}, fmt.Errorf("create subscription: %w", err)
 	}
 
+	s.UpdatedAt = s.UpdatedAt.UTC()
+	s.Status = entity.Status(status)
 	return s, nil
 }
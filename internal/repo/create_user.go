@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/user"
+)
+
+func (r *UserRepo) Create(ctx context.Context, u entity.User) (entity.User, error) {
+	const q = `
+		insert into users (name, email, timezone, currency)
+		values ($1, $2, $3, $4)
+		returning ` + userColumns
+
+	created, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, q, u.Name, nullEmail(u.Email), u.Timezone, u.Currency))
+	if err != nil {
+		if hasSQLState(err, sqlStateUniqueViolation) {
+			return entity.User{}, user.ErrEmailTaken
+		}
+		return entity.User{}, fmt.Errorf("create user: %w", err)
+	}
+	return created, nil
+}
@@ -0,0 +1,24 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"restservice/internal/entity"
+)
+
+func (r *WebhookRepo) Create(ctx context.Context, w entity.Webhook) (entity.Webhook, error) {
+	const q = `
+		insert into webhooks (url, events, secret)
+		values ($1, string_to_array($2, ','), $3)
+		returning id, created_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, q, w.URL, joinEvents(w.Events), w.Secret).Scan(&w.ID, &w.CreatedAt)
+	if err != nil {
+		return entity.Webhook{}, fmt.Errorf("create webhook: %w", err)
+	}
+
+	w.CreatedAt = w.CreatedAt.UTC()
+	return w, nil
+}
@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/usecase/catalog"
+)
+
+// Delete removes a catalog entry with its aliases. Subscriptions keep the
+// canonical name they were stored with.
+func (r *CatalogRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	const q = `delete from services where id = $1`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("delete service: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete service rows: %w", err)
+	}
+
+	if affected == 0 {
+		return catalog.ErrNotFound
+	}
+	return nil
+}
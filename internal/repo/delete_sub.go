@@ -12,7 +12,7 @@ import (
 func (r *SubscriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	const q = `delete from subscriptions where id = $1`
 
-	res, err := r.db.ExecContext(ctx, q, id)
+	res, err := conn(ctx, r.db).ExecContext(ctx, q, id)
 	if err != nil {
 		return fmt.Errorf("delete subscription: %w", err)
 	}
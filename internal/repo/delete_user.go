@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/usecase/user"
+)
+
+// Delete removes a user. The foreign key from subscriptions refuses it
+// while the user still has any.
+func (r *UserRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	const q = `delete from users where id = $1`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, q, id)
+	if err != nil {
+		if hasSQLState(err, sqlStateForeignKeyViolation) {
+			return user.ErrHasSubscriptions
+		}
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete user rows: %w", err)
+	}
+
+	if affected == 0 {
+		return user.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/usecase/webhook"
+)
+
+// Delete removes a webhook together with its deliveries.
+func (r *WebhookRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	const q = `delete from webhooks where id = $1`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete webhook rows: %w", err)
+	}
+
+	if affected == 0 {
+		return webhook.ErrNotFound
+	}
+	return nil
+}
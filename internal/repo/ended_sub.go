@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"restservice/internal/entity"
+)
+
+// MarkEnded marks up to limit subscriptions whose end month is over as of
+// now as reported and returns them. Rows another reporter holds are
+// skipped, so replicas can report at the same time.
+func (r *SubscriptionRepo) MarkEnded(ctx context.Context, now time.Time, limit int) ([]entity.Subscription, error) {
+	const q = `
+		update subscriptions
+		set ended_notified_at = now()
+		where id in (
+			select id from subscriptions
+			where end_date < date_trunc('month', $1::timestamptz)::date
+			  and ended_notified_at is null
+			order by id
+			limit $2
+			for update skip locked
+		)
+		returning id, service_name, price, user_id, start_date, end_date, updated_at,
+			category, ` + tagsColumn + `, ` + lifecycleColumns
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("mark ended subscriptions: %w", err)
+	}
+	defer rows.Close()
+	return scanSubscriptions(rows)
+}
@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/catalog"
+)
+
+func (r *CatalogRepo) Get(ctx context.Context, id uuid.UUID) (entity.Service, error) {
+	q := `select ` + serviceColumns + ` from services s where s.id = $1`
+
+	svc, err := scanService(conn(ctx, r.db).QueryRowContext(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Service{}, catalog.ErrNotFound
+		}
+		return entity.Service{}, fmt.Errorf("get service: %w", err)
+	}
+	return svc, nil
+}
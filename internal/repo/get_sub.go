@@ -11,35 +11,61 @@ import (
 	"restservice/internal/usecase/subscription"
 )
 
-func (r *SubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
-	const q = `
-		select id, service_name, price, user_id, start_date, end_date
+const getSubscriptionSQL = `
+		select id, service_name, price, user_id, start_date, end_date, updated_at,
+			category, ` + tagsColumn + `, ` + lifecycleColumns + `
 		from subscriptions
 		where id = $1
 	`
 
+func (r *SubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	var sub entity.Subscription
+	err := r.read(ctx, func(db querier) error {
+		var err error
+		sub, err = getSubscription(ctx, db, getSubscriptionSQL, id)
+		return err
+	})
+	return sub, err
+}
+
+// GetForUpdate reads the row from the primary and locks it until the
+// transaction in ctx ends.
+func (r *SubscriptionRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	return getSubscription(ctx, conn(ctx, r.db), getSubscriptionSQL+"for update\n", id)
+}
+
+func getSubscription(ctx context.Context, db querier, q string, id uuid.UUID) (entity.Subscription, error) {
 	var (
 		sub     entity.Subscription
 		endDate sql.NullTime
+		tags    string
+		life    lifecycleRow
 	)
 
-	err := r.db.QueryRowContext(ctx, q, id).Scan(
+	err := db.QueryRowContext(ctx, q, id).Scan(append([]any{
 		&sub.ID,
 		&sub.ServiceName,
 		&sub.Price,
 		&sub.UserID,
 		&sub.StartDate,
 		&endDate,
-	)
-
+		&sub.UpdatedAt,
+		&sub.Category,
+		&tags,
+	}, life.dest()...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Subscription{}, subscription.ErrNotFound
+	}
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return entity.Subscription{}, subscription.ErrNotFound
-		}
 		return entity.Subscription{}, err
 	}
 
 	sub.StartDate = sub.StartDate.UTC()
+	sub.UpdatedAt = sub.UpdatedAt.UTC()
+	sub.Tags = splitTags(tags)
+	if err := life.apply(&sub); err != nil {
+		return entity.Subscription{}, err
+	}
 
 	if endDate.Valid {
 		t := endDate.Time.UTC()
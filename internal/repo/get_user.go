@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/user"
+)
+
+func (r *UserRepo) Get(ctx context.Context, id uuid.UUID) (entity.User, error) {
+	const q = `select ` + userColumns + ` from users where id = $1`
+
+	u, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, user.ErrNotFound
+		}
+		return entity.User{}, fmt.Errorf("get user: %w", err)
+	}
+	return u, nil
+}
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/webhook"
+)
+
+func (r *WebhookRepo) Get(ctx context.Context, id uuid.UUID) (entity.Webhook, error) {
+	const q = `
+		select id, url, array_to_string(events, ','), secret, created_at
+		from webhooks
+		where id = $1
+	`
+
+	var (
+		w      entity.Webhook
+		events string
+	)
+	err := conn(ctx, r.db).QueryRowContext(ctx, q, id).Scan(&w.ID, &w.URL, &events, &w.Secret, &w.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Webhook{}, webhook.ErrNotFound
+		}
+		return entity.Webhook{}, fmt.Errorf("get webhook: %w", err)
+	}
+
+	w.Events = splitEvents(events)
+	w.CreatedAt = w.CreatedAt.UTC()
+	return w, nil
+}
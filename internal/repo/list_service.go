@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/catalog"
+)
+
+func (r *CatalogRepo) List(ctx context.Context, filter catalog.ListFilter) ([]entity.Service, error) {
+	q := `select ` + serviceColumns + ` from services s`
+	var args []any
+	if filter.Category != "" {
+		args = append(args, filter.Category)
+		q += ` where s.category = $1`
+	}
+	q += ` order by s.name_key`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list services: %w", err)
+	}
+	defer rows.Close()
+
+	var items []entity.Service
+	for rows.Next() {
+		svc, err := scanService(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan service: %w", err)
+		}
+		items = append(items, svc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list services rows: %w", err)
+	}
+
+	return items, nil
+}
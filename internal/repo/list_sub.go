@@ -12,7 +12,8 @@ import (
 
 func (r *SubscriptionRepo) List(ctx context.Context, filter subscription.ListFilter) ([]entity.Subscription, error) {
 	base := `
-		select id, service_name, price, user_id, start_date, end_date
+		select id, service_name, price, user_id, start_date, end_date, updated_at,
+			category, ` + tagsColumn + `, ` + lifecycleColumns + `
 		from subscriptions
 		where 1=1
 	`
@@ -29,35 +30,68 @@ func (r *SubscriptionRepo) List(ctx context.Context, filter subscription.ListFil
 		args = append(args, strings.TrimSpace(*filter.ServiceName))
 		cond = append(cond, fmt.Sprintf("service_name = $%d", len(args)))
 	}
+	args, cond = labelConds(args, cond, filter.Category, filter.Tag)
 
 	if len(cond) > 0 {
 		base += " and " + strings.Join(cond, " and ")
 	}
 	base += " order by start_date desc, id"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		base += fmt.Sprintf(" limit $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		base += fmt.Sprintf(" offset $%d", len(args))
+	}
+
+	var subs []entity.Subscription
+	err := r.read(ctx, func(db querier) error {
+		rows, err := db.QueryContext(ctx, base, args...)
+		if err != nil {
+			return fmt.Errorf("list subscriptions: %w", err)
+		}
+		defer rows.Close()
 
-	rows, err := r.db.QueryContext(ctx, base, args...)
+		subs, err = scanSubscriptions(rows)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("list subscriptions: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
 
+	return subs, nil
+}
+
+// scanSubscriptions reads rows of the columns List selects.
+func scanSubscriptions(rows *sql.Rows) ([]entity.Subscription, error) {
 	var subs []entity.Subscription
 	for rows.Next() {
 		var (
 			sub     entity.Subscription
 			endDate sql.NullTime
+			tags    string
+			life    lifecycleRow
 		)
-		if err := rows.Scan(
+		if err := rows.Scan(append([]any{
 			&sub.ID,
 			&sub.ServiceName,
 			&sub.Price,
 			&sub.UserID,
 			&sub.StartDate,
 			&endDate,
-		); err != nil {
+			&sub.UpdatedAt,
+			&sub.Category,
+			&tags,
+		}, life.dest()...)...); err != nil {
 			return nil, fmt.Errorf("scan subscription: %w", err)
 		}
 		sub.StartDate = sub.StartDate.UTC()
+		sub.UpdatedAt = sub.UpdatedAt.UTC()
+		sub.Tags = splitTags(tags)
+		if err := life.apply(&sub); err != nil {
+			return nil, err
+		}
 		if endDate.Valid {
 			t := endDate.Time.UTC()
 			sub.EndDate = &t
@@ -68,6 +102,5 @@ func (r *SubscriptionRepo) List(ctx context.Context, filter subscription.ListFil
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("list subscriptions rows: %w", err)
 	}
-
 	return subs, nil
 }
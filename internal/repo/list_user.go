@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/user"
+)
+
+func (r *UserRepo) List(ctx context.Context, filter user.ListFilter) ([]entity.User, error) {
+	q := `select ` + userColumns + ` from users order by created_at, id`
+	var args []any
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		q += fmt.Sprintf(" limit $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		q += fmt.Sprintf(" offset $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+
+	var items []entity.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		items = append(items, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list users rows: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"restservice/internal/entity"
+)
+
+func (r *WebhookRepo) List(ctx context.Context) ([]entity.Webhook, error) {
+	const q = `
+		select id, url, array_to_string(events, ','), secret, created_at
+		from webhooks
+		order by created_at, id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var items []entity.Webhook
+	for rows.Next() {
+		var (
+			w      entity.Webhook
+			events string
+		)
+		if err := rows.Scan(&w.ID, &w.URL, &events, &w.Secret, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan webhook: %w", err)
+		}
+		w.Events = splitEvents(events)
+		w.CreatedAt = w.CreatedAt.UTC()
+		items = append(items, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list webhooks rows: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+// snapshotVersion is bumped when the file format changes incompatibly.
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version       int              `json:"version"`
+	SavedAt       time.Time        `json:"saved_at"`
+	Subscriptions []snapshotRecord `json:"subscriptions"`
+	// Users were added later; the users of older snapshots are
+	// backfilled from their subscriptions.
+	Users []snapshotUser `json:"users,omitempty"`
+}
+
+type snapshotUser struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Timezone  string    `json:"timezone"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type snapshotRecord struct {
+	ID          uuid.UUID  `json:"id"`
+	ServiceName string     `json:"service_name"`
+	Price       int        `json:"price"`
+	UserID      uuid.UUID  `json:"user_id"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+	// The labels and the lifecycle were added later; older snapshots
+	// without them still load.
+	Category     string         `json:"category,omitempty"`
+	Tags         []string       `json:"tags,omitempty"`
+	Status       entity.Status  `json:"status,omitempty"`
+	TrialEndDate *time.Time     `json:"trial_end_date,omitempty"`
+	Pauses       []entity.Pause `json:"pauses,omitempty"`
+	CancelledAt  *time.Time     `json:"cancelled_at,omitempty"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// Open returns a repository restored from the snapshot at path. A missing
+// file gives an empty repository; the first Save creates it.
+func Open(path string) (*SubscriptionRepo, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewSubscriptionRepo(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot %s: %w", path, err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("snapshot %s: unsupported version %d", path, snap.Version)
+	}
+
+	subs := make([]entity.Subscription, 0, len(snap.Subscriptions))
+	for _, rec := range snap.Subscriptions {
+		subs = append(subs, entity.Subscription(rec))
+	}
+	r := NewSubscriptionRepo(subs...)
+	for _, u := range snap.Users {
+		r.users[u.ID] = entity.User(u)
+	}
+	return r, nil
+}
+
+// Save writes every subscription to path. The file is replaced atomically,
+// so a crash mid-write leaves the previous snapshot intact.
+func (r *SubscriptionRepo) Save(path string) error {
+	_, err := r.save(path)
+	return err
+}
+
+// SaveEvery saves to path every interval until ctx is done, skipping
+// intervals without changes. Errors are logged and retried on the next
+// tick. The final save at shutdown is the caller's, made once nothing
+// writes any more.
+func (r *SubscriptionRepo) SaveEvery(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	r.mu.RLock()
+	saved := r.version
+	r.mu.RUnlock()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.RLock()
+		changed := r.version != saved
+		r.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		version, err := r.save(path)
+		if err != nil {
+			logger.Error("save snapshot", "path", path, "error", err)
+			continue
+		}
+		saved = version
+	}
+}
+
+// save writes the snapshot and returns the version it captured.
+func (r *SubscriptionRepo) save(path string) (uint64, error) {
+	r.mu.RLock()
+	version := r.version
+	snap := snapshot{
+		Version:       snapshotVersion,
+		SavedAt:       time.Now().UTC(),
+		Subscriptions: make([]snapshotRecord, 0, len(r.items)),
+	}
+	for _, s := range r.items {
+		snap.Subscriptions = append(snap.Subscriptions, snapshotRecord(s))
+	}
+	for _, u := range r.users {
+		snap.Users = append(snap.Users, snapshotUser(u))
+	}
+	r.mu.RUnlock()
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return 0, fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return 0, fmt.Errorf("sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("replace snapshot: %w", err)
+	}
+	return version, nil
+}
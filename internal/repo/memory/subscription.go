@@ -0,0 +1,298 @@
+// Package memory keeps subscriptions in process memory. It needs no
+// database, which suits demos, integration tests of services that consume
+// this API and offline development; with a snapshot file the data also
+// survives restarts.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
+)
+
+// SubscriptionRepo orders and filters like the SQL repositories and, like
+// their schema, refuses subscriptions that break its checks or belong to an
+// unknown user. It also keeps the users, see Users, and runs transactions,
+// see UnitOfWork.
+type SubscriptionRepo struct {
+	mu    sync.RWMutex
+	items map[uuid.UUID]entity.Subscription
+	users map[uuid.UUID]entity.User
+	// version counts changes so that periodic snapshots skip idle periods.
+	version uint64
+}
+
+// NewSubscriptionRepo returns a repository holding subs exactly as given,
+// ids and updated_at included. Their users are added with default
+// settings, as the users migration backfills them.
+func NewSubscriptionRepo(subs ...entity.Subscription) *SubscriptionRepo {
+	r := &SubscriptionRepo{
+		items: make(map[uuid.UUID]entity.Subscription, len(subs)),
+		users: make(map[uuid.UUID]entity.User),
+	}
+	for _, s := range subs {
+		r.items[s.ID] = s
+		if _, ok := r.users[s.UserID]; !ok {
+			r.users[s.UserID] = backfilledUser(s.UserID)
+		}
+	}
+	return r
+}
+
+// AddUsers stores users exactly as given, ids and timestamps included,
+// replacing those with the same id.
+func (r *SubscriptionRepo) AddUsers(users ...entity.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	r.version++
+}
+
+func (r *SubscriptionRepo) Create(ctx context.Context, s entity.Subscription) (entity.Subscription, error) {
+	if err := checkSchema(s); err != nil {
+		return entity.Subscription{}, err
+	}
+	s = normalize(s)
+	s.ID = uuid.New()
+	s.UpdatedAt = now()
+
+	defer r.lock(ctx)()
+	if _, ok := r.users[s.UserID]; !ok {
+		return entity.Subscription{}, subscription.ErrUnknownUser
+	}
+	r.remember(ctx, s.ID)
+	r.items[s.ID] = s
+	r.version++
+	return clone(s), nil
+}
+
+func (r *SubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	defer r.rlock(ctx)()
+	s, ok := r.items[id]
+	if !ok {
+		return entity.Subscription{}, subscription.ErrNotFound
+	}
+	return clone(s), nil
+}
+
+// GetForUpdate is Get: a UnitOfWork transaction holds the write lock from
+// its first read, so the row cannot change until it ends.
+func (r *SubscriptionRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	return r.Get(ctx, id)
+}
+
+func (r *SubscriptionRepo) List(ctx context.Context, filter subscription.ListFilter) ([]entity.Subscription, error) {
+	unlock := r.rlock(ctx)
+	var subs []entity.Subscription
+	crit := criteria{filter.UserID, filter.ServiceName, filter.Category, filter.Tag}
+	for _, s := range r.items {
+		if crit.matches(s) {
+			subs = append(subs, clone(s))
+		}
+	}
+	unlock()
+
+	// Newest start date first, ties broken by id, as in the SQL
+	// repositories.
+	slices.SortFunc(subs, func(a, b entity.Subscription) int {
+		if c := b.StartDate.Compare(a.StartDate); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	subs = subs[min(filter.Offset, len(subs)):]
+	if filter.Limit > 0 {
+		subs = subs[:min(filter.Limit, len(subs))]
+	}
+	return subs, nil
+}
+
+func (r *SubscriptionRepo) Update(ctx context.Context, id uuid.UUID, s entity.Subscription) (entity.Subscription, error) {
+	defer r.lock(ctx)()
+	if _, ok := r.items[id]; !ok {
+		return entity.Subscription{}, subscription.ErrNotFound
+	}
+	if err := checkSchema(s); err != nil {
+		return entity.Subscription{}, err
+	}
+	if _, ok := r.users[s.UserID]; !ok {
+		return entity.Subscription{}, subscription.ErrUnknownUser
+	}
+	s = normalize(s)
+	s.ID = id
+	s.UpdatedAt = now()
+	r.remember(ctx, id)
+	r.items[id] = s
+	r.version++
+	return clone(s), nil
+}
+
+func (r *SubscriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	defer r.lock(ctx)()
+	if _, ok := r.items[id]; !ok {
+		return subscription.ErrNotFound
+	}
+	r.remember(ctx, id)
+	delete(r.items, id)
+	r.version++
+	return nil
+}
+
+// Sum counts a subscription's price for every month of the period it is
+// billed for.
+func (r *SubscriptionRepo) Sum(ctx context.Context, filter subscription.SummaryFilter) (int, error) {
+	total := 0
+	r.eachInPeriod(ctx, filter, func(s entity.Subscription, cost int) { total += cost })
+	return total, nil
+}
+
+// SumGroups counts a subscription with several tags in each of their
+// groups, and one without any in the group with the empty key.
+func (r *SubscriptionRepo) SumGroups(ctx context.Context, filter subscription.SummaryFilter, by subscription.GroupBy) ([]subscription.GroupTotal, error) {
+	totals := make(map[string]int)
+	r.eachInPeriod(ctx, filter, func(s entity.Subscription, cost int) {
+		switch {
+		case by == subscription.GroupByCategory:
+			totals[s.Category] += cost
+		case len(s.Tags) == 0:
+			totals[""] += cost
+		default:
+			for _, tag := range s.Tags {
+				totals[tag] += cost
+			}
+		}
+	})
+
+	groups := make([]subscription.GroupTotal, 0, len(totals))
+	for _, key := range slices.Sorted(maps.Keys(totals)) {
+		groups = append(groups, subscription.GroupTotal{Key: key, Total: totals[key]})
+	}
+	return groups, nil
+}
+
+// eachInPeriod calls fn with the subscriptions billed in the period and
+// what they cost in it.
+func (r *SubscriptionRepo) eachInPeriod(ctx context.Context, filter subscription.SummaryFilter, fn func(s entity.Subscription, cost int)) {
+	start, end := truncateDate(filter.StartDate), truncateDate(filter.EndDate)
+	crit := criteria{filter.UserID, filter.ServiceName, filter.Category, filter.Tag}
+
+	defer r.rlock(ctx)()
+	for _, s := range r.items {
+		if !crit.matches(s) {
+			continue
+		}
+		if months := s.BilledMonths(start, end); months > 0 {
+			fn(s, s.Price*months)
+		}
+	}
+}
+
+// checkSchema refuses what the SQL schema's checks refuse. Required fields
+// and the rest of subscription.Validate are the service's business.
+func checkSchema(s entity.Subscription) error {
+	switch {
+	case s.Price <= 0:
+		return fmt.Errorf("%w: price must be positive", subscription.ErrValidation)
+	case s.EndDate != nil && truncateDate(*s.EndDate).Before(truncateDate(s.StartDate)):
+		return fmt.Errorf("%w: end date before start date", subscription.ErrValidation)
+	}
+	switch s.Status {
+	case "", entity.StatusTrial, entity.StatusActive, entity.StatusPaused, entity.StatusCancelled:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown status %q", subscription.ErrValidation, s.Status)
+}
+
+// criteria are the list and summary filters. Blank text filters match
+// everything, and they are trimmed as in SQL.
+type criteria struct {
+	userID                     *uuid.UUID
+	serviceName, category, tag *string
+}
+
+func (c criteria) matches(s entity.Subscription) bool {
+	if c.userID != nil && s.UserID != *c.userID {
+		return false
+	}
+	if name, ok := textFilter(c.serviceName); ok && s.ServiceName != name {
+		return false
+	}
+	if category, ok := textFilter(c.category); ok && s.Category != category {
+		return false
+	}
+	if tag, ok := textFilter(c.tag); ok && !slices.Contains(s.Tags, tag) {
+		return false
+	}
+	return true
+}
+
+func textFilter(s *string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	v := strings.TrimSpace(*s)
+	return v, v != ""
+}
+
+// normalize keeps dates the way a date column would, UTC midnight, tags
+// the way the tag tables would, a sorted set, and an empty status as the
+// column default.
+func normalize(s entity.Subscription) entity.Subscription {
+	s.StartDate = truncateDate(s.StartDate)
+	s.EndDate = truncateDatePtr(s.EndDate)
+	s.TrialEndDate = truncateDatePtr(s.TrialEndDate)
+	s.CancelledAt = truncateDatePtr(s.CancelledAt)
+	pauses := make([]entity.Pause, 0, len(s.Pauses))
+	for _, p := range s.Pauses {
+		pauses = append(pauses, entity.Pause{Start: truncateDate(p.Start), End: truncateDatePtr(p.End)})
+	}
+	s.Pauses = pauses
+	if len(pauses) == 0 {
+		s.Pauses = nil
+	}
+	if s.Status == "" {
+		s.Status = entity.StatusActive
+	}
+	s.Tags = slices.Compact(slices.Sorted(slices.Values(s.Tags)))
+	if len(s.Tags) == 0 {
+		s.Tags = nil
+	}
+	return s
+}
+
+// clone keeps callers from changing stored tags through a returned row.
+func clone(s entity.Subscription) entity.Subscription {
+	s.Tags = slices.Clone(s.Tags)
+	s.Pauses = slices.Clone(s.Pauses)
+	return s
+}
+
+func truncateDatePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	d := truncateDate(*t)
+	return &d
+}
+
+func truncateDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// now matches the microsecond precision Postgres keeps for timestamps.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo/repotest"
+	"restservice/internal/usecase/subscription"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(*testing.T) subscription.Repository { return withUsers(NewSubscriptionRepo()) })
+}
+
+func TestUnitOfWork(t *testing.T) {
+	r := NewSubscriptionRepo()
+	repotest.RunUnitOfWork(t, withUsers(r), r.UnitOfWork())
+}
+
+func TestUnitOfWorkRollback(t *testing.T) {
+	ctx := context.Background()
+	kept := entity.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 400, UserID: uuid.New(),
+		StartDate: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)}
+	r := NewSubscriptionRepo(kept)
+
+	failed := errors.New("failed")
+	err := r.UnitOfWork().WithinTxLevel(ctx, sql.LevelDefault, func(ctx context.Context) error {
+		if _, err := r.Create(ctx, entity.Subscription{ServiceName: "Spotify", Price: 200, UserID: kept.UserID, StartDate: kept.StartDate}); err != nil {
+			return err
+		}
+		changed := kept
+		changed.Price = 500
+		if _, err := r.Update(ctx, kept.ID, changed); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+
+	subs, err := r.List(ctx, subscription.ListFilter{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(subs) != 1 || subs[0].Price != kept.Price {
+		t.Fatalf("expected the failed transaction to be undone, got %+v", subs)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	empty, err := Open(path)
+	if err != nil {
+		t.Fatalf("open missing snapshot: %v", err)
+	}
+	if subs, _ := empty.List(ctx, subscription.ListFilter{}); len(subs) != 0 {
+		t.Fatalf("expected an empty repository, got %d rows", len(subs))
+	}
+
+	owner, err := empty.Users().Create(ctx, entity.User{Name: "Ivan", Email: "ivan@example.com", Timezone: "UTC", Currency: "RUB"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	end := time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)
+	created, err := empty.Create(ctx, entity.Subscription{
+		ServiceName: "Netflix",
+		Price:       500,
+		UserID:      owner.ID,
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     &end,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := empty.Save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	restored, err := Open(path)
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	if u, err := restored.Users().Get(ctx, owner.ID); err != nil || u != owner {
+		t.Fatalf("restored user %+v, want %+v: %v", u, owner, err)
+	}
+	got, err := restored.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("get restored: %v", err)
+	}
+	if got.ServiceName != created.ServiceName || got.Price != created.Price || got.UserID != created.UserID ||
+		!got.StartDate.Equal(created.StartDate) || got.EndDate == nil || !got.EndDate.Equal(end) ||
+		!got.UpdatedAt.Equal(created.UpdatedAt) {
+		t.Fatalf("restored %+v, want %+v", got, created)
+	}
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) != 0 {
+		t.Fatalf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestSaveEverySkipsIdleIntervals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	repo := NewSubscriptionRepo()
+	userID := uuid.New()
+	repo.AddUsers(backfilledUser(userID))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		repo.SaveEvery(ctx, path, time.Millisecond, nil)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if matches, _ := filepath.Glob(path); len(matches) != 0 {
+		t.Fatal("an unchanged repository must not be saved")
+	}
+
+	if _, err := repo.Create(ctx, entity.Subscription{
+		ServiceName: "Netflix", Price: 1, UserID: userID, StartDate: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if matches, _ := filepath.Glob(path); len(matches) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the change was never saved")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
+
+// withUsers adds the users the conformance suite makes up.
+func withUsers(r *SubscriptionRepo) subscription.Repository {
+	return repotest.EnsureUsers(r, func(ctx context.Context, id uuid.UUID) error {
+		if _, err := r.Users().Get(ctx, id); err != nil {
+			r.AddUsers(backfilledUser(id))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+// UnitOfWork runs operations under the repository's write lock, held from
+// the first read to the last write, so a read-modify-write such as a pause
+// cannot interleave with a concurrent update. Changes made before fn fails
+// are undone.
+type UnitOfWork struct {
+	r *SubscriptionRepo
+}
+
+// UnitOfWork returns the unit of work over r.
+func (r *SubscriptionRepo) UnitOfWork() *UnitOfWork {
+	return &UnitOfWork{r: r}
+}
+
+type txKey struct{}
+
+// tx remembers the rows a transaction replaced, nil for the ones it added.
+type tx struct {
+	r    *SubscriptionRepo
+	undo map[uuid.UUID]*entity.Subscription
+}
+
+// WithinTxLevel calls fn holding the write lock. Every transaction is thus
+// serializable, level is accepted for interface compatibility only, and
+// there are no conflicts to retry. Nested calls join the outer transaction.
+func (u *UnitOfWork) WithinTxLevel(ctx context.Context, _ sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	if u.r.txFrom(ctx) != nil {
+		return fn(ctx)
+	}
+
+	u.r.mu.Lock()
+	defer u.r.mu.Unlock()
+	t := &tx{r: u.r, undo: make(map[uuid.UUID]*entity.Subscription)}
+	if err := fn(context.WithValue(ctx, txKey{}, t)); err != nil {
+		t.rollback()
+		return err
+	}
+	return nil
+}
+
+// Append drops the event: webhooks and the event stream, the only readers
+// of an outbox, need Postgres. Events still reach the configured publisher
+// once the transaction commits.
+func (u *UnitOfWork) Append(context.Context, entity.OutboxEvent) error {
+	return nil
+}
+
+func (r *SubscriptionRepo) txFrom(ctx context.Context) *tx {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok && t.r == r {
+		return t
+	}
+	return nil
+}
+
+// lock takes the write lock unless ctx is in a transaction, which holds it
+// already, and returns the matching unlock.
+func (r *SubscriptionRepo) lock(ctx context.Context) func() {
+	if r.txFrom(ctx) != nil {
+		return func() {}
+	}
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// rlock is lock for reads.
+func (r *SubscriptionRepo) rlock(ctx context.Context) func() {
+	if r.txFrom(ctx) != nil {
+		return func() {}
+	}
+	r.mu.RLock()
+	return r.mu.RUnlock
+}
+
+// remember records the row with id before the transaction in ctx, if any,
+// first changes it. The caller holds the write lock.
+func (r *SubscriptionRepo) remember(ctx context.Context, id uuid.UUID) {
+	t := r.txFrom(ctx)
+	if t == nil {
+		return
+	}
+	if _, ok := t.undo[id]; ok {
+		return
+	}
+	if s, ok := r.items[id]; ok {
+		t.undo[id] = &s
+	} else {
+		t.undo[id] = nil
+	}
+}
+
+func (t *tx) rollback() {
+	for id, s := range t.undo {
+		if s == nil {
+			delete(t.r.items, id)
+		} else {
+			t.r.items[id] = *s
+		}
+	}
+	if len(t.undo) > 0 {
+		t.r.version++
+	}
+}
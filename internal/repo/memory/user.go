@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/user"
+)
+
+// UserRepo is the user.Repository view of a SubscriptionRepo. Users and
+// subscriptions share one lock, so a user cannot be deleted while a
+// subscription for it is being created.
+type UserRepo struct {
+	r *SubscriptionRepo
+}
+
+// Users returns the users the repository checks subscriptions against.
+func (r *SubscriptionRepo) Users() *UserRepo {
+	return &UserRepo{r: r}
+}
+
+func (u *UserRepo) Create(ctx context.Context, in entity.User) (entity.User, error) {
+	defer u.r.lock(ctx)()
+	if u.emailTaken(uuid.Nil, in.Email) {
+		return entity.User{}, user.ErrEmailTaken
+	}
+	in.ID = uuid.New()
+	in.CreatedAt = now()
+	in.UpdatedAt = in.CreatedAt
+	u.r.users[in.ID] = in
+	u.r.version++
+	return in, nil
+}
+
+func (u *UserRepo) Get(ctx context.Context, id uuid.UUID) (entity.User, error) {
+	defer u.r.rlock(ctx)()
+	found, ok := u.r.users[id]
+	if !ok {
+		return entity.User{}, user.ErrNotFound
+	}
+	return found, nil
+}
+
+// List orders users by creation time, then id, as the SQL repositories.
+func (u *UserRepo) List(ctx context.Context, filter user.ListFilter) ([]entity.User, error) {
+	unlock := u.r.rlock(ctx)
+	users := make([]entity.User, 0, len(u.r.users))
+	for _, found := range u.r.users {
+		users = append(users, found)
+	}
+	unlock()
+
+	slices.SortFunc(users, func(a, b entity.User) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	users = users[min(filter.Offset, len(users)):]
+	if filter.Limit > 0 {
+		users = users[:min(filter.Limit, len(users))]
+	}
+	return users, nil
+}
+
+func (u *UserRepo) Update(ctx context.Context, id uuid.UUID, in entity.User) (entity.User, error) {
+	defer u.r.lock(ctx)()
+	stored, ok := u.r.users[id]
+	if !ok {
+		return entity.User{}, user.ErrNotFound
+	}
+	if u.emailTaken(id, in.Email) {
+		return entity.User{}, user.ErrEmailTaken
+	}
+	in.ID = id
+	in.CreatedAt = stored.CreatedAt
+	in.UpdatedAt = now()
+	u.r.users[id] = in
+	u.r.version++
+	return in, nil
+}
+
+// Delete refuses users that still have subscriptions, like the foreign
+// key in the SQL schemas.
+func (u *UserRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	defer u.r.lock(ctx)()
+	if _, ok := u.r.users[id]; !ok {
+		return user.ErrNotFound
+	}
+	for _, s := range u.r.items {
+		if s.UserID == id {
+			return user.ErrHasSubscriptions
+		}
+	}
+	delete(u.r.users, id)
+	u.r.version++
+	return nil
+}
+
+// emailTaken reports whether a user other than id has email, compared
+// regardless of case like the unique index.
+func (u *UserRepo) emailTaken(id uuid.UUID, email string) bool {
+	if email == "" {
+		return false
+	}
+	for _, other := range u.r.users {
+		if other.ID != id && strings.EqualFold(other.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// backfilledUser is a user known only from its subscriptions.
+func backfilledUser(id uuid.UUID) entity.User {
+	t := now()
+	return entity.User{
+		ID:        id,
+		Timezone:  user.DefaultTimezone,
+		Currency:  user.DefaultCurrency,
+		CreatedAt: t,
+		UpdatedAt: t,
+	}
+}
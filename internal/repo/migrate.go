@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+
+	"restservice/migrations"
+)
+
+// NewMigrator returns a goose provider over the embedded migrations. Every
+// run holds a Postgres advisory lock, so replicas starting at the same time
+// apply migrations one after another instead of racing.
+func NewMigrator(db *sql.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("migration locker: %w", err)
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations.FS,
+		goose.WithSessionLocker(locker),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("migration provider: %w", err)
+	}
+
+	return provider, nil
+}
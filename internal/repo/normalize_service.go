@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"restservice/internal/usecase/catalog"
+)
+
+func (r *CatalogRepo) SubscriptionServiceNames(ctx context.Context) ([]catalog.NameCount, error) {
+	const q = `
+		select service_name, count(*)
+		from subscriptions
+		group by service_name
+		order by service_name
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list service names: %w", err)
+	}
+	defer rows.Close()
+
+	var names []catalog.NameCount
+	for rows.Next() {
+		var n catalog.NameCount
+		if err := rows.Scan(&n.Name, &n.Subscriptions); err != nil {
+			return nil, fmt.Errorf("scan service name: %w", err)
+		}
+		names = append(names, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list service names rows: %w", err)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"restservice/internal/entity"
+)
+
+type OutboxRepo struct {
+	db *sql.DB
+}
+
+func NewOutboxRepo(db *sql.DB) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+// Append inserts an event and notifies listeners. Called with a transaction
+// context, the event and the notification commit or roll back with the
+// change they describe.
+func (r *OutboxRepo) Append(ctx context.Context, e entity.OutboxEvent) error {
+	const q = `
+		insert into outbox (event_type, subscription_id, payload)
+		values ($1, $2, $3)
+	`
+
+	c := conn(ctx, r.db)
+	if _, err := c.ExecContext(ctx, q, e.Type, e.SubscriptionID, []byte(e.Payload)); err != nil {
+		return fmt.Errorf("append outbox event: %w", err)
+	}
+	if _, err := c.ExecContext(ctx, `select pg_notify($1, '')`, OutboxChannel); err != nil {
+		return fmt.Errorf("notify outbox event: %w", err)
+	}
+	return nil
+}
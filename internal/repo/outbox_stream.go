@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
+)
+
+// OutboxChannel is notified, on commit, by every transaction that appends
+// to the outbox.
+const OutboxChannel = "outbox_events"
+
+// settled limits stream reads to rows written by transactions older than
+// any still running. A running transaction gets a tx_id at least as large
+// as the snapshot's xmin, so nothing can later commit before a settled row.
+const settled = `tx_id < pg_snapshot_xmin(pg_current_snapshot())`
+
+func (r *OutboxRepo) Head(ctx context.Context) (subscription.Position, error) {
+	const q = `
+		select tx_id::text::bigint, id
+		from outbox
+		where ` + settled + `
+		order by tx_id desc, id desc
+		limit 1
+	`
+
+	var pos subscription.Position
+	err := conn(ctx, r.db).QueryRowContext(ctx, q).Scan(&pos.TxID, &pos.EventID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return subscription.Position{}, fmt.Errorf("outbox head: %w", err)
+	}
+	return pos, nil
+}
+
+func (r *OutboxRepo) ReadAfter(ctx context.Context, pos subscription.Position, filter subscription.StreamFilter, limit int) ([]subscription.StreamEvent, error) {
+	q := `
+		select tx_id::text::bigint, id, event_type, subscription_id, payload, created_at
+		from outbox
+		where (tx_id, id) > ($1::text::xid8, $2)
+		  and ` + settled + `
+		  and event_type in ($3, $4, $5)
+	`
+	args := []any{
+		strconv.FormatInt(pos.TxID, 10), pos.EventID,
+		entity.EventSubscriptionCreated, entity.EventSubscriptionUpdated, entity.EventSubscriptionDeleted,
+	}
+	if filter.UserID != nil {
+		args = append(args, filter.UserID.String())
+		q += fmt.Sprintf(" and payload->>'user_id' = $%d", len(args))
+	}
+	if filter.ServiceName != nil && strings.TrimSpace(*filter.ServiceName) != "" {
+		args = append(args, strings.TrimSpace(*filter.ServiceName))
+		q += fmt.Sprintf(" and payload->>'service_name' = $%d", len(args))
+	}
+	args = append(args, limit)
+	q += fmt.Sprintf(" order by tx_id, id limit $%d", len(args))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("read outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var events []subscription.StreamEvent
+	for rows.Next() {
+		var (
+			e       subscription.StreamEvent
+			payload []byte
+		)
+		if err := rows.Scan(
+			&e.Position.TxID,
+			&e.Position.EventID,
+			&e.Event.Type,
+			&e.Event.SubscriptionID,
+			&payload,
+			&e.Event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan outbox event: %w", err)
+		}
+		e.Event.ID = e.Position.EventID
+		e.Event.Payload = payload
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read outbox rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// Listen holds a dedicated connection with LISTEN on OutboxChannel. The
+// connection is discarded afterwards rather than returned to the pool
+// still listening.
+func (r *OutboxRepo) Listen(ctx context.Context, notify func()) error {
+	c, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("listen conn: %w", err)
+	}
+	defer c.Close()
+
+	return c.Raw(func(driverConn any) error {
+		pc := driverConn.(*stdlib.Conn).Conn()
+		// Close the connection on the way out; database/sql then drops it
+		// instead of handing a listening session to another caller.
+		defer pc.Close(context.Background())
+
+		if _, err := pc.Exec(ctx, "listen "+OutboxChannel); err != nil {
+			return fmt.Errorf("listen: %w", err)
+		}
+		for {
+			if _, err := pc.WaitForNotification(ctx); err != nil {
+				return fmt.Errorf("wait for notification: %w", err)
+			}
+			notify()
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package pgxrepo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo"
+	"restservice/internal/usecase/subscription"
+)
+
+// benchRows is how many subscriptions the benchmarks seed.
+const benchRows = 2000
+
+// The benchmarks need a migrated database:
+//
+//	BENCH_DSN=postgres://... go test -run '^$' -bench . ./internal/repo/pgxrepo
+//
+// They insert rows under a random service name and delete them afterwards.
+func BenchmarkRepositories(b *testing.B) {
+	dsn := os.Getenv("BENCH_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_DSN is not set")
+	}
+	ctx := context.Background()
+
+	db, err := repo.Open(dsn)
+	if err != nil {
+		b.Fatalf("open database/sql: %v", err)
+	}
+	defer db.Close()
+	pool, err := Open(ctx, dsn, 0)
+	if err != nil {
+		b.Fatalf("open pgxpool: %v", err)
+	}
+	defer pool.Close()
+
+	service := "bench-" + uuid.NewString()
+	user := uuid.New()
+	if _, err := pool.Exec(ctx, `insert into users (id, name) values ($1, 'bench')`, user); err != nil {
+		b.Fatalf("seed user: %v", err)
+	}
+	seed := NewSubscriptionRepo(pool)
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := range benchRows {
+		_, err := seed.Create(ctx, entity.Subscription{
+			ServiceName: service,
+			Price:       100 + i%900,
+			UserID:      user,
+			StartDate:   start.AddDate(0, i%24, 0),
+		})
+		if err != nil {
+			b.Fatalf("seed: %v", err)
+		}
+	}
+	defer func() {
+		if _, err := pool.Exec(ctx, `delete from subscriptions where service_name = $1`, service); err != nil {
+			b.Errorf("clean up: %v", err)
+		}
+		if _, err := pool.Exec(ctx, `delete from users where id = $1`, user); err != nil {
+			b.Errorf("clean up user: %v", err)
+		}
+	}()
+
+	repos := []struct {
+		name string
+		repo subscription.Repository
+	}{
+		{"stdlib", repo.NewSubscriptionRepo(db)},
+		{"pgxpool", NewSubscriptionRepo(pool)},
+	}
+	for _, r := range repos {
+		b.Run("list/"+r.name, func(b *testing.B) {
+			filter := subscription.ListFilter{ServiceName: &service, Limit: 100}
+			for b.Loop() {
+				if _, err := r.repo.List(ctx, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("summary/"+r.name, func(b *testing.B) {
+			filter := subscription.SummaryFilter{
+				UserID:    &user,
+				StartDate: start,
+				EndDate:   start.AddDate(1, 0, 0),
+			}
+			for b.Loop() {
+				if _, err := r.repo.Sum(ctx, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Package pgxrepo implements the subscription storage on a native pgx
+// connection pool instead of database/sql. It uses pgx's own UUID and date
+// handling, statements prepared on every connection, and batches the
+// outbox writes of a transaction into its commit round trip.
+package pgxrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultMaxConns    = 10
+	defaultConnLife    = 30 * time.Minute
+	defaultHealthCheck = time.Minute
+	pingTimeout        = 3 * time.Second
+)
+
+// Open creates a pool for dsn and checks that the database is reachable.
+// Idle connections are checked every healthCheck and replaced when broken.
+func Open(ctx context.Context, dsn string, healthCheck time.Duration) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse dsn: %w", err)
+	}
+	if healthCheck <= 0 {
+		healthCheck = defaultHealthCheck
+	}
+	cfg.MaxConns = defaultMaxConns
+	cfg.MaxConnLifetime = defaultConnLife
+	cfg.HealthCheckPeriod = healthCheck
+	cfg.AfterConnect = prepare
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	if err := pool.Ping(pingCtx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// prepare prepares the fixed statements on a new connection. pgx runs a
+// query string equal to a prepared statement's name as that statement.
+func prepare(ctx context.Context, conn *pgx.Conn) error {
+	for name, sql := range statements {
+		if _, err := conn.Prepare(ctx, name, sql); err != nil {
+			return fmt.Errorf("prepare %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// subscriptionColumns are the columns scanSubscription reads, with the
+// tags as a sorted array.
+const subscriptionColumns = `id, service_name, price, user_id, start_date, end_date, updated_at, category,
+	array(
+		select t.name
+		from subscription_tags st
+		join tags t on t.id = st.tag_id
+		where st.subscription_id = subscriptions.id
+		order by t.name
+	),
+	status, trial_end_date, cancelled_at, pauses`
+
+// Statements prepared on every connection, by name.
+const (
+	stmtGetSubscription    = "get_subscription"
+	stmtLockSubscription   = "lock_subscription"
+	stmtCreateSubscription = "create_subscription"
+	stmtUpdateSubscription = "update_subscription"
+	stmtDeleteSubscription = "delete_subscription"
+	stmtAppendOutbox       = "append_outbox"
+	stmtNotifyOutbox       = "notify_outbox"
+)
+
+var statements = map[string]string{
+	stmtGetSubscription: `
+		select ` + subscriptionColumns + `
+		from subscriptions
+		where id = $1
+	`,
+	stmtLockSubscription: `
+		select ` + subscriptionColumns + `
+		from subscriptions
+		where id = $1
+		for update
+	`,
+	stmtCreateSubscription: `
+		with sub as (
+			insert into subscriptions (service_name, price, user_id, start_date, end_date, category,
+				status, trial_end_date, cancelled_at, pauses)
+			values ($1, $2, $3, $4, $5, $6, coalesce(nullif($8, ''), 'active'), $9, $10, $11)
+			returning id, updated_at, status
+		),
+		tag as (
+			insert into tags (name)
+			select unnest($7::text[])
+			on conflict (name) do update set name = excluded.name
+			returning id
+		),
+		link as (
+			insert into subscription_tags (subscription_id, tag_id)
+			select sub.id, tag.id from sub, tag
+		)
+		select id, updated_at, status from sub
+	`,
+	// The final select returns the new tags, which the statement cannot
+	// read back from the links it inserts.
+	stmtUpdateSubscription: `
+		with sub as (
+			update subscriptions
+			set service_name = $1,
+				price = $2,
+				user_id = $3,
+				start_date = $4,
+				end_date = $5,
+				category = $7,
+				status = coalesce(nullif($9, ''), 'active'),
+				trial_end_date = $10,
+				cancelled_at = $11,
+				pauses = $12,
+				updated_at = now(),
+				-- A changed end date may end the subscription at another time.
+				ended_notified_at = case
+					when end_date is distinct from $5 then null
+					else ended_notified_at
+				end
+			where id = $6
+			returning id, service_name, price, user_id, start_date, end_date, updated_at, category,
+				status, trial_end_date, cancelled_at, pauses
+		),
+		tag as (
+			insert into tags (name)
+			select unnest($8::text[])
+			on conflict (name) do update set name = excluded.name
+			returning id
+		),
+		unlink as (
+			delete from subscription_tags
+			where subscription_id in (select id from sub)
+			  and tag_id not in (select id from tag)
+		),
+		link as (
+			insert into subscription_tags (subscription_id, tag_id)
+			select sub.id, tag.id from sub, tag
+			on conflict do nothing
+		)
+		select id, service_name, price, user_id, start_date, end_date, updated_at, category,
+			coalesce($8::text[], '{}'), status, trial_end_date, cancelled_at, pauses
+		from sub
+	`,
+	stmtDeleteSubscription: `delete from subscriptions where id = $1`,
+	stmtAppendOutbox: `
+		insert into outbox (event_type, subscription_id, payload)
+		values ($1, $2, $3)
+	`,
+	stmtNotifyOutbox: `select pg_notify($1, '')`,
+}
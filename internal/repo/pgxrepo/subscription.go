@@ -0,0 +1,268 @@
+package pgxrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo"
+	"restservice/internal/usecase/subscription"
+)
+
+type SubscriptionRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewSubscriptionRepo(pool *pgxpool.Pool) *SubscriptionRepo {
+	return &SubscriptionRepo{pool: pool}
+}
+
+func (r *SubscriptionRepo) Create(ctx context.Context, s entity.Subscription) (entity.Subscription, error) {
+	err := conn(ctx, r.pool).QueryRow(ctx, stmtCreateSubscription,
+		s.ServiceName, s.Price, s.UserID, s.StartDate.UTC(), utc(s.EndDate), s.Category, s.Tags,
+		string(s.Status), utc(s.TrialEndDate), utc(s.CancelledAt), pauses(s.Pauses),
+	).Scan(&s.ID, &s.UpdatedAt, &s.Status)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return entity.Subscription{}, subscription.ErrUnknownUser
+		}
+		return entity.Subscription{}, fmt.Errorf("create subscription: %w", err)
+	}
+	s.UpdatedAt = s.UpdatedAt.UTC()
+	return s, nil
+}
+
+func (r *SubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	return r.get(ctx, stmtGetSubscription, id)
+}
+
+// GetForUpdate is Get that locks the row until the transaction in ctx
+// ends.
+func (r *SubscriptionRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	return r.get(ctx, stmtLockSubscription, id)
+}
+
+func (r *SubscriptionRepo) get(ctx context.Context, stmt string, id uuid.UUID) (entity.Subscription, error) {
+	sub, err := scanSubscription(conn(ctx, r.pool).QueryRow(ctx, stmt, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Subscription{}, subscription.ErrNotFound
+		}
+		return entity.Subscription{}, fmt.Errorf("get subscription: %w", err)
+	}
+	return sub, nil
+}
+
+func (r *SubscriptionRepo) List(ctx context.Context, filter subscription.ListFilter) ([]entity.Subscription, error) {
+	q := `
+		select ` + subscriptionColumns + `
+		from subscriptions
+		where true
+	`
+	args := pgx.NamedArgs{}
+	q += filterSQL(filter.UserID, filter.ServiceName, args)
+	q += labelSQL(filter.Category, filter.Tag, args)
+	q += " order by start_date desc, id"
+	if filter.Limit > 0 {
+		args["limit"] = filter.Limit
+		q += " limit @limit"
+	}
+	if filter.Offset > 0 {
+		args["offset"] = filter.Offset
+		q += " offset @offset"
+	}
+
+	rows, err := conn(ctx, r.pool).Query(ctx, q, args)
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+	subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Subscription, error) {
+		return scanSubscription(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions rows: %w", err)
+	}
+	return subs, nil
+}
+
+func (r *SubscriptionRepo) Update(ctx context.Context, id uuid.UUID, s entity.Subscription) (entity.Subscription, error) {
+	sub, err := scanSubscription(conn(ctx, r.pool).QueryRow(ctx, stmtUpdateSubscription,
+		s.ServiceName, s.Price, s.UserID, s.StartDate.UTC(), utc(s.EndDate), id, s.Category, s.Tags,
+		string(s.Status), utc(s.TrialEndDate), utc(s.CancelledAt), pauses(s.Pauses),
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Subscription{}, subscription.ErrNotFound
+		}
+		if foreignKeyViolation(err) {
+			return entity.Subscription{}, subscription.ErrUnknownUser
+		}
+		return entity.Subscription{}, fmt.Errorf("update subscription: %w", err)
+	}
+	return sub, nil
+}
+
+func (r *SubscriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, stmtDeleteSubscription, id)
+	if err != nil {
+		return fmt.Errorf("delete subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return subscription.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SubscriptionRepo) Sum(ctx context.Context, filter subscription.SummaryFilter) (int, error) {
+	q := `
+		select coalesce(sum(price * billed.months), 0)
+		from subscriptions ` + billedJoin + `
+		where start_date <= @end
+		  and (end_date is null or end_date >= @start)
+		  and billed.months > 0
+	`
+	args := pgx.NamedArgs{"start": filter.StartDate.UTC(), "end": filter.EndDate.UTC()}
+	q += filterSQL(filter.UserID, filter.ServiceName, args)
+	q += labelSQL(filter.Category, filter.Tag, args)
+
+	var total int
+	if err := conn(ctx, r.pool).QueryRow(ctx, q, args).Scan(&total); err != nil {
+		return 0, fmt.Errorf("sum subscriptions: %w", err)
+	}
+	return total, nil
+}
+
+func (r *SubscriptionRepo) SumGroups(ctx context.Context, filter subscription.SummaryFilter, by subscription.GroupBy) ([]subscription.GroupTotal, error) {
+	key, from := "category", "subscriptions "+billedJoin
+	if by == subscription.GroupByTag {
+		key = "coalesce(t.name, '')"
+		from = `subscriptions ` + billedJoin + `
+			left join subscription_tags st on st.subscription_id = subscriptions.id
+			left join tags t on t.id = st.tag_id`
+	}
+	q := `
+		select ` + key + `, sum(price * billed.months)
+		from ` + from + `
+		where start_date <= @end
+		  and (end_date is null or end_date >= @start)
+		  and billed.months > 0
+	`
+	args := pgx.NamedArgs{"start": filter.StartDate.UTC(), "end": filter.EndDate.UTC()}
+	q += filterSQL(filter.UserID, filter.ServiceName, args)
+	q += labelSQL(filter.Category, filter.Tag, args)
+	q += " group by 1 order by 1"
+
+	rows, err := conn(ctx, r.pool).Query(ctx, q, args)
+	if err != nil {
+		return nil, fmt.Errorf("sum subscription groups: %w", err)
+	}
+	groups, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (subscription.GroupTotal, error) {
+		var g subscription.GroupTotal
+		err := row.Scan(&g.Key, &g.Total)
+		return g, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sum subscription groups rows: %w", err)
+	}
+	return groups, nil
+}
+
+// filterSQL returns the conditions for the optional user and service
+// filters and adds their arguments to args.
+func filterSQL(userID *uuid.UUID, serviceName *string, args pgx.NamedArgs) string {
+	var cond strings.Builder
+	if userID != nil {
+		args["user_id"] = *userID
+		cond.WriteString(" and user_id = @user_id")
+	}
+	if serviceName != nil && strings.TrimSpace(*serviceName) != "" {
+		args["service_name"] = strings.TrimSpace(*serviceName)
+		cond.WriteString(" and service_name = @service_name")
+	}
+	return cond.String()
+}
+
+// billedJoin gives each subscription billed.months, the months of the
+// period from @start to @end it costs its price in.
+var billedJoin = "cross join lateral (select " + repo.BilledMonthsSQL("@start", "@end") + " as months) billed"
+
+// pauses keeps an empty list from being stored as JSON null.
+func pauses(p []entity.Pause) []entity.Pause {
+	if p == nil {
+		return []entity.Pause{}
+	}
+	return p
+}
+
+// labelSQL is filterSQL for the category and tag filters.
+func labelSQL(category, tag *string, args pgx.NamedArgs) string {
+	var cond strings.Builder
+	if category != nil && strings.TrimSpace(*category) != "" {
+		args["category"] = strings.TrimSpace(*category)
+		cond.WriteString(" and category = @category")
+	}
+	if tag != nil && strings.TrimSpace(*tag) != "" {
+		args["tag"] = strings.TrimSpace(*tag)
+		cond.WriteString(` and exists (
+			select 1
+			from subscription_tags st
+			join tags t on t.id = st.tag_id
+			where st.subscription_id = subscriptions.id and t.name = @tag
+		)`)
+	}
+	return cond.String()
+}
+
+// foreignKeyViolation reports whether err is Postgres refusing a user_id
+// that has no users row.
+func foreignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// scanSubscription reads subscriptionColumns. pgx decodes a NULL end_date
+// into a nil pointer, so no Null* wrapper is needed.
+func scanSubscription(row pgx.Row) (entity.Subscription, error) {
+	var sub entity.Subscription
+	if err := row.Scan(
+		&sub.ID,
+		&sub.ServiceName,
+		&sub.Price,
+		&sub.UserID,
+		&sub.StartDate,
+		&sub.EndDate,
+		&sub.UpdatedAt,
+		&sub.Category,
+		&sub.Tags,
+		&sub.Status,
+		&sub.TrialEndDate,
+		&sub.CancelledAt,
+		&sub.Pauses,
+	); err != nil {
+		return entity.Subscription{}, err
+	}
+	sub.UpdatedAt = sub.UpdatedAt.UTC()
+	if len(sub.Tags) == 0 {
+		sub.Tags = nil
+	}
+	if len(sub.Pauses) == 0 {
+		sub.Pauses = nil
+	}
+	return sub, nil
+}
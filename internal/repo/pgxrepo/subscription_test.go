@@ -0,0 +1,49 @@
+package pgxrepo
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/repo"
+	"restservice/internal/repo/repotest"
+	"restservice/internal/usecase/subscription"
+)
+
+// TestConformance runs against TEST_DATABASE_DSN like the database/sql
+// repository's suite and is skipped when the variable is unset.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	ctx := context.Background()
+
+	db, err := repo.Open(dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	migrator, err := repo.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := Open(ctx, dsn, 0)
+	if err != nil {
+		t.Fatalf("open pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	r := repotest.EnsureUsers(NewSubscriptionRepo(pool), func(ctx context.Context, id uuid.UUID) error {
+		_, err := pool.Exec(ctx, `insert into users (id) values ($1) on conflict (id) do nothing`, id)
+		return err
+	})
+	repotest.Run(t, func(*testing.T) subscription.Repository { return r })
+	repotest.RunUnitOfWork(t, r, NewUnitOfWork(pool))
+}
@@ -0,0 +1,122 @@
+package pgxrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo"
+)
+
+// querier is the subset of *pgxpool.Pool and pgx.Tx the repository uses.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type txKey struct{}
+
+// txState is the transaction carried by the context together with the
+// outbox writes queued for its commit.
+type txState struct {
+	tx    pgx.Tx
+	level sql.IsolationLevel
+	batch *pgx.Batch
+}
+
+func conn(ctx context.Context, pool *pgxpool.Pool) querier {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok {
+		return st.tx
+	}
+	return pool
+}
+
+// UnitOfWork runs subscription changes and their outbox events in one pgx
+// transaction. It behaves like repo.UnitOfWork, including retries on
+// conflicts, but Append only queues the event: all events of a transaction
+// are sent in a single batch right before the commit.
+type UnitOfWork struct {
+	pool *pgxpool.Pool
+}
+
+func NewUnitOfWork(pool *pgxpool.Pool) *UnitOfWork {
+	return &UnitOfWork{pool: pool}
+}
+
+// WithinTxLevel calls fn in a transaction at level. Nested calls join the
+// outer transaction unless they need a stricter level, see
+// repo.CheckNestedLevel.
+func (u *UnitOfWork) WithinTxLevel(ctx context.Context, level sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok {
+		if err := repo.CheckNestedLevel(st.level, level); err != nil {
+			return err
+		}
+		return fn(ctx)
+	}
+
+	iso, err := isoLevel(level)
+	if err != nil {
+		return err
+	}
+	return repo.RetryConflicts(ctx, func() error {
+		return u.run(ctx, level, iso, fn)
+	})
+}
+
+func (u *UnitOfWork) run(ctx context.Context, level sql.IsolationLevel, iso pgx.TxIsoLevel, fn func(ctx context.Context) error) error {
+	tx, err := u.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: iso})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	// Rollback after a successful commit is a no-op.
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	st := &txState{tx: tx, level: level, batch: &pgx.Batch{}}
+	if err := fn(context.WithValue(ctx, txKey{}, st)); err != nil {
+		return err
+	}
+	if st.batch.Len() > 0 {
+		if err := tx.SendBatch(ctx, st.batch).Close(); err != nil {
+			return fmt.Errorf("append outbox events: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// Append records an event. Inside a transaction it is queued for the
+// commit batch; outside of one it is written immediately.
+func (u *UnitOfWork) Append(ctx context.Context, e entity.OutboxEvent) error {
+	st, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return u.WithinTxLevel(ctx, sql.LevelDefault, func(ctx context.Context) error {
+			return u.Append(ctx, e)
+		})
+	}
+	st.batch.Queue(stmtAppendOutbox, e.Type, e.SubscriptionID, []byte(e.Payload))
+	st.batch.Queue(stmtNotifyOutbox, repo.OutboxChannel)
+	return nil
+}
+
+func isoLevel(level sql.IsolationLevel) (pgx.TxIsoLevel, error) {
+	switch level {
+	case sql.LevelDefault:
+		return "", nil
+	case sql.LevelReadCommitted:
+		return pgx.ReadCommitted, nil
+	case sql.LevelRepeatableRead:
+		return pgx.RepeatableRead, nil
+	case sql.LevelSerializable:
+		return pgx.Serializable, nil
+	default:
+		return "", fmt.Errorf("unsupported isolation level %s", level)
+	}
+}
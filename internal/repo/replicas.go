@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+const defaultReplicaCheckInterval = 10 * time.Second
+
+// replicaFailureLimit is how many failed queries and checks in a row take
+// a replica out of rotation: a single one may be a dropped connection.
+const replicaFailureLimit = 3
+
+// replicaStatusQuery reports whether the server is a replica and how far
+// behind the primary it is in seconds. A replica that has replayed all the
+// WAL it received is not behind, however old its last transaction is.
+const replicaStatusQuery = `
+	select pg_is_in_recovery(),
+		case
+			when pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() then 0
+			else coalesce(extract(epoch from now() - pg_last_xact_replay_timestamp()), 0)
+		end::float8`
+
+var errNotReplica = errors.New("server is not in recovery")
+
+// Replicas spreads read-only queries over Postgres read replicas in turn,
+// skipping those that fail their health check.
+type Replicas struct {
+	replicas []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+	logger   *slog.Logger
+}
+
+type replica struct {
+	// index identifies the replica in logs without exposing its DSN.
+	index    int
+	db       *sql.DB
+	healthy  atomic.Bool
+	failures atomic.Int32
+}
+
+// OpenReplicas connects to the replicas at dsns. A replica that is down
+// does not fail startup: it starts unhealthy and is used once a health
+// check succeeds. Replicas more than maxLag behind the primary are not
+// used; zero allows any lag.
+func OpenReplicas(dsns []string, maxLag time.Duration, logger *slog.Logger) (*Replicas, error) {
+	r := &Replicas{maxLag: maxLag, logger: logger}
+	for i, dsn := range dsns {
+		db, err := sql.Open(dbDriverName, dsn)
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		db.SetMaxOpenConns(defaultMaxOpen)
+		db.SetMaxIdleConns(defaultMaxIdle)
+		db.SetConnMaxLifetime(defaultConnLife)
+		r.replicas = append(r.replicas, &replica{index: i, db: db})
+	}
+	r.check(context.Background())
+	return r, nil
+}
+
+// Run checks every replica each interval until ctx is done.
+func (r *Replicas) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultReplicaCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
+
+// check takes replicas that are no longer in recovery or lag too far
+// behind out of rotation at once, and those that cannot be reached after
+// replicaFailureLimit attempts.
+func (r *Replicas) check(ctx context.Context) {
+	for _, rep := range r.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+		var (
+			inRecovery bool
+			lagSeconds float64
+		)
+		err := rep.db.QueryRowContext(checkCtx, replicaStatusQuery).Scan(&inRecovery, &lagSeconds)
+		cancel()
+		lag := time.Duration(lagSeconds * float64(time.Second))
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				r.failed(rep, err)
+			}
+		case !inRecovery:
+			r.markDown(rep, errNotReplica)
+		case r.maxLag > 0 && lag > r.maxLag:
+			r.markDown(rep, fmt.Errorf("replication lag %s exceeds %s", lag.Round(time.Millisecond), r.maxLag))
+		default:
+			rep.failures.Store(0)
+			if !rep.healthy.Swap(true) {
+				r.logger.Info("replica healthy", "replica", rep.index, "lag", lag)
+			}
+		}
+	}
+}
+
+// failed counts a failed query or check of rep and takes it out of
+// rotation once replicaFailureLimit fail in a row.
+func (r *Replicas) failed(rep *replica, err error) {
+	if rep.failures.Add(1) >= replicaFailureLimit {
+		r.markDown(rep, err)
+	}
+}
+
+func (r *Replicas) markDown(rep *replica, err error) {
+	if rep.healthy.Swap(false) {
+		r.logger.Warn("replica unhealthy, reading from others", "replica", rep.index, "error", err)
+	}
+}
+
+// pick returns the next healthy replica, or nil when there is none.
+func (r *Replicas) pick() *replica {
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := range n {
+		if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+func (r *Replicas) Close() error {
+	var errs []error
+	for _, rep := range r.replicas {
+		errs = append(errs, rep.db.Close())
+	}
+	return errors.Join(errs...)
+}
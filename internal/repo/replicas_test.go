@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestReplicasPick(t *testing.T) {
+	r := &Replicas{}
+	for i := range 3 {
+		r.replicas = append(r.replicas, &replica{index: i})
+	}
+	if r.pick() != nil {
+		t.Fatal("no replica is healthy yet")
+	}
+
+	r.replicas[0].healthy.Store(true)
+	r.replicas[2].healthy.Store(true)
+	seen := make(map[int]int)
+	for range 10 {
+		seen[r.pick().index]++
+	}
+	if seen[1] != 0 {
+		t.Fatal("an unhealthy replica was picked")
+	}
+	if seen[0] == 0 || seen[2] == 0 {
+		t.Fatalf("reads must rotate over healthy replicas: %v", seen)
+	}
+}
+
+func TestReplicasFailureLimit(t *testing.T) {
+	r := &Replicas{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	rep := &replica{}
+	rep.healthy.Store(true)
+	r.replicas = []*replica{rep}
+
+	for range replicaFailureLimit - 1 {
+		r.failed(rep, errors.New("connection reset"))
+	}
+	if !rep.healthy.Load() {
+		t.Fatal("a replica must survive fewer than replicaFailureLimit failures")
+	}
+	r.failed(rep, errors.New("connection reset"))
+	if rep.healthy.Load() {
+		t.Fatal("a replica must be taken out after replicaFailureLimit failures in a row")
+	}
+}
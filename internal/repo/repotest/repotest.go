@@ -0,0 +1,694 @@
+// Package repotest is a conformance suite for subscription.Repository
+// implementations. Every backend runs the same tests, so they agree on
+// ordering, filtering, summary and error semantics.
+package repotest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
+)
+
+// Factory returns the repository under test. It may be shared between
+// tests: every test works with its own random user and service names and
+// never assumes an empty table.
+type Factory func(t *testing.T) subscription.Repository
+
+// Run runs the suite against the repositories newRepo returns.
+func Run(t *testing.T, newRepo Factory) {
+	t.Run("CreateGet", func(t *testing.T) { testCreateGet(t, newRepo(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newRepo(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newRepo(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newRepo(t)) })
+	t.Run("Validation", func(t *testing.T) { testValidation(t, newRepo(t)) })
+	t.Run("List", func(t *testing.T) { testList(t, newRepo(t)) })
+	t.Run("Sum", func(t *testing.T) { testSum(t, newRepo(t)) })
+	t.Run("Labels", func(t *testing.T) { testLabels(t, newRepo(t)) })
+	t.Run("Lifecycle", func(t *testing.T) { testLifecycle(t, newRepo(t)) })
+	t.Run("UnknownUser", func(t *testing.T) { testUnknownUser(t, newRepo(t)) })
+}
+
+// EnsureUsers wraps repo so that Create and Update first call ensure with
+// the subscription's user. The suite makes up random user IDs, and every
+// backend refuses subscriptions of unknown users, so each passes a
+// function that inserts the missing ones. The UnknownUser test unwraps
+// repo to check the refusal.
+func EnsureUsers(repo subscription.Repository, ensure func(ctx context.Context, id uuid.UUID) error) subscription.Repository {
+	return ensuringRepo{Repository: repo, ensure: ensure}
+}
+
+type ensuringRepo struct {
+	subscription.Repository
+	ensure func(ctx context.Context, id uuid.UUID) error
+}
+
+func (r ensuringRepo) Create(ctx context.Context, s entity.Subscription) (entity.Subscription, error) {
+	if err := r.ensure(ctx, s.UserID); err != nil {
+		return entity.Subscription{}, err
+	}
+	return r.Repository.Create(ctx, s)
+}
+
+func (r ensuringRepo) Update(ctx context.Context, id uuid.UUID, s entity.Subscription) (entity.Subscription, error) {
+	if err := r.ensure(ctx, s.UserID); err != nil {
+		return entity.Subscription{}, err
+	}
+	return r.Repository.Update(ctx, id, s)
+}
+
+// unwrap returns the repository EnsureUsers wrapped.
+func unwrap(repo subscription.Repository) subscription.Repository {
+	if r, ok := repo.(ensuringRepo); ok {
+		return r.Repository
+	}
+	return repo
+}
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func create(t *testing.T, repo subscription.Repository, s entity.Subscription) entity.Subscription {
+	t.Helper()
+	created, err := repo.Create(context.Background(), s)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return created
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// equalFields compares everything but the id and updated_at.
+func equalFields(got, want entity.Subscription) bool {
+	return got.ServiceName == want.ServiceName &&
+		got.Price == want.Price &&
+		got.UserID == want.UserID &&
+		got.StartDate.Equal(want.StartDate) &&
+		sameDate(got.EndDate, want.EndDate)
+}
+
+func testCreateGet(t *testing.T, repo subscription.Repository) {
+	cases := []struct {
+		name string
+		in   entity.Subscription
+	}{
+		{"closed", entity.Subscription{
+			ServiceName: "Yandex Plus", Price: 400, UserID: uuid.New(),
+			StartDate: month(2025, time.July), EndDate: ptr(month(2025, time.December)),
+		}},
+		{"open end", entity.Subscription{
+			ServiceName: "Netflix", Price: 1, UserID: uuid.New(), StartDate: month(2025, time.July),
+		}},
+		{"single month", entity.Subscription{
+			ServiceName: "Spotify", Price: 199, UserID: uuid.New(),
+			StartDate: month(2025, time.March), EndDate: ptr(month(2025, time.March)),
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			created := create(t, repo, tc.in)
+			if created.ID == uuid.Nil {
+				t.Fatal("create must assign an id")
+			}
+			if created.UpdatedAt.IsZero() {
+				t.Fatal("create must set updated_at")
+			}
+			if !equalFields(created, tc.in) {
+				t.Fatalf("create returned %+v, want %+v", created, tc.in)
+			}
+
+			got, err := repo.Get(context.Background(), created.ID)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if got.ID != created.ID || !equalFields(got, tc.in) {
+				t.Fatalf("get returned %+v, want %+v", got, tc.in)
+			}
+			if !got.UpdatedAt.Equal(created.UpdatedAt) {
+				t.Fatalf("updated_at changed between create and get: %v %v", created.UpdatedAt, got.UpdatedAt)
+			}
+
+			locked, err := repo.GetForUpdate(context.Background(), created.ID)
+			if err != nil {
+				t.Fatalf("get for update: %v", err)
+			}
+			if locked.ID != created.ID || !equalFields(locked, tc.in) {
+				t.Fatalf("get for update returned %+v, want %+v", locked, tc.in)
+			}
+		})
+	}
+}
+
+func testUpdate(t *testing.T, repo subscription.Repository) {
+	ctx := context.Background()
+	cases := []struct {
+		name   string
+		before entity.Subscription
+		after  entity.Subscription
+	}{
+		{"all fields",
+			entity.Subscription{ServiceName: "Netflix", Price: 100, UserID: uuid.New(), StartDate: month(2025, time.January)},
+			entity.Subscription{ServiceName: "Netflix Premium", Price: 250, UserID: uuid.New(),
+				StartDate: month(2025, time.March), EndDate: ptr(month(2025, time.June))},
+		},
+		{"clear end date",
+			entity.Subscription{ServiceName: "Netflix", Price: 100, UserID: uuid.New(),
+				StartDate: month(2025, time.January), EndDate: ptr(month(2025, time.June))},
+			entity.Subscription{ServiceName: "Netflix", Price: 100, UserID: uuid.New(), StartDate: month(2025, time.January)},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			created := create(t, repo, tc.before)
+
+			updated, err := repo.Update(ctx, created.ID, tc.after)
+			if err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if updated.ID != created.ID || !equalFields(updated, tc.after) {
+				t.Fatalf("update returned %+v, want %+v", updated, tc.after)
+			}
+			if updated.UpdatedAt.Before(created.UpdatedAt) {
+				t.Fatalf("updated_at went back: %v < %v", updated.UpdatedAt, created.UpdatedAt)
+			}
+
+			got, err := repo.Get(ctx, created.ID)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if !equalFields(got, tc.after) {
+				t.Fatalf("update not persisted: %+v", got)
+			}
+		})
+	}
+}
+
+func testDelete(t *testing.T, repo subscription.Repository) {
+	ctx := context.Background()
+	user := uuid.New()
+	kept := create(t, repo, entity.Subscription{ServiceName: "Kept", Price: 1, UserID: user, StartDate: month(2025, time.January)})
+	gone := create(t, repo, entity.Subscription{ServiceName: "Gone", Price: 2, UserID: user, StartDate: month(2025, time.January)})
+
+	if err := repo.Delete(ctx, gone.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.Get(ctx, gone.ID); !errors.Is(err, subscription.ErrNotFound) {
+		t.Fatalf("get after delete: expected ErrNotFound, got %v", err)
+	}
+	list, err := repo.List(ctx, subscription.ListFilter{UserID: &user})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != kept.ID {
+		t.Fatalf("delete must remove only its row, left %+v", list)
+	}
+}
+
+func testNotFound(t *testing.T, repo subscription.Repository) {
+	ctx := context.Background()
+	deleted := create(t, repo, entity.Subscription{ServiceName: "Netflix", Price: 1, UserID: uuid.New(), StartDate: month(2025, time.July)})
+	if err := repo.Delete(ctx, deleted.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	valid := entity.Subscription{ServiceName: "Netflix", Price: 1, UserID: uuid.New(), StartDate: month(2025, time.July)}
+
+	ops := []struct {
+		name string
+		call func(id uuid.UUID) error
+	}{
+		{"get", func(id uuid.UUID) error { _, err := repo.Get(ctx, id); return err }},
+		{"get for update", func(id uuid.UUID) error { _, err := repo.GetForUpdate(ctx, id); return err }},
+		{"update", func(id uuid.UUID) error { _, err := repo.Update(ctx, id, valid); return err }},
+		{"delete", func(id uuid.UUID) error { return repo.Delete(ctx, id) }},
+	}
+	ids := []struct {
+		name string
+		id   uuid.UUID
+	}{
+		{"unknown", uuid.New()},
+		{"deleted", deleted.ID},
+	}
+	for _, op := range ops {
+		for _, id := range ids {
+			t.Run(op.name+"/"+id.name, func(t *testing.T) {
+				if err := op.call(id.id); !errors.Is(err, subscription.ErrNotFound) {
+					t.Fatalf("expected ErrNotFound, got %v", err)
+				}
+			})
+		}
+	}
+}
+
+// testValidation checks that rows the schema forbids are rejected with an
+// error and leave nothing behind. The error itself is backend-specific.
+func testValidation(t *testing.T, repo subscription.Repository) {
+	ctx := context.Background()
+	cases := []struct {
+		name   string
+		modify func(s *entity.Subscription)
+	}{
+		{"zero price", func(s *entity.Subscription) { s.Price = 0 }},
+		{"negative price", func(s *entity.Subscription) { s.Price = -100 }},
+		{"end before start", func(s *entity.Subscription) { s.EndDate = ptr(s.StartDate.AddDate(0, -1, 0)) }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := uuid.New()
+			valid := entity.Subscription{ServiceName: "Netflix", Price: 100, UserID: user, StartDate: month(2025, time.March)}
+			invalid := valid
+			tc.modify(&invalid)
+
+			if _, err := repo.Create(ctx, invalid); err == nil {
+				t.Fatal("create must fail")
+			}
+			if list, err := repo.List(ctx, subscription.ListFilter{UserID: &user}); err != nil || len(list) != 0 {
+				t.Fatalf("failed create stored %+v (%v)", list, err)
+			}
+
+			created := create(t, repo, valid)
+			if _, err := repo.Update(ctx, created.ID, invalid); err == nil {
+				t.Fatal("update must fail")
+			}
+			got, err := repo.Get(ctx, created.ID)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if !equalFields(got, valid) {
+				t.Fatalf("failed update changed the row: %+v", got)
+			}
+		})
+	}
+}
+
+func testList(t *testing.T, repo subscription.Repository) {
+	ctx := context.Background()
+	user, other := uuid.New(), uuid.New()
+	service := "svc-" + uuid.NewString()
+
+	march := create(t, repo, entity.Subscription{ServiceName: service, Price: 1, UserID: user, StartDate: month(2025, time.March)})
+	jan := create(t, repo, entity.Subscription{ServiceName: service, Price: 2, UserID: user, StartDate: month(2025, time.January)})
+	feb := create(t, repo, entity.Subscription{ServiceName: "other-" + service, Price: 3, UserID: user, StartDate: month(2025, time.February)})
+	febToo := create(t, repo, entity.Subscription{ServiceName: service, Price: 4, UserID: user, StartDate: month(2025, time.February)})
+	april := create(t, repo, entity.Subscription{ServiceName: service, Price: 5, UserID: other, StartDate: month(2025, time.April)})
+
+	// Newest start date first, ties broken by id.
+	febs := []uuid.UUID{feb.ID, febToo.ID}
+	slices.SortFunc(febs, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	byUser := []uuid.UUID{march.ID, febs[0], febs[1], jan.ID}
+
+	cases := []struct {
+		name   string
+		filter subscription.ListFilter
+		want   []uuid.UUID
+	}{
+		{"by user", subscription.ListFilter{UserID: &user}, byUser},
+		{"other user", subscription.ListFilter{UserID: &other}, []uuid.UUID{april.ID}},
+		{"unknown user", subscription.ListFilter{UserID: ptr(uuid.New())}, nil},
+		{"by service", subscription.ListFilter{ServiceName: &service}, []uuid.UUID{april.ID, march.ID, febToo.ID, jan.ID}},
+		{"by user and service", subscription.ListFilter{UserID: &user, ServiceName: &service}, []uuid.UUID{march.ID, febToo.ID, jan.ID}},
+		{"service is trimmed", subscription.ListFilter{UserID: &user, ServiceName: ptr("  " + service + " ")}, []uuid.UUID{march.ID, febToo.ID, jan.ID}},
+		{"blank service is ignored", subscription.ListFilter{UserID: &user, ServiceName: ptr("  ")}, byUser},
+		{"service is case-sensitive", subscription.ListFilter{UserID: &user, ServiceName: ptr("SVC-" + service[4:])}, nil},
+		{"limit", subscription.ListFilter{UserID: &user, Limit: 2}, byUser[:2]},
+		{"zero limit means all", subscription.ListFilter{UserID: &user, Limit: 0}, byUser},
+		{"offset", subscription.ListFilter{UserID: &user, Offset: 1}, byUser[1:]},
+		{"limit and offset", subscription.ListFilter{UserID: &user, Limit: 2, Offset: 1}, byUser[1:3]},
+		{"offset past the end", subscription.ListFilter{UserID: &user, Offset: 10}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := repo.List(ctx, tc.filter)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			gotIDs := make([]uuid.UUID, 0, len(got))
+			for _, s := range got {
+				gotIDs = append(gotIDs, s.ID)
+			}
+			if !slices.Equal(gotIDs, tc.want) && !(len(gotIDs) == 0 && len(tc.want) == 0) {
+				t.Fatalf("got %v, want %v", gotIDs, tc.want)
+			}
+		})
+	}
+}
+
+func testSum(t *testing.T, repo subscription.Repository) {
+	ctx := context.Background()
+	user := uuid.New()
+	// Dates are inclusive months: a subscription costs its price for every
+	// month it shares with the period.
+	create(t, repo, entity.Subscription{ServiceName: "A", Price: 1, UserID: user,
+		StartDate: month(2025, time.January), EndDate: ptr(month(2025, time.March))})
+	create(t, repo, entity.Subscription{ServiceName: "B", Price: 10, UserID: user,
+		StartDate: month(2025, time.May), EndDate: ptr(month(2025, time.May))})
+	create(t, repo, entity.Subscription{ServiceName: "A", Price: 100, UserID: user,
+		StartDate: month(2025, time.July)})
+	create(t, repo, entity.Subscription{ServiceName: "C", Price: 1000, UserID: user,
+		StartDate: month(2024, time.January), EndDate: ptr(month(2024, time.December))})
+	// Another user's subscription must never count.
+	create(t, repo, entity.Subscription{ServiceName: "A", Price: 10000, UserID: uuid.New(),
+		StartDate: month(2020, time.January)})
+
+	cases := []struct {
+		name       string
+		start, end time.Time
+		service    *string
+		want       int
+	}{
+		{"ends on period start", month(2025, time.March), month(2025, time.April), nil, 1},
+		{"starts on period end", month(2025, time.June), month(2025, time.July), nil, 100},
+		{"ends before period", month(2025, time.April), month(2025, time.April), nil, 0},
+		{"starts after period", month(2023, time.January), month(2023, time.December), nil, 0},
+		{"period inside subscription", month(2025, time.February), month(2025, time.February), nil, 1},
+		{"subscription inside period", month(2025, time.April), month(2025, time.June), nil, 10},
+		{"single month subscription", month(2025, time.May), month(2025, time.May), nil, 10},
+		{"open end far ahead", month(2030, time.January), month(2030, time.December), nil, 1200},
+		{"whole range", month(2024, time.January), month(2025, time.December), nil, 12613},
+		{"by service", month(2024, time.January), month(2025, time.December), ptr("A"), 603},
+		{"service is trimmed", month(2024, time.January), month(2025, time.December), ptr(" A "), 603},
+		{"blank service is ignored", month(2024, time.January), month(2025, time.December), ptr(" "), 12613},
+		{"unknown service", month(2024, time.January), month(2025, time.December), ptr("Z"), 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := repo.Sum(ctx, subscription.SummaryFilter{
+				UserID:      &user,
+				ServiceName: tc.service,
+				StartDate:   tc.start,
+				EndDate:     tc.end,
+			})
+			if err != nil {
+				t.Fatalf("sum: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("no rows", func(t *testing.T) {
+		got, err := repo.Sum(ctx, subscription.SummaryFilter{
+			UserID:    ptr(uuid.New()),
+			StartDate: month(2025, time.January),
+			EndDate:   month(2025, time.December),
+		})
+		if err != nil || got != 0 {
+			t.Fatalf("got %d (%v), want 0", got, err)
+		}
+	})
+}
+
+// testLabels passes labels the way the service does: lowercased, and tags
+// sorted without duplicates.
+func testLabels(t *testing.T, repo subscription.Repository) {
+	ctx := context.Background()
+	user := uuid.New()
+	// Tag names are global, so each run uses its own.
+	suffix := uuid.NewString()[:8]
+	music, family, work := "music-"+suffix, "family-"+suffix, "work-"+suffix
+
+	a := create(t, repo, entity.Subscription{ServiceName: "A", Price: 1, UserID: user,
+		StartDate: month(2025, time.January), Category: "streaming", Tags: []string{family, music}})
+	create(t, repo, entity.Subscription{ServiceName: "B", Price: 10, UserID: user,
+		StartDate: month(2025, time.January), Category: "streaming", Tags: []string{music}})
+	create(t, repo, entity.Subscription{ServiceName: "C", Price: 100, UserID: user,
+		StartDate: month(2025, time.January)})
+
+	got, err := repo.Get(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Category != "streaming" || !slices.Equal(got.Tags, []string{family, music}) {
+		t.Fatalf("got %q %v, want streaming [%s %s]", got.Category, got.Tags, family, music)
+	}
+
+	t.Run("filters", func(t *testing.T) {
+		cases := []struct {
+			name          string
+			category, tag *string
+			want          int
+		}{
+			{"by category", ptr("streaming"), nil, 11},
+			{"by tag", nil, &music, 11},
+			{"by category and tag", ptr("streaming"), &family, 1},
+			{"blank filters are ignored", ptr(" "), ptr(""), 111},
+			{"unknown tag", nil, ptr("none-" + suffix), 0},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				subs, err := repo.List(ctx, subscription.ListFilter{UserID: &user, Category: tc.category, Tag: tc.tag})
+				if err != nil {
+					t.Fatalf("list: %v", err)
+				}
+				listed := 0
+				for _, s := range subs {
+					listed += s.Price
+				}
+				total, err := repo.Sum(ctx, subscription.SummaryFilter{UserID: &user, Category: tc.category, Tag: tc.tag,
+					StartDate: month(2025, time.January), EndDate: month(2025, time.January)})
+				if err != nil {
+					t.Fatalf("sum: %v", err)
+				}
+				if listed != tc.want || total != tc.want {
+					t.Fatalf("listed %d, summed %d, want %d", listed, total, tc.want)
+				}
+			})
+		}
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		filter := subscription.SummaryFilter{UserID: &user, StartDate: month(2025, time.January), EndDate: month(2025, time.January)}
+		cases := []struct {
+			by   subscription.GroupBy
+			want []subscription.GroupTotal
+		}{
+			{subscription.GroupByCategory, []subscription.GroupTotal{{Key: "", Total: 100}, {Key: "streaming", Total: 11}}},
+			{subscription.GroupByTag, []subscription.GroupTotal{{Key: "", Total: 100}, {Key: family, Total: 1}, {Key: music, Total: 11}}},
+		}
+		for _, tc := range cases {
+			t.Run(string(tc.by), func(t *testing.T) {
+				got, err := repo.SumGroups(ctx, filter, tc.by)
+				if err != nil {
+					t.Fatalf("sum groups: %v", err)
+				}
+				if !slices.Equal(got, tc.want) {
+					t.Fatalf("got %v, want %v", got, tc.want)
+				}
+			})
+		}
+	})
+
+	t.Run("update replaces tags", func(t *testing.T) {
+		in := a
+		in.Category, in.Tags = "work", []string{music, work}
+		updated, err := repo.Update(ctx, a.ID, in)
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		got, err := repo.Get(ctx, a.ID)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		for _, s := range []entity.Subscription{updated, got} {
+			if s.Category != "work" || !slices.Equal(s.Tags, []string{music, work}) {
+				t.Fatalf("got %q %v, want work [%s %s]", s.Category, s.Tags, music, work)
+			}
+		}
+
+		in.Category, in.Tags = "", nil
+		if _, err := repo.Update(ctx, a.ID, in); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if got, _ := repo.Get(ctx, a.ID); got.Category != "" || len(got.Tags) != 0 {
+			t.Fatalf("got %q %v, want no labels", got.Category, got.Tags)
+		}
+	})
+}
+
+// testLifecycle checks that the lifecycle round-trips and that summaries
+// skip trial and paused months, agreeing with
+// entity.Subscription.BilledMonths.
+func testLifecycle(t *testing.T, repo subscription.Repository) {
+	ctx := context.Background()
+	user := uuid.New()
+
+	// Free January through March, billed from April.
+	trial := create(t, repo, entity.Subscription{ServiceName: "Trial", Price: 1, UserID: user,
+		StartDate: month(2025, time.January), Status: entity.StatusTrial, TrialEndDate: ptr(month(2025, time.April))})
+	// Paused March through May, and again from August on.
+	paused := create(t, repo, entity.Subscription{ServiceName: "Paused", Price: 10, UserID: user,
+		StartDate: month(2025, time.January), Status: entity.StatusPaused, Pauses: []entity.Pause{
+			{Start: month(2025, time.March), End: ptr(month(2025, time.June))},
+			{Start: month(2025, time.August)},
+		}})
+	// Billed through its cancellation month, June.
+	cancelled := create(t, repo, entity.Subscription{ServiceName: "Cancelled", Price: 100, UserID: user,
+		StartDate: month(2025, time.January), EndDate: ptr(month(2025, time.June)),
+		Status: entity.StatusCancelled, CancelledAt: ptr(time.Date(2025, time.June, 17, 0, 0, 0, 0, time.UTC))})
+
+	for _, want := range []entity.Subscription{trial, paused, cancelled} {
+		got, err := repo.Get(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if got.Status != want.Status || !sameDate(got.TrialEndDate, want.TrialEndDate) ||
+			!sameDate(got.CancelledAt, want.CancelledAt) || !samePauses(got.Pauses, want.Pauses) {
+			t.Fatalf("got %s %v %v %v, want %s %v %v %v", got.Status, got.TrialEndDate, got.CancelledAt, got.Pauses,
+				want.Status, want.TrialEndDate, want.CancelledAt, want.Pauses)
+		}
+	}
+
+	cases := []struct {
+		name       string
+		start, end time.Time
+		want       int
+	}{
+		{"trial and pause", month(2025, time.March), month(2025, time.March), 100},
+		{"trial over", month(2025, time.April), month(2025, time.April), 101},
+		{"pause over", month(2025, time.June), month(2025, time.June), 111},
+		{"after cancellation", month(2025, time.July), month(2025, time.July), 11},
+		{"open pause", month(2025, time.August), month(2025, time.December), 5},
+		{"trial ends inside", month(2025, time.March), month(2025, time.April), 201},
+		{"pause inside", month(2025, time.February), month(2025, time.July), 534},
+		{"whole year", month(2025, time.January), month(2025, time.December), 649},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			billed := 0
+			for _, s := range []entity.Subscription{trial, paused, cancelled} {
+				billed += s.Price * s.BilledMonths(tc.start, tc.end)
+			}
+			if billed != tc.want {
+				t.Fatalf("entity.BilledMonths gives %d, want %d", billed, tc.want)
+			}
+			got, err := repo.Sum(ctx, subscription.SummaryFilter{UserID: &user, StartDate: tc.start, EndDate: tc.end})
+			if err != nil {
+				t.Fatalf("sum: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("update", func(t *testing.T) {
+		in := paused
+		in.Status, in.Pauses = entity.StatusActive, []entity.Pause{{Start: month(2025, time.March), End: ptr(month(2025, time.June))}}
+		if _, err := repo.Update(ctx, paused.ID, in); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		got, err := repo.Get(ctx, paused.ID)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if got.Status != entity.StatusActive || !samePauses(got.Pauses, in.Pauses) {
+			t.Fatalf("got %s %v, want active %v", got.Status, got.Pauses, in.Pauses)
+		}
+	})
+
+	t.Run("empty status is active", func(t *testing.T) {
+		got := create(t, repo, entity.Subscription{ServiceName: "Plain", Price: 1000, UserID: user, StartDate: month(2025, time.January)})
+		if got, _ = repo.Get(ctx, got.ID); got.Status != entity.StatusActive {
+			t.Fatalf("got status %q, want active", got.Status)
+		}
+	})
+}
+
+func samePauses(a, b []entity.Pause) bool {
+	return slices.EqualFunc(a, b, func(x, y entity.Pause) bool {
+		return x.Start.Equal(y.Start) && sameDate(x.End, y.End)
+	})
+}
+
+func testUnknownUser(t *testing.T, repo subscription.Repository) {
+	ctx := context.Background()
+	existing := create(t, repo, entity.Subscription{ServiceName: "Netflix", Price: 1, UserID: uuid.New(), StartDate: month(2025, time.July)})
+	unknown := entity.Subscription{ServiceName: "Netflix", Price: 1, UserID: uuid.New(), StartDate: month(2025, time.July)}
+
+	repo = unwrap(repo)
+	if _, err := repo.Create(ctx, unknown); !errors.Is(err, subscription.ErrUnknownUser) {
+		t.Fatalf("create: expected ErrUnknownUser, got %v", err)
+	}
+	if _, err := repo.Update(ctx, existing.ID, unknown); !errors.Is(err, subscription.ErrUnknownUser) {
+		t.Fatalf("update: expected ErrUnknownUser, got %v", err)
+	}
+	got, err := repo.Get(ctx, existing.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.UserID != existing.UserID {
+		t.Fatalf("refused update changed the user to %s", got.UserID)
+	}
+}
+
+// RunUnitOfWork checks that uow isolates the service's read-modify-write
+// operations on repo: an update racing a pause must keep the pause, and a
+// pause racing an update must keep the new price.
+func RunUnitOfWork(t *testing.T, repo subscription.Repository, uow subscription.UnitOfWork) {
+	ctx := context.Background()
+	service := subscription.NewService(slowReads{repo}, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		subscription.WithUnitOfWork(uow))
+	at := time.Now()
+
+	for range 5 {
+		sub := create(t, repo, entity.Subscription{ServiceName: "Netflix", Price: 100, UserID: uuid.New(),
+			StartDate: month(2025, time.January)})
+		in := sub
+		in.Price = 200
+
+		var wg sync.WaitGroup
+		var updateErr, pauseErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, updateErr = service.Update(ctx, sub.ID, in)
+		}()
+		go func() {
+			defer wg.Done()
+			_, pauseErr = service.Pause(ctx, sub.ID, at)
+		}()
+		wg.Wait()
+		if updateErr != nil || pauseErr != nil {
+			t.Fatalf("update: %v, pause: %v", updateErr, pauseErr)
+		}
+
+		got, err := repo.Get(ctx, sub.ID)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if got.Price != 200 || got.Status != entity.StatusPaused || len(got.Pauses) != 1 {
+			t.Fatalf("lost a concurrent change: price %d, status %s, pauses %v", got.Price, got.Status, got.Pauses)
+		}
+	}
+}
+
+// slowReads widens the window between an operation's read and its write,
+// so that a unit of work that does not isolate them loses a change.
+type slowReads struct {
+	subscription.Repository
+}
+
+func (r slowReads) GetForUpdate(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	sub, err := r.Repository.GetForUpdate(ctx, id)
+	time.Sleep(20 * time.Millisecond)
+	return sub, err
+}
@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/catalog"
+)
+
+// Resolve matches key against canonical names and exact aliases, then
+// against prefix aliases, longest pattern first. A prefix only matches
+// whole words: "netflix" matches "netflix premium" but not "netflixer".
+func (r *CatalogRepo) Resolve(ctx context.Context, key string) (entity.Service, error) {
+	q := `
+		with matched as (
+			select id as service_id, 0 as rank, 0 as length
+			from services
+			where name_key = $1
+			union all
+			select service_id, 0, 0
+			from service_aliases
+			where match = 'exact' and pattern = $1
+			union all
+			select service_id, 1, length(pattern)
+			from service_aliases
+			where match = 'prefix' and ($1 = pattern or starts_with($1, pattern || ' '))
+		)
+		select ` + serviceColumns + `
+		from matched m
+		join services s on s.id = m.service_id
+		order by m.rank, m.length desc, s.name_key
+		limit 1
+	`
+
+	svc, err := scanService(conn(ctx, r.db).QueryRowContext(ctx, q, key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Service{}, catalog.ErrNotFound
+		}
+		return entity.Service{}, fmt.Errorf("resolve service: %w", err)
+	}
+	return svc, nil
+}
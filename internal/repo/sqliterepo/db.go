@@ -0,0 +1,67 @@
+// Package sqliterepo stores subscriptions in a SQLite file through the
+// pure-Go modernc.org/sqlite driver, for laptops and small single-tenant
+// installs that do without Postgres.
+package sqliterepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+
+	"restservice/migrations"
+)
+
+const driverName = "sqlite"
+
+// Open opens the database file at path, creating it if needed. Path may
+// carry driver parameters after "?", as in "subs.db?_pragma=cache_size(-8000)".
+//
+// Transactions begin immediately, taking the write lock up front: a
+// transaction that reads and then writes could otherwise fail to upgrade
+// its lock when another writer got there first, and GetForUpdate relies on
+// it. A path that asks for another _txlock mode is rejected. Writers that
+// find the lock taken wait for up to five seconds.
+func Open(path string) (*sql.DB, error) {
+	name, params, _ := strings.Cut(path, "?")
+	q, err := url.ParseQuery(params)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite %s: %w", path, err)
+	}
+	if lock := q.Get("_txlock"); lock != "" && lock != "immediate" {
+		return nil, fmt.Errorf("sqlite %s: _txlock=%s: transactions must begin immediately", path, lock)
+	}
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Set("_txlock", "immediate")
+
+	db, err := sql.Open(driverName, "file:"+name+"?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("open sqlite %s: %w", name, err)
+	}
+	return db, nil
+}
+
+// NewMigrator returns a goose provider over the embedded SQLite
+// migrations.
+func NewMigrator(db *sql.DB) (*goose.Provider, error) {
+	fsys, err := fs.Sub(migrations.SQLiteFS, "sqlite")
+	if err != nil {
+		return nil, fmt.Errorf("sqlite migrations: %w", err)
+	}
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	if err != nil {
+		return nil, fmt.Errorf("migration provider: %w", err)
+	}
+	return provider, nil
+}
@@ -0,0 +1,501 @@
+package sqliterepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
+)
+
+const (
+	dateLayout      = time.DateOnly
+	timestampLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+	subscriptionColumns = `id, service_name, price, user_id, start_date, end_date, updated_at, category,
+		status, trial_end_date, cancelled_at, pauses`
+
+	// tagsColumn selects a subscription's tags as sorted comma-separated
+	// text; the service rejects tags with commas.
+	tagsColumn = `coalesce((
+		select group_concat(name, ',')
+		from (
+			select t.name
+			from subscription_tags st
+			join tags t on t.id = st.tag_id
+			where st.subscription_id = subscriptions.id
+			order by t.name
+		)
+	), '')`
+)
+
+type SubscriptionRepo struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepo(db *sql.DB) *SubscriptionRepo {
+	return &SubscriptionRepo{db: db}
+}
+
+func (r *SubscriptionRepo) Create(ctx context.Context, s entity.Subscription) (entity.Subscription, error) {
+	const q = `
+		insert into subscriptions (` + subscriptionColumns + `)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	s.ID = uuid.New()
+	s.UpdatedAt = now()
+	if s.Status == "" {
+		s.Status = entity.StatusActive
+	}
+	pauses, err := encodePauses(s.Pauses)
+	if err != nil {
+		return entity.Subscription{}, err
+	}
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		_, err := conn(ctx, r.db).ExecContext(ctx, q,
+			s.ID.String(),
+			s.ServiceName,
+			s.Price,
+			s.UserID.String(),
+			formatDate(s.StartDate),
+			formatEndDate(s.EndDate),
+			formatTimestamp(s.UpdatedAt),
+			s.Category,
+			string(s.Status),
+			formatEndDate(s.TrialEndDate),
+			formatEndDate(s.CancelledAt),
+			pauses,
+		)
+		if err != nil {
+			return err
+		}
+		return linkTags(ctx, conn(ctx, r.db), s.ID, s.Tags)
+	})
+	if err != nil {
+		if raised(err, errUnknownUser) {
+			return entity.Subscription{}, subscription.ErrUnknownUser
+		}
+		return entity.Subscription{}, fmt.Errorf("create subscription: %w", err)
+	}
+	return s, nil
+}
+
+func (r *SubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	const q = `select ` + subscriptionColumns + `, ` + tagsColumn + ` from subscriptions where id = ?`
+
+	sub, err := scanSubscription(conn(ctx, r.db).QueryRowContext(ctx, q, id.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Subscription{}, subscription.ErrNotFound
+		}
+		return entity.Subscription{}, fmt.Errorf("get subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// GetForUpdate is Get: Open sets _txlock=immediate, so transactions take
+// the write lock when they begin and hold it until they end, and a row read
+// in one cannot change under it. A *sql.DB opened without that mode gives
+// no such guarantee.
+func (r *SubscriptionRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	return r.Get(ctx, id)
+}
+
+func (r *SubscriptionRepo) List(ctx context.Context, filter subscription.ListFilter) ([]entity.Subscription, error) {
+	q := `select ` + subscriptionColumns + `, ` + tagsColumn + ` from subscriptions where 1=1`
+	cond, args := filterSQL(filter.UserID, filter.ServiceName)
+	labelCond, labelArgs := labelSQL(filter.Category, filter.Tag)
+	args = append(args, labelArgs...)
+	q += cond + labelCond + ` order by start_date desc, id`
+	// SQLite only accepts OFFSET after LIMIT; -1 means no limit.
+	if filter.Limit > 0 || filter.Offset > 0 {
+		limit := -1
+		if filter.Limit > 0 {
+			limit = filter.Limit
+		}
+		q += ` limit ? offset ?`
+		args = append(args, limit, filter.Offset)
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []entity.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list subscriptions rows: %w", err)
+	}
+	return subs, nil
+}
+
+func (r *SubscriptionRepo) Update(ctx context.Context, id uuid.UUID, s entity.Subscription) (entity.Subscription, error) {
+	const q = `
+		update subscriptions
+		set service_name = ?, price = ?, user_id = ?, start_date = ?, end_date = ?, updated_at = ?,
+			category = ?, status = ?, trial_end_date = ?, cancelled_at = ?, pauses = ?
+		where id = ?
+	`
+
+	s.ID = id
+	s.UpdatedAt = now()
+	if s.Status == "" {
+		s.Status = entity.StatusActive
+	}
+	pauses, err := encodePauses(s.Pauses)
+	if err != nil {
+		return entity.Subscription{}, err
+	}
+	err = r.withinTx(ctx, func(ctx context.Context) error {
+		res, err := conn(ctx, r.db).ExecContext(ctx, q,
+			s.ServiceName,
+			s.Price,
+			s.UserID.String(),
+			formatDate(s.StartDate),
+			formatEndDate(s.EndDate),
+			formatTimestamp(s.UpdatedAt),
+			s.Category,
+			string(s.Status),
+			formatEndDate(s.TrialEndDate),
+			formatEndDate(s.CancelledAt),
+			pauses,
+			id.String(),
+		)
+		if err != nil {
+			if raised(err, errUnknownUser) {
+				return subscription.ErrUnknownUser
+			}
+			return fmt.Errorf("update subscription: %w", err)
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("update subscription rows: %w", err)
+		} else if affected == 0 {
+			return subscription.ErrNotFound
+		}
+
+		if _, err := conn(ctx, r.db).ExecContext(ctx,
+			`delete from subscription_tags where subscription_id = ?`, id.String()); err != nil {
+			return fmt.Errorf("unlink tags: %w", err)
+		}
+		return linkTags(ctx, conn(ctx, r.db), id, s.Tags)
+	})
+	if err != nil {
+		return entity.Subscription{}, err
+	}
+
+	// Return what a read would, with dates normalised the same way.
+	s.StartDate = truncateDate(s.StartDate)
+	s.EndDate = truncateDatePtr(s.EndDate)
+	s.TrialEndDate = truncateDatePtr(s.TrialEndDate)
+	s.CancelledAt = truncateDatePtr(s.CancelledAt)
+	return s, nil
+}
+
+func (r *SubscriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	const q = `delete from subscriptions where id = ?`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, q, id.String())
+	if err != nil {
+		return fmt.Errorf("delete subscription: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete subscription rows: %w", err)
+	}
+	if affected == 0 {
+		return subscription.ErrNotFound
+	}
+	return nil
+}
+
+// Sum uses the same rule as the Postgres repository: a subscription costs
+// its price for every month of the period it is billed for. Dates are ISO
+// strings, so text comparison orders them correctly.
+func (r *SubscriptionRepo) Sum(ctx context.Context, filter subscription.SummaryFilter) (int, error) {
+	q := `
+		select coalesce(sum(price * ` + billedMonthsSQL + `), 0)
+		from subscriptions
+		where start_date <= ?1
+		  and (end_date is null or end_date >= ?2)
+	`
+	args := []any{formatDate(filter.EndDate), formatDate(filter.StartDate)}
+	cond, condArgs := filterSQL(filter.UserID, filter.ServiceName)
+	labelCond, labelArgs := labelSQL(filter.Category, filter.Tag)
+	q += cond + labelCond
+	args = append(args, condArgs...)
+	args = append(args, labelArgs...)
+
+	var total int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, q, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("sum subscriptions: %w", err)
+	}
+	return total, nil
+}
+
+func (r *SubscriptionRepo) SumGroups(ctx context.Context, filter subscription.SummaryFilter, by subscription.GroupBy) ([]subscription.GroupTotal, error) {
+	key, from := "category", "subscriptions"
+	if by == subscription.GroupByTag {
+		key = "coalesce(t.name, '')"
+		from = `subscriptions
+			left join subscription_tags st on st.subscription_id = subscriptions.id
+			left join tags t on t.id = st.tag_id`
+	}
+	q := `
+		select ` + key + `, sum(price * ` + billedMonthsSQL + `)
+		from ` + from + `
+		where start_date <= ?1
+		  and (end_date is null or end_date >= ?2)
+		  and ` + billedMonthsSQL + ` > 0
+	`
+	args := []any{formatDate(filter.EndDate), formatDate(filter.StartDate)}
+	cond, condArgs := filterSQL(filter.UserID, filter.ServiceName)
+	labelCond, labelArgs := labelSQL(filter.Category, filter.Tag)
+	q += cond + labelCond + ` group by 1 order by 1`
+	args = append(args, condArgs...)
+	args = append(args, labelArgs...)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sum subscription groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []subscription.GroupTotal
+	for rows.Next() {
+		var g subscription.GroupTotal
+		if err := rows.Scan(&g.Key, &g.Total); err != nil {
+			return nil, fmt.Errorf("scan subscription group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sum subscription groups rows: %w", err)
+	}
+	return groups, nil
+}
+
+// billedMonthsSQL is entity.Subscription.BilledMonths for the period from
+// ?2 to ?1, the first two parameters of the summary queries. Months are
+// numbered as in entity.MonthNumber. SQLite's multi-argument max and min
+// are null if any argument is, so missing dates fall back to ones that do
+// not change the result. The Postgres repositories share a fragment of
+// their own; this dialect has no jsonb_to_recordset and no lateral joins.
+const billedMonthsSQL = `(
+		select max(0, b.hi - b.lo + 1 - (
+			select coalesce(sum(max(0,
+				min(b.hi, coalesce(
+					cast(substr(json_extract(p.value, '$.end'), 1, 4) as integer) * 12
+						+ cast(substr(json_extract(p.value, '$.end'), 6, 2) as integer) - 1,
+					b.hi))
+				- max(b.lo,
+					cast(substr(json_extract(p.value, '$.start'), 1, 4) as integer) * 12
+						+ cast(substr(json_extract(p.value, '$.start'), 6, 2) as integer))
+				+ 1
+			)), 0)
+			from json_each(subscriptions.pauses) p
+		))
+		from (
+			select max(
+					cast(substr(start_date, 1, 4) as integer) * 12 + cast(substr(start_date, 6, 2) as integer),
+					cast(substr(coalesce(trial_end_date, start_date), 1, 4) as integer) * 12
+						+ cast(substr(coalesce(trial_end_date, start_date), 6, 2) as integer),
+					cast(substr(?2, 1, 4) as integer) * 12 + cast(substr(?2, 6, 2) as integer)
+				) as lo,
+				min(
+					cast(substr(coalesce(end_date, ?1), 1, 4) as integer) * 12
+						+ cast(substr(coalesce(end_date, ?1), 6, 2) as integer),
+					cast(substr(?1, 1, 4) as integer) * 12 + cast(substr(?1, 6, 2) as integer)
+				) as hi
+		) b
+	)`
+
+// withinTx runs fn in the caller's transaction, or in a new one.
+func (r *SubscriptionRepo) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return NewUnitOfWork(r.db).WithinTxLevel(ctx, sql.LevelDefault, fn)
+}
+
+// linkTags creates the tags that do not exist yet and links them to the
+// subscription.
+func linkTags(ctx context.Context, q querier, id uuid.UUID, tags []string) error {
+	for _, tag := range tags {
+		if _, err := q.ExecContext(ctx,
+			`insert into tags (name) values (?) on conflict (name) do nothing`, tag); err != nil {
+			return fmt.Errorf("create tag: %w", err)
+		}
+		if _, err := q.ExecContext(ctx, `
+			insert into subscription_tags (subscription_id, tag_id)
+			select ?, id from tags where name = ?
+		`, id.String(), tag); err != nil {
+			return fmt.Errorf("link tag: %w", err)
+		}
+	}
+	return nil
+}
+
+func filterSQL(userID *uuid.UUID, serviceName *string) (string, []any) {
+	var (
+		cond strings.Builder
+		args []any
+	)
+	if userID != nil {
+		cond.WriteString(` and user_id = ?`)
+		args = append(args, userID.String())
+	}
+	if serviceName != nil && strings.TrimSpace(*serviceName) != "" {
+		cond.WriteString(` and service_name = ?`)
+		args = append(args, strings.TrimSpace(*serviceName))
+	}
+	return cond.String(), args
+}
+
+func labelSQL(category, tag *string) (string, []any) {
+	var (
+		cond strings.Builder
+		args []any
+	)
+	if category != nil && strings.TrimSpace(*category) != "" {
+		cond.WriteString(` and category = ?`)
+		args = append(args, strings.TrimSpace(*category))
+	}
+	if tag != nil && strings.TrimSpace(*tag) != "" {
+		cond.WriteString(` and exists (
+			select 1
+			from subscription_tags st
+			join tags t on t.id = st.tag_id
+			where st.subscription_id = subscriptions.id and t.name = ?
+		)`)
+		args = append(args, strings.TrimSpace(*tag))
+	}
+	return cond.String(), args
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner) (entity.Subscription, error) {
+	var (
+		sub                          entity.Subscription
+		id, userID, start, updatedAt string
+		end, trialEnd, cancelledAt   sql.NullString
+		tags, status, pauses         string
+	)
+	if err := row.Scan(&id, &sub.ServiceName, &sub.Price, &userID, &start, &end, &updatedAt,
+		&sub.Category, &status, &trialEnd, &cancelledAt, &pauses, &tags); err != nil {
+		return entity.Subscription{}, err
+	}
+
+	var err error
+	if sub.ID, err = uuid.Parse(id); err != nil {
+		return entity.Subscription{}, fmt.Errorf("parse id: %w", err)
+	}
+	if sub.UserID, err = uuid.Parse(userID); err != nil {
+		return entity.Subscription{}, fmt.Errorf("parse user_id: %w", err)
+	}
+	if sub.StartDate, err = time.Parse(dateLayout, start); err != nil {
+		return entity.Subscription{}, fmt.Errorf("parse start_date: %w", err)
+	}
+	if sub.EndDate, err = parseNullDate(end); err != nil {
+		return entity.Subscription{}, fmt.Errorf("parse end_date: %w", err)
+	}
+	if sub.TrialEndDate, err = parseNullDate(trialEnd); err != nil {
+		return entity.Subscription{}, fmt.Errorf("parse trial_end_date: %w", err)
+	}
+	if sub.CancelledAt, err = parseNullDate(cancelledAt); err != nil {
+		return entity.Subscription{}, fmt.Errorf("parse cancelled_at: %w", err)
+	}
+	sub.Status = entity.Status(status)
+	if sub.Pauses, err = decodePauses(pauses); err != nil {
+		return entity.Subscription{}, err
+	}
+	if sub.UpdatedAt, err = time.Parse(timestampLayout, updatedAt); err != nil {
+		return entity.Subscription{}, fmt.Errorf("parse updated_at: %w", err)
+	}
+	if tags != "" {
+		sub.Tags = strings.Split(tags, ",")
+	}
+	return sub, nil
+}
+
+// now matches the microsecond precision Postgres keeps for timestamps.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format(dateLayout)
+}
+
+func formatEndDate(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return formatDate(*t)
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+func parseNullDate(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(dateLayout, s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func truncateDatePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	d := truncateDate(*t)
+	return &d
+}
+
+// encodePauses never returns null, which the column does not accept.
+func encodePauses(pauses []entity.Pause) (string, error) {
+	if len(pauses) == 0 {
+		return "[]", nil
+	}
+	b, err := json.Marshal(pauses)
+	if err != nil {
+		return "", fmt.Errorf("encode pauses: %w", err)
+	}
+	return string(b), nil
+}
+
+func decodePauses(s string) ([]entity.Pause, error) {
+	var pauses []entity.Pause
+	if err := json.Unmarshal([]byte(s), &pauses); err != nil {
+		return nil, fmt.Errorf("decode pauses: %w", err)
+	}
+	if len(pauses) == 0 {
+		return nil, nil
+	}
+	return pauses, nil
+}
+
+func truncateDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,49 @@
+package sqliterepo
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/repo/repotest"
+	"restservice/internal/usecase/subscription"
+)
+
+func TestConformance(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "subscriptions.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := repotest.EnsureUsers(NewSubscriptionRepo(db), func(ctx context.Context, id uuid.UUID) error {
+		ts := formatTimestamp(now())
+		_, err := conn(ctx, db).ExecContext(ctx,
+			`insert or ignore into users (id, created_at, updated_at) values (?, ?, ?)`, id.String(), ts, ts)
+		return err
+	})
+	repotest.Run(t, func(*testing.T) subscription.Repository { return repo })
+	repotest.RunUnitOfWork(t, repo, NewUnitOfWork(db))
+}
+
+func TestOpenRejectsDeferredTransactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.db")
+	if _, err := Open(path + "?_txlock=deferred"); err == nil {
+		t.Fatal("expected an error for _txlock=deferred")
+	}
+	db, err := Open(path + "?_txlock=immediate&_pragma=cache_size(-2000)")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_ = db.Close()
+}
@@ -0,0 +1,66 @@
+package sqliterepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"restservice/internal/entity"
+)
+
+// querier is the subset of *sql.DB and *sql.Tx the repository uses.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// UnitOfWork commits subscription changes and their outbox events in one
+// SQLite transaction.
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// WithinTxLevel calls fn in a transaction. SQLite transactions are always
+// serializable, so level is accepted for interface compatibility only, and
+// with a single writer at a time there are no conflicts to retry. Nested
+// calls join the outer transaction.
+func (u *UnitOfWork) WithinTxLevel(ctx context.Context, _ sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// Append drops the event. Webhooks and the event stream, the only readers
+// of an outbox, need Postgres, so a SQLite outbox would only grow; events
+// still reach the configured publisher once the transaction commits. The
+// server says so in its log at startup.
+func (u *UnitOfWork) Append(context.Context, entity.OutboxEvent) error {
+	return nil
+}
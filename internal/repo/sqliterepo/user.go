@@ -0,0 +1,180 @@
+package sqliterepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/user"
+)
+
+// Messages of the triggers that stand in for the users foreign key, see
+// migrations/sqlite/20261019220000_users.sql.
+const (
+	errUnknownUser      = "unknown user"
+	errHasSubscriptions = "user has subscriptions"
+)
+
+const userColumns = `id, name, coalesce(email, ''), timezone, currency, created_at, updated_at`
+
+type UserRepo struct {
+	db *sql.DB
+}
+
+func NewUserRepo(db *sql.DB) *UserRepo {
+	return &UserRepo{db: db}
+}
+
+func (r *UserRepo) Create(ctx context.Context, u entity.User) (entity.User, error) {
+	const q = `
+		insert into users (id, name, email, timezone, currency, created_at, updated_at)
+		values (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	u.ID = uuid.New()
+	u.CreatedAt = now()
+	u.UpdatedAt = u.CreatedAt
+	_, err := conn(ctx, r.db).ExecContext(ctx, q, u.ID.String(), u.Name, nullEmail(u.Email), u.Timezone, u.Currency,
+		formatTimestamp(u.CreatedAt), formatTimestamp(u.UpdatedAt))
+	if err != nil {
+		if emailConflict(err) {
+			return entity.User{}, user.ErrEmailTaken
+		}
+		return entity.User{}, fmt.Errorf("create user: %w", err)
+	}
+	return u, nil
+}
+
+func (r *UserRepo) Get(ctx context.Context, id uuid.UUID) (entity.User, error) {
+	const q = `select ` + userColumns + ` from users where id = ?`
+
+	u, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, q, id.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, user.ErrNotFound
+		}
+		return entity.User{}, fmt.Errorf("get user: %w", err)
+	}
+	return u, nil
+}
+
+func (r *UserRepo) List(ctx context.Context, filter user.ListFilter) ([]entity.User, error) {
+	q := `select ` + userColumns + ` from users order by created_at, id`
+	var args []any
+	if filter.Limit > 0 || filter.Offset > 0 {
+		// SQLite accepts offset only after a limit; -1 means none.
+		limit := -1
+		if filter.Limit > 0 {
+			limit = filter.Limit
+		}
+		q += ` limit ? offset ?`
+		args = append(args, limit, filter.Offset)
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+
+	var items []entity.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		items = append(items, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list users rows: %w", err)
+	}
+	return items, nil
+}
+
+func (r *UserRepo) Update(ctx context.Context, id uuid.UUID, u entity.User) (entity.User, error) {
+	const q = `
+		update users
+		set name = ?, email = ?, timezone = ?, currency = ?, updated_at = ?
+		where id = ?
+		returning ` + userColumns
+
+	updated, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, q,
+		u.Name, nullEmail(u.Email), u.Timezone, u.Currency, formatTimestamp(now()), id.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, user.ErrNotFound
+		}
+		if emailConflict(err) {
+			return entity.User{}, user.ErrEmailTaken
+		}
+		return entity.User{}, fmt.Errorf("update user: %w", err)
+	}
+	return updated, nil
+}
+
+// Delete removes a user. A trigger refuses it while the user still has
+// subscriptions.
+func (r *UserRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	const q = `delete from users where id = ?`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, q, id.String())
+	if err != nil {
+		if raised(err, errHasSubscriptions) {
+			return user.ErrHasSubscriptions
+		}
+		return fmt.Errorf("delete user: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete user rows: %w", err)
+	}
+	if affected == 0 {
+		return user.ErrNotFound
+	}
+	return nil
+}
+
+func scanUser(row rowScanner) (entity.User, error) {
+	var (
+		u                        entity.User
+		id, createdAt, updatedAt string
+	)
+	if err := row.Scan(&id, &u.Name, &u.Email, &u.Timezone, &u.Currency, &createdAt, &updatedAt); err != nil {
+		return entity.User{}, err
+	}
+
+	var err error
+	if u.ID, err = uuid.Parse(id); err != nil {
+		return entity.User{}, fmt.Errorf("parse id: %w", err)
+	}
+	if u.CreatedAt, err = time.Parse(timestampLayout, createdAt); err != nil {
+		return entity.User{}, fmt.Errorf("parse created_at: %w", err)
+	}
+	if u.UpdatedAt, err = time.Parse(timestampLayout, updatedAt); err != nil {
+		return entity.User{}, fmt.Errorf("parse updated_at: %w", err)
+	}
+	return u, nil
+}
+
+// nullEmail stores a missing email as null, so the unique index only
+// covers the users that have one.
+func nullEmail(email string) sql.NullString {
+	return sql.NullString{String: email, Valid: email != ""}
+}
+
+// raised reports whether err comes from a trigger aborting with msg. The
+// driver reports those only as text.
+func raised(err error, msg string) bool {
+	return err != nil && strings.Contains(err.Error(), msg)
+}
+
+func emailConflict(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed") &&
+		strings.Contains(err.Error(), "idx_users_email")
+}
@@ -1,11 +1,200 @@
 package repo
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/subscription"
+)
 
 type SubscriptionRepo struct {
-	db *sql.DB
+	db       *sql.DB
+	replicas *Replicas
+}
+
+type SubscriptionOption func(*SubscriptionRepo)
+
+// WithReplicas serves Get, List and Sum from replicas when one is healthy.
+func WithReplicas(replicas *Replicas) SubscriptionOption {
+	return func(r *SubscriptionRepo) { r.replicas = replicas }
+}
+
+func NewSubscriptionRepo(db *sql.DB, opts ...SubscriptionOption) *SubscriptionRepo {
+	r := &SubscriptionRepo{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// read runs a read-only query on a replica, or on the primary inside a
+// transaction, when ctx asks for primary reads or no replica is healthy.
+// When a replica fails the query it is repeated on the primary, so fn must
+// not keep state between calls; a replica failing several in a row is
+// marked unhealthy.
+func (r *SubscriptionRepo) read(ctx context.Context, fn func(db querier) error) error {
+	_, inTx := ctx.Value(txKey{}).(*sql.Tx)
+	if inTx || r.replicas == nil || subscription.PrimaryReads(ctx) {
+		return fn(conn(ctx, r.db))
+	}
+	rep := r.replicas.pick()
+	if rep == nil {
+		return fn(r.db)
+	}
+
+	err := fn(rep.db)
+	if err == nil || errors.Is(err, subscription.ErrNotFound) {
+		rep.failures.Store(0)
+		return err
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+	r.replicas.failed(rep, err)
+	return fn(r.db)
+}
+
+// tagsColumn selects a subscription's tags as sorted comma-separated text.
+// Tags are passed both ways as text, like webhook events; the service
+// rejects tags with commas.
+const tagsColumn = `coalesce((
+		select string_agg(t.name, ',' order by t.name)
+		from subscription_tags st
+		join tags t on t.id = st.tag_id
+		where st.subscription_id = subscriptions.id
+	), '')`
+
+func joinTags(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// labelConds appends the category and tag filters, which are ignored when
+// blank.
+func labelConds(args []any, cond []string, category, tag *string) ([]any, []string) {
+	if category != nil && strings.TrimSpace(*category) != "" {
+		args = append(args, strings.TrimSpace(*category))
+		cond = append(cond, fmt.Sprintf("category = $%d", len(args)))
+	}
+	if tag != nil && strings.TrimSpace(*tag) != "" {
+		args = append(args, strings.TrimSpace(*tag))
+		cond = append(cond, fmt.Sprintf(`exists (
+			select 1
+			from subscription_tags st
+			join tags t on t.id = st.tag_id
+			where st.subscription_id = subscriptions.id and t.name = $%d
+		)`, len(args)))
+	}
+	return args, cond
+}
+
+// lifecycleColumns selects status, trial_end_date, cancelled_at and pauses
+// for lifecycleRow to scan.
+const lifecycleColumns = `status, trial_end_date, cancelled_at, pauses::text`
+
+type lifecycleRow struct {
+	status                string
+	trialEnd, cancelledAt sql.NullTime
+	pauses                string
+}
+
+func (l *lifecycleRow) dest() []any {
+	return []any{&l.status, &l.trialEnd, &l.cancelledAt, &l.pauses}
+}
+
+func (l *lifecycleRow) apply(sub *entity.Subscription) error {
+	sub.Status = entity.Status(l.status)
+	sub.TrialEndDate = utcTime(l.trialEnd)
+	sub.CancelledAt = utcTime(l.cancelledAt)
+	pauses, err := decodePauses(l.pauses)
+	if err != nil {
+		return err
+	}
+	sub.Pauses = pauses
+	return nil
+}
+
+// BilledMonthsSQL is entity.Subscription.BilledMonths for the period from
+// start to end, both date parameters: the subscription's months in the
+// period, past the trial, less those its pauses cover. Months are numbered
+// as in entity.MonthNumber. It is an int, so that sums of price times
+// months stay bigint. The pgx repository shares it, with its own parameter
+// names.
+func BilledMonthsSQL(start, end string) string {
+	month := func(d string) string {
+		return "(extract(year from " + d + ")::int * 12 + extract(month from " + d + ")::int)"
+	}
+	// Pause dates are RFC 3339 text in the JSON.
+	textMonth := func(d string) string {
+		return "(substr(" + d + ", 1, 4)::int * 12 + substr(" + d + ", 6, 2)::int)"
+	}
+	return `(
+		select greatest(0, b.hi - b.lo + 1 - (
+			select coalesce(sum(greatest(0,
+				least(b.hi, coalesce(` + textMonth(`p."end"`) + ` - 1, b.hi)) - greatest(b.lo, ` + textMonth("p.start") + `) + 1
+			)), 0)
+			from jsonb_to_recordset(subscriptions.pauses) as p(start text, "end" text)
+		))::int
+		from (
+			select greatest(` + month("start_date") + `, ` + month("trial_end_date") + `, ` + month(start+"::date") + `) as lo,
+				least(` + month("end_date") + `, ` + month(end+"::date") + `) as hi
+		) b
+	)`
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func utcTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	u := t.Time.UTC()
+	return &u
+}
+
+// encodePauses never returns null, which the column does not accept.
+func encodePauses(pauses []entity.Pause) (string, error) {
+	if len(pauses) == 0 {
+		return "[]", nil
+	}
+	b, err := json.Marshal(pauses)
+	if err != nil {
+		return "", fmt.Errorf("encode pauses: %w", err)
+	}
+	return string(b), nil
 }
 
-func NewSubscriptionRepo(db *sql.DB) *SubscriptionRepo {
-	return &SubscriptionRepo{db: db}
+func decodePauses(s string) ([]entity.Pause, error) {
+	var pauses []entity.Pause
+	if err := json.Unmarshal([]byte(s), &pauses); err != nil {
+		return nil, fmt.Errorf("decode pauses: %w", err)
+	}
+	for i := range pauses {
+		pauses[i].Start = pauses[i].Start.UTC()
+		if end := pauses[i].End; end != nil {
+			u := end.UTC()
+			pauses[i].End = &u
+		}
+	}
+	if len(pauses) == 0 {
+		return nil, nil
+	}
+	return pauses, nil
 }
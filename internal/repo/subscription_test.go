@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/repo/repotest"
+	"restservice/internal/usecase/subscription"
+)
+
+// TestConformance runs against the Postgres database in TEST_DATABASE_DSN,
+// migrating it first, and is skipped when the variable is unset. The suite
+// only adds rows of its own, but use a database that holds nothing else.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repo := repotest.EnsureUsers(NewSubscriptionRepo(db), func(ctx context.Context, id uuid.UUID) error {
+		_, err := db.ExecContext(ctx, `insert into users (id) values ($1) on conflict (id) do nothing`, id)
+		return err
+	})
+	repotest.Run(t, func(*testing.T) subscription.Repository { return repo })
+	repotest.RunUnitOfWork(t, repo, NewUnitOfWork(db))
+}
+
+// TestMarkEnded reports a subscription once its end month is over, and
+// only once.
+func TestMarkEnded(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	userID := uuid.New()
+	if _, err := db.ExecContext(ctx, `insert into users (id) values ($1)`, userID); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	repo := NewSubscriptionRepo(db)
+	end := time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)
+	sub, err := repo.Create(ctx, entity.Subscription{
+		ServiceName: "Netflix",
+		Price:       400,
+		UserID:      userID,
+		StartDate:   end.AddDate(0, -2, 0),
+		EndDate:     &end,
+		Tags:        []string{"family"},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	reported := func(now time.Time) bool {
+		t.Helper()
+		subs, err := repo.MarkEnded(ctx, now, 1000)
+		if err != nil {
+			t.Fatalf("mark ended: %v", err)
+		}
+		for _, s := range subs {
+			if s.ID == sub.ID {
+				if len(s.Tags) != 1 || s.Tags[0] != "family" {
+					t.Fatalf("unexpected reported row: %+v", s)
+				}
+				return true
+			}
+		}
+		return false
+	}
+	if reported(time.Date(2025, time.August, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("reported during its last month")
+	}
+	if !reported(time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("not reported after its last month")
+	}
+	if reported(time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("reported twice")
+	}
+}
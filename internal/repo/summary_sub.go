@@ -11,15 +11,87 @@ import (
 )
 
 func (r *SubscriptionRepo) Sum(ctx context.Context, filter subscription.SummaryFilter) (int, error) {
-	base := `
-		select coalesce(sum(price), 0)
-		from subscriptions
-		where start_date <= $1
-		  and (end_date is null or end_date >= $2)
+	where, args := summaryWhere(filter)
+	q := `
+		select coalesce(sum(price * billed.months), 0)
+		from subscriptions ` + billedJoin + `
+		where ` + where
+
+	var total int
+	err := r.read(ctx, func(db querier) error {
+		err := db.QueryRowContext(ctx, q, args...).Scan(&total)
+		if errors.Is(err, sql.ErrNoRows) {
+			total = 0
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("sum subscriptions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *SubscriptionRepo) SumGroups(ctx context.Context, filter subscription.SummaryFilter, by subscription.GroupBy) ([]subscription.GroupTotal, error) {
+	where, args := summaryWhere(filter)
+	q := `
+		select category, sum(price * billed.months)
+		from subscriptions ` + billedJoin + `
+		where ` + where + `
+		group by 1
+		order by 1
 	`
+	if by == subscription.GroupByTag {
+		q = `
+			select coalesce(t.name, ''), sum(price * billed.months)
+			from subscriptions ` + billedJoin + `
+			left join subscription_tags st on st.subscription_id = subscriptions.id
+			left join tags t on t.id = st.tag_id
+			where ` + where + `
+			group by 1
+			order by 1
+		`
+	}
 
+	var groups []subscription.GroupTotal
+	err := r.read(ctx, func(db querier) error {
+		rows, err := db.QueryContext(ctx, q, args...)
+		if err != nil {
+			return fmt.Errorf("sum subscription groups: %w", err)
+		}
+		defer rows.Close()
+
+		groups = nil
+		for rows.Next() {
+			var g subscription.GroupTotal
+			if err := rows.Scan(&g.Key, &g.Total); err != nil {
+				return fmt.Errorf("scan subscription group: %w", err)
+			}
+			groups = append(groups, g)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// billedJoin gives each subscription billed.months, the months of the
+// period from $2 to $1 it costs its price in.
+var billedJoin = "cross join lateral (select " + BilledMonthsSQL("$2", "$1") + " as months) billed"
+
+// summaryWhere keeps the subscriptions billed for a month of the period,
+// which needs billedJoin. The overlap conditions are implied by
+// billed.months but can use the indexes.
+func summaryWhere(filter subscription.SummaryFilter) (string, []any) {
 	args := []any{filter.EndDate.UTC(), filter.StartDate.UTC()}
-	cond := make([]string, 0, 2)
+	cond := []string{"start_date <= $1", "(end_date is null or end_date >= $2)", "billed.months > 0"}
 
 	if filter.UserID != nil {
 		args = append(args, *filter.UserID)
@@ -29,18 +101,7 @@ func (r *SubscriptionRepo) Sum(ctx context.Context, filter subscription.SummaryF
 		args = append(args, strings.TrimSpace(*filter.ServiceName))
 		cond = append(cond, fmt.Sprintf("service_name = $%d", len(args)))
 	}
+	args, cond = labelConds(args, cond, filter.Category, filter.Tag)
 
-	if len(cond) > 0 {
-		base += " and " + strings.Join(cond, " and ")
-	}
-
-	var total int
-	if err := r.db.QueryRowContext(ctx, base, args...).Scan(&total); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return 0, nil
-		}
-		return 0, fmt.Errorf("sum subscriptions: %w", err)
-	}
-
-	return total, nil
+	return strings.Join(cond, " and "), args
 }
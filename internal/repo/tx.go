@@ -0,0 +1,172 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	maxTxAttempts    = 3
+	txRetryBaseDelay = 10 * time.Millisecond
+
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+	sqlStateForeignKeyViolation  = "23503"
+	sqlStateUniqueViolation      = "23505"
+)
+
+// querier is the subset of *sql.DB and *sql.Tx the repositories use.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// txLevelKey holds the isolation level of the transaction under txKey.
+type txLevelKey struct{}
+
+// Transactor runs functions in a database transaction carried by the
+// context. Repositories built on the same *sql.DB pick the transaction up
+// through conn, so their calls inside fn commit or roll back together.
+type Transactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTx calls fn in a transaction at the database's default isolation
+// level. See WithinTxLevel.
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.WithinTxLevel(ctx, sql.LevelDefault, fn)
+}
+
+// WithinTxLevel calls fn in a transaction at level and commits it if fn
+// returns nil. When the transaction fails with a serialization failure or a
+// deadlock, it is rolled back and fn runs again, up to maxTxAttempts times,
+// so fn must not have side effects outside the transaction.
+//
+// Nested calls join the outer transaction and leave retrying to the
+// outermost call. They fail with ErrTxLevel if they ask for a stricter
+// level than the outer transaction runs at.
+func (t *Transactor) WithinTxLevel(ctx context.Context, level sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		outer, _ := ctx.Value(txLevelKey{}).(sql.IsolationLevel)
+		if err := CheckNestedLevel(outer, level); err != nil {
+			return err
+		}
+		return fn(ctx)
+	}
+
+	return RetryConflicts(ctx, func() error {
+		return t.run(ctx, level, fn)
+	})
+}
+
+// ErrTxLevel is returned when a nested transaction asks for a stricter
+// isolation level than the transaction it joins.
+var ErrTxLevel = errors.New("nested transaction needs a stricter isolation level")
+
+// CheckNestedLevel fails when a call asking for inner joins a transaction
+// running at outer, which would give it weaker guarantees than it needs.
+// The default level is read committed, as in Postgres.
+func CheckNestedLevel(outer, inner sql.IsolationLevel) error {
+	effective := func(l sql.IsolationLevel) sql.IsolationLevel {
+		if l == sql.LevelDefault {
+			return sql.LevelReadCommitted
+		}
+		return l
+	}
+	if effective(inner) > effective(outer) {
+		return fmt.Errorf("%w: %s inside %s", ErrTxLevel, effective(inner), effective(outer))
+	}
+	return nil
+}
+
+// RetryConflicts calls run until it succeeds, fails with an error other
+// than a serialization failure or a deadlock, or has been called
+// maxTxAttempts times. Attempts are spaced by a growing, jittered delay.
+func RetryConflicts(ctx context.Context, run func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt == maxTxAttempts || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(txRetryDelay(attempt)):
+		}
+	}
+}
+
+func (t *Transactor) run(ctx context.Context, level sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	tx, err := t.db.BeginTx(ctx, &sql.TxOptions{Isolation: level})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	ctx = context.WithValue(context.WithValue(ctx, txKey{}, tx), txLevelKey{}, level)
+	if err := fn(ctx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// retryable reports whether err is a conflict with a concurrent transaction
+// that a fresh attempt may not hit.
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Code {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return true
+	}
+	return false
+}
+
+// hasSQLState reports whether err is a Postgres error with code.
+func hasSQLState(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// txRetryDelay grows with each attempt and is jittered so that the
+// transactions that collided do not collide again.
+func txRetryDelay(attempt int) time.Duration {
+	base := txRetryBaseDelay << (attempt - 1)
+	return base/2 + rand.N(base)
+}
+
+// conn returns the transaction from ctx, or db outside of one.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// UnitOfWork commits subscription changes and their outbox events in one
+// transaction.
+type UnitOfWork struct {
+	*Transactor
+	*OutboxRepo
+}
+
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{Transactor: NewTransactor(db), OutboxRepo: NewOutboxRepo(db)}
+}
@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: sqlStateSerializationFailure}, true},
+		{fmt.Errorf("commit tx: %w", &pgconn.PgError{Code: sqlStateDeadlockDetected}), true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{errors.New("connection reset"), false},
+	}
+	for _, tc := range cases {
+		if got := retryable(tc.err); got != tc.want {
+			t.Fatalf("%v: got %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestTxRetryDelay(t *testing.T) {
+	for attempt := 1; attempt < maxTxAttempts; attempt++ {
+		base := txRetryBaseDelay << (attempt - 1)
+		for range 100 {
+			d := txRetryDelay(attempt)
+			if d < base/2 || d >= base*3/2 {
+				t.Fatalf("attempt %d: delay %v outside [%v, %v)", attempt, d, base/2, base*3/2)
+			}
+		}
+	}
+}
+
+func TestCheckNestedLevel(t *testing.T) {
+	cases := []struct {
+		outer, inner sql.IsolationLevel
+		ok           bool
+	}{
+		{sql.LevelRepeatableRead, sql.LevelReadCommitted, true},
+		{sql.LevelRepeatableRead, sql.LevelRepeatableRead, true},
+		{sql.LevelDefault, sql.LevelReadCommitted, true},
+		{sql.LevelReadCommitted, sql.LevelDefault, true},
+		{sql.LevelReadCommitted, sql.LevelRepeatableRead, false},
+		{sql.LevelDefault, sql.LevelSerializable, false},
+	}
+	for _, tc := range cases {
+		err := CheckNestedLevel(tc.outer, tc.inner)
+		if (err == nil) != tc.ok || (err != nil && !errors.Is(err, ErrTxLevel)) {
+			t.Fatalf("%s inside %s: unexpected error %v", tc.inner, tc.outer, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/catalog"
+)
+
+// Update replaces the entry and all of its aliases.
+func (r *CatalogRepo) Update(ctx context.Context, id uuid.UUID, svc entity.Service) (entity.Service, error) {
+	const q = `
+		update services
+		set name = $1,
+			name_key = $2,
+			category = $3,
+			vendor_url = $4,
+			default_price = $5,
+			updated_at = now()
+		where id = $6
+	`
+
+	var updated entity.Service
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		res, err := conn(ctx, r.db).ExecContext(ctx, q,
+			svc.Name, catalog.Key(svc.Name), svc.Category, svc.VendorURL, svc.DefaultPrice, id,
+		)
+		if err != nil {
+			if hasSQLState(err, sqlStateUniqueViolation) {
+				return catalog.ErrConflict
+			}
+			return fmt.Errorf("update service: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("update service rows: %w", err)
+		}
+		if affected == 0 {
+			return catalog.ErrNotFound
+		}
+
+		if _, err := conn(ctx, r.db).ExecContext(ctx, `delete from service_aliases where service_id = $1`, id); err != nil {
+			return fmt.Errorf("delete aliases: %w", err)
+		}
+		if err := r.insertAliases(ctx, id, svc.Aliases); err != nil {
+			return err
+		}
+		updated, err = r.Get(ctx, id)
+		return err
+	})
+	if err != nil {
+		return entity.Service{}, err
+	}
+	return updated, nil
+}
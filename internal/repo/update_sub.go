@@ -13,15 +13,50 @@ import (
 )
 
 func (r *SubscriptionRepo) Update(ctx context.Context, id uuid.UUID, s entity.Subscription) (entity.Subscription, error) {
+	// Links to tags the subscription no longer has are deleted and the
+	// rest are inserted unless present, so the two never touch one row.
 	const q = `
-		update subscriptions
-		set service_name = $1,
-			price = $2,
-			user_id = $3,
-			start_date = $4,
-			end_date = $5
-		where id = $6
-		returning id, service_name, price, user_id, start_date, end_date
+		with sub as (
+			update subscriptions
+			set service_name = $1,
+				price = $2,
+				user_id = $3,
+				start_date = $4,
+				end_date = $5,
+				category = $7,
+				status = coalesce(nullif($9, ''), 'active'),
+				trial_end_date = $10,
+				cancelled_at = $11,
+				pauses = $12::jsonb,
+				updated_at = now(),
+				-- A changed end date may end the subscription at another time.
+				ended_notified_at = case
+					when end_date is distinct from $5 then null
+					else ended_notified_at
+				end
+			where id = $6
+			returning id, service_name, price, user_id, start_date, end_date, updated_at, category,
+				` + lifecycleColumns + `
+		),
+		tag as (
+			insert into tags (name)
+			select unnest(string_to_array($8, ','))
+			on conflict (name) do update set name = excluded.name
+			returning id
+		),
+		unlink as (
+			delete from subscription_tags
+			where subscription_id in (select id from sub)
+			  and tag_id not in (select id from tag)
+		),
+		link as (
+			insert into subscription_tags (subscription_id, tag_id)
+			select sub.id, tag.id from sub, tag
+			on conflict do nothing
+		)
+		select id, service_name, price, user_id, start_date, end_date, updated_at, category,
+			status, trial_end_date, cancelled_at, pauses
+		from sub
 	`
 
 	var endDate sql.NullTime
@@ -29,12 +64,18 @@ func (r *SubscriptionRepo) Update(ctx context.Context, id uuid.UUID, s entity.Su
 		endDate = sql.NullTime{Time: s.EndDate.UTC(), Valid: true}
 	}
 
+	pauses, err := encodePauses(s.Pauses)
+	if err != nil {
+		return entity.Subscription{}, err
+	}
+
 	var (
-		sub entity.Subscription
-		ed  sql.NullTime
+		sub  entity.Subscription
+		ed   sql.NullTime
+		life lifecycleRow
 	)
 
-	err := r.db.QueryRowContext(
+	err = conn(ctx, r.db).QueryRowContext(
 		ctx,
 		q,
 		s.ServiceName,
@@ -43,23 +84,40 @@ func (r *SubscriptionRepo) Update(ctx context.Context, id uuid.UUID, s entity.Su
 		s.StartDate.UTC(),
 		endDate,
 		id,
-	).Scan(
+		s.Category,
+		joinTags(s.Tags),
+		string(s.Status),
+		nullTime(s.TrialEndDate),
+		nullTime(s.CancelledAt),
+		pauses,
+	).Scan(append([]any{
 		&sub.ID,
 		&sub.ServiceName,
 		&sub.Price,
 		&sub.UserID,
 		&sub.StartDate,
 		&ed,
-	)
+		&sub.UpdatedAt,
+		&sub.Category,
+	}, life.dest()...)...)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return entity.Subscription{}, subscription.ErrNotFound
 		}
+		if hasSQLState(err, sqlStateForeignKeyViolation) {
+			return entity.Subscription{}, subscription.ErrUnknownUser
+		}
 		return entity.Subscription{}, fmt.Errorf("update subscription: %w", err)
 	}
 
 	sub.StartDate = sub.StartDate.UTC()
+	sub.UpdatedAt = sub.UpdatedAt.UTC()
+	// The statement cannot read back the links it inserts.
+	sub.Tags = s.Tags
+	if err := life.apply(&sub); err != nil {
+		return entity.Subscription{}, err
+	}
 	if ed.Valid {
 		t := ed.Time.UTC()
 		sub.EndDate = &t
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/user"
+)
+
+func (r *UserRepo) Update(ctx context.Context, id uuid.UUID, u entity.User) (entity.User, error) {
+	const q = `
+		update users
+		set name = $1,
+			email = $2,
+			timezone = $3,
+			currency = $4,
+			updated_at = now()
+		where id = $5
+		returning ` + userColumns
+
+	updated, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, q, u.Name, nullEmail(u.Email), u.Timezone, u.Currency, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, user.ErrNotFound
+		}
+		if hasSQLState(err, sqlStateUniqueViolation) {
+			return entity.User{}, user.ErrEmailTaken
+		}
+		return entity.User{}, fmt.Errorf("update user: %w", err)
+	}
+	return updated, nil
+}
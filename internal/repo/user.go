@@ -0,0 +1,33 @@
+package repo
+
+import (
+	"database/sql"
+
+	"restservice/internal/entity"
+)
+
+type UserRepo struct {
+	db *sql.DB
+}
+
+func NewUserRepo(db *sql.DB) *UserRepo {
+	return &UserRepo{db: db}
+}
+
+const userColumns = `id, name, coalesce(email, ''), timezone, currency, created_at, updated_at`
+
+func scanUser(row rowScanner) (entity.User, error) {
+	var u entity.User
+	if err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Timezone, &u.Currency, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		return entity.User{}, err
+	}
+	u.CreatedAt = u.CreatedAt.UTC()
+	u.UpdatedAt = u.UpdatedAt.UTC()
+	return u, nil
+}
+
+// nullEmail stores a missing email as null, so the unique index only
+// covers the users that have one.
+func nullEmail(email string) sql.NullString {
+	return sql.NullString{String: email, Valid: email != ""}
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"database/sql"
+	"strings"
+)
+
+type WebhookRepo struct {
+	db *sql.DB
+}
+
+func NewWebhookRepo(db *sql.DB) *WebhookRepo {
+	return &WebhookRepo{db: db}
+}
+
+// Event lists are passed as comma-separated text and converted with
+// string_to_array and array_to_string, which avoids driver-specific array
+// scanning. Event names never contain commas.
+func joinEvents(events []string) string {
+	return strings.Join(events, ",")
+}
+
+func splitEvents(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
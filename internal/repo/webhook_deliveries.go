@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	"restservice/internal/usecase/webhook"
+)
+
+const deliveryColumns = `
+	d.id, d.webhook_id, d.event_id, e.event_type, d.status, d.attempts,
+	d.next_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at
+`
+
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, webhookID uuid.UUID, filter webhook.DeliveryFilter) ([]entity.WebhookDelivery, error) {
+	q := `select ` + deliveryColumns + `
+		from webhook_deliveries d
+		join outbox e on e.id = d.event_id
+		where d.webhook_id = $1
+	`
+	args := []any{webhookID}
+	if filter.Status != "" {
+		args = append(args, string(filter.Status))
+		q += fmt.Sprintf(" and d.status = $%d", len(args))
+	}
+	q += " order by d.id desc"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		q += fmt.Sprintf(" limit $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var items []entity.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list webhook deliveries rows: %w", err)
+	}
+
+	return items, nil
+}
+
+func (r *WebhookRepo) Redeliver(ctx context.Context, webhookID uuid.UUID, deliveryID int64) (entity.WebhookDelivery, error) {
+	q := `
+		with requeued as (
+			update webhook_deliveries
+			set status = 'pending', attempts = 0, next_attempt_at = now()
+			where id = $1 and webhook_id = $2
+			returning *
+		)
+		select ` + deliveryColumns + `
+		from requeued d
+		join outbox e on e.id = d.event_id
+	`
+
+	d, err := scanDelivery(conn(ctx, r.db).QueryRowContext(ctx, q, deliveryID, webhookID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.WebhookDelivery{}, webhook.ErrNotFound
+		}
+		return entity.WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDelivery(row rowScanner) (entity.WebhookDelivery, error) {
+	var (
+		d           entity.WebhookDelivery
+		status      string
+		statusCode  sql.NullInt64
+		lastError   sql.NullString
+		deliveredAt sql.NullTime
+	)
+	if err := row.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.EventType,
+		&status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&statusCode,
+		&lastError,
+		&deliveredAt,
+		&d.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.WebhookDelivery{}, err
+		}
+		return entity.WebhookDelivery{}, fmt.Errorf("scan webhook delivery: %w", err)
+	}
+
+	d.Status = entity.DeliveryStatus(status)
+	d.LastStatusCode = int(statusCode.Int64)
+	d.LastError = lastError.String
+	d.NextAttemptAt = d.NextAttemptAt.UTC()
+	d.CreatedAt = d.CreatedAt.UTC()
+	if deliveredAt.Valid {
+		t := deliveredAt.Time.UTC()
+		d.DeliveredAt = &t
+	}
+	return d, nil
+}
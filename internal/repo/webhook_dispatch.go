@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"restservice/internal/usecase/webhook"
+)
+
+func (r *WebhookRepo) FanOut(ctx context.Context, limit int) (int, error) {
+	const q = `
+		with events as (
+			select id, event_type
+			from outbox
+			where dispatched_at is null
+			order by id
+			limit $1
+			for update skip locked
+		), queued as (
+			insert into webhook_deliveries (webhook_id, event_id)
+			select w.id, e.id
+			from events e
+			join webhooks w on e.event_type = any(w.events)
+			on conflict (webhook_id, event_id) do nothing
+		)
+		update outbox
+		set dispatched_at = now()
+		where id in (select id from events)
+	`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, q, limit)
+	if err != nil {
+		return 0, fmt.Errorf("fan out outbox: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (r *WebhookRepo) Claim(ctx context.Context, limit int, lease time.Duration) ([]webhook.Job, error) {
+	const q = `
+		with claimed as (
+			update webhook_deliveries
+			set attempts = attempts + 1,
+				next_attempt_at = now() + $2::float8 * interval '1 millisecond'
+			where id in (
+				select id from webhook_deliveries
+				where status = 'pending' and next_attempt_at <= now()
+				order by next_attempt_at, id
+				limit $1
+				for update skip locked
+			)
+			returning id, webhook_id, event_id, attempts
+		)
+		select c.id, c.attempts, w.id, w.url, w.secret,
+			e.id, e.event_type, e.subscription_id, e.payload, e.created_at
+		from claimed c
+		join webhooks w on w.id = c.webhook_id
+		join outbox e on e.id = c.event_id
+		order by c.id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, q, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []webhook.Job
+	for rows.Next() {
+		var (
+			job     webhook.Job
+			payload []byte
+		)
+		if err := rows.Scan(
+			&job.DeliveryID,
+			&job.Attempt,
+			&job.WebhookID,
+			&job.URL,
+			&job.Secret,
+			&job.Event.ID,
+			&job.Event.Type,
+			&job.Event.SubscriptionID,
+			&payload,
+			&job.Event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan webhook job: %w", err)
+		}
+		job.Event.Payload = payload
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries rows: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (r *WebhookRepo) MarkDelivered(ctx context.Context, deliveryID int64, statusCode int) error {
+	const q = `
+		update webhook_deliveries
+		set status = 'delivered', delivered_at = now(),
+			last_status_code = $2, last_error = null
+		where id = $1
+	`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, q, deliveryID, statusCode); err != nil {
+		return fmt.Errorf("mark webhook delivered: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepo) MarkFailed(ctx context.Context, deliveryID int64, statusCode int, reason string, next time.Time, dead bool) error {
+	const q = `
+		update webhook_deliveries
+		set status = case when $5::boolean then 'dead' else 'pending' end,
+			last_status_code = $2, last_error = $3, next_attempt_at = $4
+		where id = $1
+	`
+
+	code := sql.NullInt64{Int64: int64(statusCode), Valid: statusCode != 0}
+	if _, err := conn(ctx, r.db).ExecContext(ctx, q, deliveryID, code, reason, next.UTC(), dead); err != nil {
+		return fmt.Errorf("mark webhook failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+var (
+	ErrNotFound   = errors.New("service not found")
+	ErrValidation = errors.New("validation error")
+	// ErrConflict means the name or an alias is already used by another
+	// catalog entry.
+	ErrConflict = errors.New("service name or alias already in use")
+)
+
+type Repository interface {
+	Create(ctx context.Context, svc entity.Service) (entity.Service, error)
+	Get(ctx context.Context, id uuid.UUID) (entity.Service, error)
+	List(ctx context.Context, filter ListFilter) ([]entity.Service, error)
+	Update(ctx context.Context, id uuid.UUID, svc entity.Service) (entity.Service, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Resolve finds the entry for a name already passed through Key:
+	// canonical names and exact aliases first, then the longest prefix
+	// alias. It returns ErrNotFound when nothing matches.
+	Resolve(ctx context.Context, key string) (entity.Service, error)
+	// SubscriptionServiceNames lists the distinct service names stored on
+	// subscriptions with the number of subscriptions for each.
+	SubscriptionServiceNames(ctx context.Context) ([]NameCount, error)
+}
+
+// Renamer renames stored subscriptions. subscription.Service implements
+// it, so that the renames commit together and raise subscription events.
+type Renamer interface {
+	// RenameServices gives every subscription named by a key of names the
+	// name it maps to and returns how many were renamed from each.
+	RenameServices(ctx context.Context, names map[string]string) (map[string]int64, error)
+}
+
+type ListFilter struct {
+	// Category limits the result to one category; empty means any.
+	Category string
+}
+
+type NameCount struct {
+	Name          string
+	Subscriptions int64
+}
+
+// Rename is one service name that Normalize maps to a catalog entry.
+type Rename struct {
+	From          string
+	To            string
+	Subscriptions int64
+}
+
+type Service struct {
+	repository Repository
+	logger     *slog.Logger
+}
+
+func NewService(repository Repository, logger *slog.Logger) *Service {
+	return &Service{repository: repository, logger: logger}
+}
+
+// Key is the form names and patterns are matched in: lowercased, trimmed
+// and with runs of whitespace collapsed to one space.
+func Key(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func Validate(svc entity.Service) error {
+	if strings.TrimSpace(svc.Name) == "" {
+		return errors.New("name is required")
+	}
+	if svc.VendorURL != "" {
+		u, err := url.Parse(svc.VendorURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("vendor url must be an absolute http or https url")
+		}
+	}
+	if svc.DefaultPrice < 0 {
+		return errors.New("default price must not be negative")
+	}
+	for _, alias := range svc.Aliases {
+		if alias.Pattern == "" {
+			return errors.New("alias pattern is required")
+		}
+		if alias.Match != entity.AliasExact && alias.Match != entity.AliasPrefix {
+			return fmt.Errorf("unknown alias match %q", alias.Match)
+		}
+	}
+	return nil
+}
+
+// normalize trims the fields, brings alias patterns to their Key form and
+// drops duplicate aliases.
+func normalize(svc entity.Service) entity.Service {
+	svc.Name = strings.Join(strings.Fields(svc.Name), " ")
+	svc.Category = strings.TrimSpace(svc.Category)
+	svc.VendorURL = strings.TrimSpace(svc.VendorURL)
+
+	aliases := make([]entity.ServiceAlias, 0, len(svc.Aliases))
+	for _, alias := range svc.Aliases {
+		alias.Pattern = Key(alias.Pattern)
+		if alias.Match == "" {
+			alias.Match = entity.AliasExact
+		}
+		// The canonical name always matches exactly.
+		if alias.Match == entity.AliasExact && alias.Pattern == Key(svc.Name) {
+			continue
+		}
+		if !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	svc.Aliases = aliases
+	return svc
+}
+
+func (s *Service) Create(ctx context.Context, svc entity.Service) (entity.Service, error) {
+	svc = normalize(svc)
+	if err := Validate(svc); err != nil {
+		s.logger.Info("service validation failed", "error", err)
+		return entity.Service{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+
+	created, err := s.repository.Create(ctx, svc)
+	if err != nil {
+		if errors.Is(err, ErrConflict) {
+			return entity.Service{}, ErrConflict
+		}
+		s.logger.Error("service create failed", "error", err)
+		return entity.Service{}, fmt.Errorf("create service: %w", err)
+	}
+
+	s.logger.Info("service created", "service_id", created.ID, "name", created.Name)
+	return created, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (entity.Service, error) {
+	svc, err := s.repository.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return entity.Service{}, ErrNotFound
+		}
+		s.logger.Error("service get failed", "error", err, "service_id", id)
+		return entity.Service{}, fmt.Errorf("get service: %w", err)
+	}
+	return svc, nil
+}
+
+func (s *Service) List(ctx context.Context, filter ListFilter) ([]entity.Service, error) {
+	filter.Category = strings.TrimSpace(filter.Category)
+	items, err := s.repository.List(ctx, filter)
+	if err != nil {
+		s.logger.Error("service list failed", "error", err)
+		return nil, fmt.Errorf("list services: %w", err)
+	}
+	return items, nil
+}
+
+// Update replaces a catalog entry, aliases included. Subscriptions that
+// carry the old canonical name keep it until Normalize runs.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, svc entity.Service) (entity.Service, error) {
+	svc = normalize(svc)
+	if err := Validate(svc); err != nil {
+		s.logger.Info("service validation failed", "error", err)
+		return entity.Service{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+
+	updated, err := s.repository.Update(ctx, id, svc)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) {
+			return entity.Service{}, err
+		}
+		s.logger.Error("service update failed", "error", err, "service_id", id)
+		return entity.Service{}, fmt.Errorf("update service: %w", err)
+	}
+
+	s.logger.Info("service updated", "service_id", id)
+	return updated, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrNotFound
+		}
+		s.logger.Error("service delete failed", "error", err, "service_id", id)
+		return fmt.Errorf("delete service: %w", err)
+	}
+
+	s.logger.Info("service deleted", "service_id", id)
+	return nil
+}
+
+// ResolveService implements subscription.ServiceResolver.
+func (s *Service) ResolveService(ctx context.Context, name string) (entity.Service, bool, error) {
+	key := Key(name)
+	if key == "" {
+		return entity.Service{}, false, nil
+	}
+	svc, err := s.repository.Resolve(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return entity.Service{}, false, nil
+		}
+		return entity.Service{}, false, fmt.Errorf("resolve service %q: %w", name, err)
+	}
+	return svc, true, nil
+}
+
+// Normalize renames stored subscriptions to the canonical name their
+// service name resolves to, all at once through renamer. With dryRun it
+// only reports what it would change.
+func (s *Service) Normalize(ctx context.Context, renamer Renamer, dryRun bool) ([]Rename, error) {
+	names, err := s.repository.SubscriptionServiceNames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list subscription service names: %w", err)
+	}
+
+	var renames []Rename
+	for _, name := range names {
+		svc, ok, err := s.ResolveService(ctx, name.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || svc.Name == name.Name {
+			continue
+		}
+		renames = append(renames, Rename{From: name.Name, To: svc.Name, Subscriptions: name.Subscriptions})
+	}
+	if dryRun || len(renames) == 0 {
+		return renames, nil
+	}
+
+	to := make(map[string]string, len(renames))
+	for _, r := range renames {
+		to[r.From] = r.To
+	}
+	counts, err := renamer.RenameServices(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range renames {
+		renames[i].Subscriptions = counts[renames[i].From]
+	}
+	return renames, nil
+}
@@ -0,0 +1,141 @@
+package catalog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"restservice/internal/entity"
+)
+
+// stubRepo resolves keys with the same rules as the Postgres query, over a
+// fixed catalog, and records renames.
+type stubRepo struct {
+	Repository
+	services []entity.Service
+	names    []NameCount
+	renamed  map[string]string
+	calls    int
+}
+
+func (r *stubRepo) Resolve(_ context.Context, key string) (entity.Service, error) {
+	var (
+		best   entity.Service
+		length = -1
+	)
+	for _, svc := range r.services {
+		if Key(svc.Name) == key {
+			return svc, nil
+		}
+		for _, a := range svc.Aliases {
+			switch {
+			case a.Match == entity.AliasExact && a.Pattern == key:
+				return svc, nil
+			case a.Match == entity.AliasPrefix && (key == a.Pattern || strings.HasPrefix(key, a.Pattern+" ")) && len(a.Pattern) > length:
+				best, length = svc, len(a.Pattern)
+			}
+		}
+	}
+	if length < 0 {
+		return entity.Service{}, ErrNotFound
+	}
+	return best, nil
+}
+
+func (r *stubRepo) SubscriptionServiceNames(context.Context) ([]NameCount, error) {
+	return r.names, nil
+}
+
+// RenameServices makes stubRepo its own Renamer; calls counts how often
+// it was called.
+func (r *stubRepo) RenameServices(_ context.Context, names map[string]string) (map[string]int64, error) {
+	r.calls++
+	counts := make(map[string]int64)
+	for from, to := range names {
+		r.renamed[from] = to
+		for _, n := range r.names {
+			if n.Name == from {
+				counts[from] = n.Subscriptions
+			}
+		}
+	}
+	return counts, nil
+}
+
+func TestNormalize(t *testing.T) {
+	repo := &stubRepo{
+		services: []entity.Service{
+			{Name: "Netflix", Aliases: []entity.ServiceAlias{{Pattern: "netflix", Match: entity.AliasPrefix}}},
+			{Name: "Yandex Plus", Aliases: []entity.ServiceAlias{
+				{Pattern: "яндекс плюс", Match: entity.AliasExact},
+				{Pattern: "yandex", Match: entity.AliasPrefix},
+			}},
+		},
+		names: []NameCount{
+			{Name: "NETFLIX Premium", Subscriptions: 3},
+			{Name: "Netflix", Subscriptions: 10},
+			{Name: "Netflixer", Subscriptions: 1},
+			{Name: "netflix ", Subscriptions: 2},
+			{Name: "Яндекс  Плюс", Subscriptions: 4},
+		},
+		renamed: map[string]string{},
+	}
+	service := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	planned, err := service.Normalize(context.Background(), repo, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(repo.renamed) != 0 {
+		t.Fatalf("dry run renamed rows: %v", repo.renamed)
+	}
+
+	done, err := service.Normalize(context.Background(), repo, false)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if repo.calls != 1 {
+		t.Fatalf("renames must be made in one call, got %d", repo.calls)
+	}
+	want := map[string]string{"NETFLIX Premium": "Netflix", "netflix ": "Netflix", "Яндекс  Плюс": "Yandex Plus"}
+	if len(repo.renamed) != len(want) {
+		t.Fatalf("unexpected renames: %v", repo.renamed)
+	}
+	for from, to := range want {
+		if repo.renamed[from] != to {
+			t.Fatalf("expected %q to become %q, got %v", from, to, repo.renamed)
+		}
+	}
+	if len(planned) != len(done) {
+		t.Fatalf("dry run planned %d renames, normalize did %d", len(planned), len(done))
+	}
+	for i := range planned {
+		if planned[i] != done[i] {
+			t.Fatalf("dry run %+v differs from %+v", planned[i], done[i])
+		}
+	}
+}
+
+func TestNormalizeAliases(t *testing.T) {
+	svc := normalize(entity.Service{
+		Name: "  Yandex   Plus ",
+		Aliases: []entity.ServiceAlias{
+			{Pattern: "Yandex plus"},
+			{Pattern: " YANDEX ", Match: entity.AliasPrefix},
+			{Pattern: "yandex", Match: entity.AliasPrefix},
+			{Pattern: "Яндекс Плюс"},
+		},
+	})
+	if svc.Name != "Yandex Plus" {
+		t.Fatalf("unexpected name %q", svc.Name)
+	}
+	want := []entity.ServiceAlias{
+		{Pattern: "yandex", Match: entity.AliasPrefix},
+		{Pattern: "яндекс плюс", Match: entity.AliasExact},
+	}
+	if len(svc.Aliases) != len(want) || svc.Aliases[0] != want[0] || svc.Aliases[1] != want[1] {
+		t.Fatalf("unexpected aliases %+v", svc.Aliases)
+	}
+}
@@ -0,0 +1,151 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"restservice/internal/entity"
+)
+
+// ServiceResolver maps a free-text service name to its catalog entry.
+// ok is false for names the catalog does not know.
+type ServiceResolver interface {
+	ResolveService(ctx context.Context, name string) (svc entity.Service, ok bool, err error)
+}
+
+// WithServiceResolver makes the service store canonical names: incoming
+// service names, and the service_name filters of List and Sum, are
+// replaced by the catalog entry they resolve to. Unknown names are kept
+// as given.
+func WithServiceResolver(r ServiceResolver) Option {
+	return func(s *Service) { s.resolver = r }
+}
+
+// resolveService canonicalizes sub's service name and fills in the
+// catalog's default price and category when sub has none.
+func (s *Service) resolveService(ctx context.Context, sub entity.Subscription) (entity.Subscription, error) {
+	if s.resolver == nil || strings.TrimSpace(sub.ServiceName) == "" {
+		return sub, nil
+	}
+	svc, ok, err := s.resolver.ResolveService(ctx, sub.ServiceName)
+	if err != nil {
+		return entity.Subscription{}, fmt.Errorf("resolve service name: %w", err)
+	}
+	if !ok {
+		sub.ServiceName = strings.TrimSpace(sub.ServiceName)
+		return sub, nil
+	}
+	sub.ServiceName = svc.Name
+	if sub.Price == 0 {
+		sub.Price = svc.DefaultPrice
+	}
+	if strings.TrimSpace(sub.Category) == "" {
+		sub.Category = svc.Category
+	}
+	return sub, nil
+}
+
+// resolveFilterName returns the canonical form of a service_name filter.
+func (s *Service) resolveFilterName(ctx context.Context, name *string) (*string, error) {
+	if s.resolver == nil || name == nil || strings.TrimSpace(*name) == "" {
+		return name, nil
+	}
+	svc, ok, err := s.resolver.ResolveService(ctx, *name)
+	if err != nil {
+		return nil, fmt.Errorf("resolve service name: %w", err)
+	}
+	if !ok {
+		return name, nil
+	}
+	return &svc.Name, nil
+}
+
+// renamePageSize is how many subscriptions RenameServices reads at a time.
+const renamePageSize = 1000
+
+// RenameServices gives every subscription whose service name is a key of
+// names the name it maps to, and returns how many were renamed from each.
+// Names are compared as stored, surrounding whitespace included. All
+// renames are one unit of work that raises SubscriptionUpdated for each
+// renamed subscription.
+func (s *Service) RenameServices(ctx context.Context, names map[string]string) (map[string]int64, error) {
+	var counts map[string]int64
+	err := s.commit(ctx, sql.LevelRepeatableRead, func(ctx context.Context) ([]Event, error) {
+		counts = make(map[string]int64)
+		var events []Event
+		rename := func(before entity.Subscription) error {
+			to, ok := names[before.ServiceName]
+			if !ok || to == before.ServiceName {
+				return nil
+			}
+			renamed := before
+			renamed.ServiceName = to
+			after, err := s.repository.Update(Replacing(ctx, before), before.ID, renamed)
+			if err != nil {
+				return fmt.Errorf("rename subscription %s: %w", before.ID, err)
+			}
+			events = append(events, updateEvents(before, after)...)
+			counts[before.ServiceName]++
+			return nil
+		}
+
+		// The service name filter is trimmed, so it finds names stored
+		// without surrounding whitespace only; the rest are looked for in
+		// one pass over every subscription.
+		scan := false
+		for from, to := range names {
+			if from != strings.TrimSpace(from) || from == "" {
+				scan = true
+				continue
+			}
+			if from == to {
+				continue
+			}
+			// Renamed rows leave the filter, so the first page is always
+			// the next one.
+			for {
+				page, err := s.repository.List(ctx, ListFilter{ServiceName: &from, Limit: renamePageSize})
+				if err != nil {
+					return nil, err
+				}
+				done := counts[from]
+				for _, before := range page {
+					if err := rename(before); err != nil {
+						return nil, err
+					}
+				}
+				if len(page) < renamePageSize || counts[from] == done {
+					break
+				}
+			}
+		}
+		if !scan {
+			return events, nil
+		}
+		// Renaming keeps the order of the list, so the pages stay stable.
+		for offset := 0; ; offset += renamePageSize {
+			page, err := s.repository.List(ctx, ListFilter{Limit: renamePageSize, Offset: offset})
+			if err != nil {
+				return nil, err
+			}
+			for _, before := range page {
+				if err := rename(before); err != nil {
+					return nil, err
+				}
+			}
+			if len(page) < renamePageSize {
+				return events, nil
+			}
+		}
+	})
+	if err != nil {
+		s.logger.Error("service rename failed", "error", err)
+		return nil, fmt.Errorf("rename services: %w", err)
+	}
+	for from, n := range counts {
+		s.logger.Info("subscriptions renamed", "from", from, "to", names[from], "count", n)
+	}
+	return counts, nil
+}
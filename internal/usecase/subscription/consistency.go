@@ -0,0 +1,89 @@
+package subscription
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+type primaryReadsKey struct{}
+
+// WithPrimaryReads marks ctx so that repositories with read replicas serve
+// its reads from the primary. Replicas may lag behind, so a client that
+// has just written reads its own writes only from the primary.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// PrimaryReads reports whether ctx asks for reads from the primary.
+func PrimaryReads(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return v
+}
+
+type replacingKey struct{}
+
+// Replacing tells the repository that the update or delete made with ctx
+// replaces before, the row the caller has just read, so that decorators
+// such as a cache need not read it again.
+func Replacing(ctx context.Context, before entity.Subscription) context.Context {
+	return context.WithValue(ctx, replacingKey{}, before)
+}
+
+// Replaced returns the row Replacing recorded in ctx for id.
+func Replaced(ctx context.Context, id uuid.UUID) (entity.Subscription, bool) {
+	before, ok := ctx.Value(replacingKey{}).(entity.Subscription)
+	if !ok || before.ID != id {
+		return entity.Subscription{}, false
+	}
+	return before, true
+}
+
+type unitOfWorkKey struct{}
+
+// unitOfWork holds what a unit of work runs once it has committed.
+type unitOfWork struct {
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// InUnitOfWork reports whether ctx belongs to a unit of work that has not
+// committed yet. Its reads may see its own uncommitted changes, so caches
+// must neither serve them nor store what they return.
+func InUnitOfWork(ctx context.Context) bool {
+	_, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWork)
+	return ok
+}
+
+// AfterCommit runs fn once the unit of work ctx belongs to has committed,
+// and not at all if it fails. Outside of a unit of work fn runs at once.
+func AfterCommit(ctx context.Context, fn func()) {
+	u, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWork)
+	if !ok {
+		fn()
+		return
+	}
+	u.mu.Lock()
+	u.afterCommit = append(u.afterCommit, fn)
+	u.mu.Unlock()
+}
+
+// reset forgets what an attempt registered before it is retried.
+func (u *unitOfWork) reset() {
+	u.mu.Lock()
+	u.afterCommit = nil
+	u.mu.Unlock()
+}
+
+func (u *unitOfWork) committed() {
+	u.mu.Lock()
+	hooks := u.afterCommit
+	u.afterCommit = nil
+	u.mu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+}
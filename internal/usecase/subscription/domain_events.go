@@ -0,0 +1,81 @@
+package subscription
+
+import (
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+// Event is a domain event raised by a change to a subscription.
+type Event interface {
+	// EventType is one of the entity.EventSubscription* constants.
+	EventType() string
+	SubscriptionID() uuid.UUID
+	// Payload is encoded as the outbox, webhook and stream data.
+	Payload() any
+}
+
+type SubscriptionCreated struct {
+	Subscription entity.Subscription
+}
+
+func (e SubscriptionCreated) EventType() string         { return entity.EventSubscriptionCreated }
+func (e SubscriptionCreated) SubscriptionID() uuid.UUID { return e.Subscription.ID }
+func (e SubscriptionCreated) Payload() any              { return NewEventData(e.Subscription) }
+
+// SubscriptionUpdated carries the row before and after the update.
+type SubscriptionUpdated struct {
+	Before entity.Subscription
+	After  entity.Subscription
+}
+
+func (e SubscriptionUpdated) EventType() string         { return entity.EventSubscriptionUpdated }
+func (e SubscriptionUpdated) SubscriptionID() uuid.UUID { return e.After.ID }
+func (e SubscriptionUpdated) Payload() any              { return NewEventData(e.After) }
+
+// PriceChanged is raised together with SubscriptionUpdated when an update
+// changes the price.
+type PriceChanged struct {
+	Subscription entity.Subscription
+	OldPrice     int
+}
+
+// PriceChangedData is the payload of PriceChanged: the updated row plus the
+// previous price.
+type PriceChangedData struct {
+	EventData
+	OldPrice int `json:"old_price"`
+}
+
+func (e PriceChanged) EventType() string         { return entity.EventSubscriptionPriceChanged }
+func (e PriceChanged) SubscriptionID() uuid.UUID { return e.Subscription.ID }
+func (e PriceChanged) Payload() any {
+	return PriceChangedData{EventData: NewEventData(e.Subscription), OldPrice: e.OldPrice}
+}
+
+type SubscriptionDeleted struct {
+	Subscription entity.Subscription
+}
+
+func (e SubscriptionDeleted) EventType() string         { return entity.EventSubscriptionDeleted }
+func (e SubscriptionDeleted) SubscriptionID() uuid.UUID { return e.Subscription.ID }
+func (e SubscriptionDeleted) Payload() any              { return NewEventData(e.Subscription) }
+
+// SubscriptionEnded is raised by ReportEnded once the last paid month of a
+// subscription is over.
+type SubscriptionEnded struct {
+	Subscription entity.Subscription
+}
+
+func (e SubscriptionEnded) EventType() string         { return entity.EventSubscriptionEnded }
+func (e SubscriptionEnded) SubscriptionID() uuid.UUID { return e.Subscription.ID }
+func (e SubscriptionEnded) Payload() any              { return NewEventData(e.Subscription) }
+
+// updateEvents returns the events an update from before to after raises.
+func updateEvents(before, after entity.Subscription) []Event {
+	events := []Event{SubscriptionUpdated{Before: before, After: after}}
+	if before.Price != after.Price {
+		events = append(events, PriceChanged{Subscription: after, OldPrice: before.Price})
+	}
+	return events
+}
@@ -0,0 +1,55 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"restservice/internal/entity"
+)
+
+// endedBatchSize bounds how many subscriptions one ReportEnded call
+// reports.
+const endedBatchSize = 1000
+
+// EndedRepository is implemented by repositories that record which
+// subscriptions have been reported as ended.
+type EndedRepository interface {
+	// MarkEnded marks up to limit subscriptions whose end month is over
+	// as of now as reported and returns them.
+	MarkEnded(ctx context.Context, now time.Time, limit int) ([]entity.Subscription, error)
+}
+
+// ReportEnded raises SubscriptionEnded for subscriptions whose last month
+// is over as of now and that were not reported yet, and returns how many
+// it reported. The mark and the events commit together, so each
+// subscription is reported once, and the events reach the outbox and the
+// publisher like any other. The repository must implement
+// EndedRepository.
+func (s *Service) ReportEnded(ctx context.Context, now time.Time) (int, error) {
+	ended, ok := s.repository.(EndedRepository)
+	if !ok {
+		return 0, errors.New("report ended subscriptions: the repository does not record reports")
+	}
+
+	var n int
+	err := s.commit(ctx, sql.LevelReadCommitted, func(ctx context.Context) ([]Event, error) {
+		subs, err := ended.MarkEnded(ctx, now, endedBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		events := make([]Event, 0, len(subs))
+		for _, sub := range subs {
+			events = append(events, SubscriptionEnded{Subscription: sub})
+		}
+		n = len(events)
+		return events, nil
+	})
+	if err != nil {
+		s.logger.Error("report ended subscriptions failed", "error", err)
+		return 0, fmt.Errorf("report ended subscriptions: %w", err)
+	}
+	return n, nil
+}
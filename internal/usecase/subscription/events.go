@@ -0,0 +1,139 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+// UnitOfWork makes a change and the events it raises atomic. Repository
+// calls made with the context passed to fn join one transaction at level,
+// and Append writes an event to the outbox within it. fn may run more than
+// once if the transaction conflicts with another and is retried.
+type UnitOfWork interface {
+	WithinTxLevel(ctx context.Context, level sql.IsolationLevel, fn func(ctx context.Context) error) error
+	Append(ctx context.Context, event entity.OutboxEvent) error
+}
+
+// Publisher receives domain events once their transaction has committed.
+// Publishing is best effort: failures are logged, and the outbox remains
+// the durable record.
+type Publisher interface {
+	Publish(ctx context.Context, events []Event) error
+}
+
+type Option func(*Service)
+
+// WithUnitOfWork makes every create, update and delete commit its events to
+// the outbox together with the row change.
+func WithUnitOfWork(uow UnitOfWork) Option {
+	return func(s *Service) { s.uow = uow }
+}
+
+// WithPublisher hands committed events to p.
+func WithPublisher(p Publisher) Option {
+	return func(s *Service) { s.publisher = p }
+}
+
+// EventData is the JSON payload of subscription events. Dates use
+// YYYY-MM-DD and the price is in whole rubles.
+type EventData struct {
+	ID          uuid.UUID `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Price       int       `json:"price"`
+	UserID      uuid.UUID `json:"user_id"`
+	StartDate   string    `json:"start_date"`
+	EndDate     *string   `json:"end_date"`
+}
+
+const eventDateLayout = time.DateOnly
+
+func NewEventData(sub entity.Subscription) EventData {
+	data := EventData{
+		ID:          sub.ID,
+		ServiceName: sub.ServiceName,
+		Price:       sub.Price,
+		UserID:      sub.UserID,
+		StartDate:   sub.StartDate.UTC().Format(eventDateLayout),
+	}
+	if sub.EndDate != nil {
+		end := sub.EndDate.UTC().Format(eventDateLayout)
+		data.EndDate = &end
+	}
+	return data
+}
+
+// commit runs fn, which makes a change and returns the events it raised,
+// in a unit of work at level, appends the events to the outbox and, after
+// the commit, runs the AfterCommit hooks and publishes the events. A
+// nested call joins the outer unit of work, whose commit runs its hooks.
+func (s *Service) commit(ctx context.Context, level sql.IsolationLevel, fn func(ctx context.Context) ([]Event, error)) error {
+	u, nested := ctx.Value(unitOfWorkKey{}).(*unitOfWork)
+	if !nested {
+		u = &unitOfWork{}
+		ctx = context.WithValue(ctx, unitOfWorkKey{}, u)
+	}
+
+	var events []Event
+	run := func(ctx context.Context) error {
+		if !nested {
+			u.reset()
+		}
+		var err error
+		if events, err = fn(ctx); err != nil {
+			return err
+		}
+		if s.uow == nil {
+			return nil
+		}
+		for _, e := range events {
+			if err := s.append(ctx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if s.uow == nil {
+		err = run(ctx)
+	} else {
+		err = s.uow.WithinTxLevel(ctx, level, run)
+	}
+	// Without a unit of work nothing is rolled back, so the hooks of the
+	// changes made before a failure still run.
+	if !nested && (err == nil || s.uow == nil) {
+		u.committed()
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.publisher != nil {
+		if err := s.publisher.Publish(ctx, events); err != nil {
+			s.logger.Error("publish events failed", "error", err, "count", len(events))
+		}
+	}
+	return nil
+}
+
+func (s *Service) append(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e.Payload())
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", e.EventType(), err)
+	}
+	if err := s.uow.Append(ctx, entity.OutboxEvent{
+		Type:           e.EventType(),
+		SubscriptionID: e.SubscriptionID(),
+		Payload:        payload,
+	}); err != nil {
+		return fmt.Errorf("record %s event: %w", e.EventType(), err)
+	}
+	return nil
+}
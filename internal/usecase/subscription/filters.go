@@ -9,11 +9,42 @@ import (
 type ListFilter struct {
 	UserID      *uuid.UUID
 	ServiceName *string
+	// Category and Tag match exactly; like ServiceName they are trimmed
+	// and ignored when blank.
+	Category *string
+	Tag      *string
+	// Limit caps the number of returned items; zero means no limit.
+	Limit  int
+	Offset int
 }
 
 type SummaryFilter struct {
 	UserID      *uuid.UUID
 	ServiceName *string
+	Category    *string
+	Tag         *string
 	StartDate   time.Time
 	EndDate     time.Time
 }
+
+// StreamFilter narrows the change stream; nil fields match everything.
+type StreamFilter struct {
+	UserID      *uuid.UUID
+	ServiceName *string
+}
+
+// GroupBy selects how SumGroups splits the total.
+type GroupBy string
+
+const (
+	GroupByCategory GroupBy = "category"
+	// GroupByTag counts a subscription in the group of each of its tags.
+	GroupByTag GroupBy = "tag"
+)
+
+// GroupTotal is the total of one group. Key is empty for subscriptions
+// without a category, or without tags.
+type GroupTotal struct {
+	Key   string
+	Total int
+}
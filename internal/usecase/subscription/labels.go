@@ -0,0 +1,85 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"restservice/internal/entity"
+)
+
+// maxLabelLength caps categories and tags, which end up in URLs and
+// group keys.
+const maxLabelLength = 64
+
+// normalizeLabels lowercases and trims the category and tags, so that
+// "Streaming " and "streaming" are one group, and sorts the tags without
+// blanks or duplicates.
+func normalizeLabels(sub entity.Subscription) entity.Subscription {
+	sub.Category = normalizeLabel(sub.Category)
+	var tags []string
+	for _, tag := range sub.Tags {
+		if tag = normalizeLabel(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	sub.Tags = slices.Compact(tags)
+	return sub
+}
+
+// UpdateOption adjusts an Update.
+type UpdateOption func(*updateOptions)
+
+type updateOptions struct {
+	keepLabels bool
+}
+
+// KeepLabels makes an Update keep the stored category and tags, for
+// callers such as the v1 API that have no labels to send.
+func KeepLabels() UpdateOption {
+	return func(o *updateOptions) { o.keepLabels = true }
+}
+
+// keepLabels copies the labels of the stored row into an update. A row
+// without a category takes the one the catalog resolved for the update.
+func keepLabels(sub, stored entity.Subscription) entity.Subscription {
+	if stored.Category != "" {
+		sub.Category = stored.Category
+	}
+	sub.Tags = stored.Tags
+	return sub
+}
+
+func normalizeLabel(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// normalizeLabelFilter applies normalizeLabel to an optional filter.
+func normalizeLabelFilter(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := normalizeLabel(*s)
+	return &v
+}
+
+func validateLabels(sub entity.Subscription) error {
+	if len(sub.Category) > maxLabelLength {
+		return fmt.Errorf("category must be at most %d characters", maxLabelLength)
+	}
+	for _, tag := range sub.Tags {
+		if tag == "" {
+			return errors.New("tags must not be blank")
+		}
+		if len(tag) > maxLabelLength {
+			return fmt.Errorf("tag must be at most %d characters", maxLabelLength)
+		}
+		// Repositories pass tag lists as comma-separated text.
+		if strings.Contains(tag, ",") {
+			return fmt.Errorf("tag %q must not contain commas", tag)
+		}
+	}
+	return nil
+}
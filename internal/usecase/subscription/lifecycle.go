@@ -0,0 +1,200 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+// ErrInvalidTransition is returned when a subscription's status does not
+// allow a pause, resume or cancel.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// Pause stops billing from the month of at until the subscription is
+// resumed. Only an active subscription can be paused; a trial becomes
+// active once it ends.
+func (s *Service) Pause(ctx context.Context, id uuid.UUID, at time.Time) (entity.Subscription, error) {
+	return s.transition(ctx, id, "pause", func(sub entity.Subscription) (entity.Subscription, error) {
+		if status := sub.StatusAt(at); status != entity.StatusActive {
+			return entity.Subscription{}, fmt.Errorf("%w: cannot pause a %s subscription", ErrInvalidTransition, status)
+		}
+		month := monthOf(at)
+		if month.Before(sub.StartDate) {
+			return entity.Subscription{}, fmt.Errorf("%w: pause must not start before the subscription", ErrValidation)
+		}
+		if sub.EndDate != nil && month.After(*sub.EndDate) {
+			return entity.Subscription{}, fmt.Errorf("%w: pause must not start after the end date", ErrValidation)
+		}
+		if n := len(sub.Pauses); n > 0 && month.Before(*sub.Pauses[n-1].End) {
+			return entity.Subscription{}, fmt.Errorf("%w: pause must not start before the previous one ended", ErrValidation)
+		}
+
+		sub.Pauses = append(slices.Clone(sub.Pauses), entity.Pause{Start: month})
+		sub.Status = entity.StatusPaused
+		return sub, nil
+	})
+}
+
+// Resume bills a paused subscription again from the month of at. Resuming
+// in the month the pause started drops the pause.
+func (s *Service) Resume(ctx context.Context, id uuid.UUID, at time.Time) (entity.Subscription, error) {
+	return s.transition(ctx, id, "resume", func(sub entity.Subscription) (entity.Subscription, error) {
+		if status := sub.StatusAt(at); status != entity.StatusPaused {
+			return entity.Subscription{}, fmt.Errorf("%w: cannot resume a %s subscription", ErrInvalidTransition, status)
+		}
+		month := monthOf(at)
+		pauses := slices.Clone(sub.Pauses)
+		last := &pauses[len(pauses)-1]
+		if month.Before(last.Start) {
+			return entity.Subscription{}, fmt.Errorf("%w: resume must not be before the pause started", ErrValidation)
+		}
+
+		if month.Equal(last.Start) {
+			pauses = pauses[:len(pauses)-1]
+		} else {
+			last.End = &month
+		}
+		sub.Pauses = pauses
+		sub.Status = entity.StatusActive
+		return sub, nil
+	})
+}
+
+// Cancel ends a subscription that is not cancelled yet. It is still billed
+// for the month of at, which becomes its end date unless it ends earlier.
+func (s *Service) Cancel(ctx context.Context, id uuid.UUID, at time.Time) (entity.Subscription, error) {
+	return s.transition(ctx, id, "cancel", func(sub entity.Subscription) (entity.Subscription, error) {
+		if sub.StatusAt(at) == entity.StatusCancelled {
+			return entity.Subscription{}, fmt.Errorf("%w: subscription is already cancelled", ErrInvalidTransition)
+		}
+		month := monthOf(at)
+		if month.Before(sub.StartDate) {
+			return entity.Subscription{}, fmt.Errorf("%w: cancellation must not be before the subscription starts", ErrValidation)
+		}
+
+		day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+		sub.CancelledAt = &day
+		if sub.EndDate == nil || sub.EndDate.After(month) {
+			sub.EndDate = &month
+		}
+		sub.Status = entity.StatusCancelled
+		return sub, nil
+	})
+}
+
+// transition applies a status change to the stored row and raises the
+// same events as an update.
+func (s *Service) transition(ctx context.Context, id uuid.UUID, name string, apply func(entity.Subscription) (entity.Subscription, error)) (entity.Subscription, error) {
+	var updated entity.Subscription
+	err := s.commit(ctx, sql.LevelRepeatableRead, func(ctx context.Context) ([]Event, error) {
+		before, err := s.repository.GetForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		after, err := apply(before)
+		if err != nil {
+			return nil, err
+		}
+		if updated, err = s.repository.Update(Replacing(ctx, before), id, after); err != nil {
+			return nil, err
+		}
+		return updateEvents(before, updated), nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			s.logger.Info("subscription not found", "subscription_id", id)
+			return entity.Subscription{}, ErrNotFound
+		}
+		if errors.Is(err, ErrInvalidTransition) || errors.Is(err, ErrValidation) {
+			s.logger.Info("subscription transition refused", "error", err, "subscription_id", id, "transition", name)
+			return entity.Subscription{}, err
+		}
+		s.logger.Error("subscription transition failed", "error", err, "subscription_id", id, "transition", name)
+		return entity.Subscription{}, fmt.Errorf("%s subscription: %w", name, err)
+	}
+
+	s.logger.Info("subscription status changed", "subscription_id", id, "transition", name, "status", updated.Status)
+	return updated, nil
+}
+
+// startLifecycle sets the status of a new subscription: a trial when it
+// has a trial end date, active otherwise. Pauses and cancellation only
+// come from transitions.
+func startLifecycle(sub entity.Subscription) entity.Subscription {
+	sub.Status = entity.StatusActive
+	if sub.TrialEndDate != nil {
+		sub.Status = entity.StatusTrial
+	}
+	sub.Pauses, sub.CancelledAt = nil, nil
+	return sub
+}
+
+// keepLifecycle copies the lifecycle of the stored row into an update,
+// which cannot change it.
+func keepLifecycle(sub, stored entity.Subscription) entity.Subscription {
+	sub.Status = stored.Status
+	sub.TrialEndDate = stored.TrialEndDate
+	sub.Pauses = stored.Pauses
+	sub.CancelledAt = stored.CancelledAt
+	return sub
+}
+
+func validateLifecycle(sub entity.Subscription) error {
+	switch sub.Status {
+	case "", entity.StatusActive, entity.StatusPaused, entity.StatusCancelled:
+	case entity.StatusTrial:
+		if sub.TrialEndDate == nil {
+			return errors.New("trial must have a trial end date")
+		}
+	default:
+		return fmt.Errorf("unknown status %q", sub.Status)
+	}
+	if sub.TrialEndDate != nil && !sub.TrialEndDate.After(sub.StartDate) {
+		return errors.New("trial end date must be after start date")
+	}
+
+	free := sub.StartDate
+	for i, p := range sub.Pauses {
+		if p.Start.Before(free) {
+			return errors.New("pauses must not overlap or start before the subscription")
+		}
+		if p.End == nil {
+			if i != len(sub.Pauses)-1 {
+				return errors.New("only the last pause may be open")
+			}
+			continue
+		}
+		if !p.End.After(p.Start) {
+			return errors.New("pause must end after it starts")
+		}
+		free = *p.End
+	}
+	open := len(sub.Pauses) > 0 && sub.Pauses[len(sub.Pauses)-1].End == nil
+	if sub.Status == entity.StatusPaused && !open {
+		return errors.New("paused subscription must have an open pause")
+	}
+	if open && sub.Status != entity.StatusPaused && sub.Status != entity.StatusCancelled {
+		return errors.New("only a paused or cancelled subscription may have an open pause")
+	}
+
+	if (sub.Status == entity.StatusCancelled) != (sub.CancelledAt != nil) {
+		return errors.New("cancellation date must be set exactly for cancelled subscriptions")
+	}
+	if sub.CancelledAt != nil && (sub.EndDate == nil || sub.EndDate.After(*sub.CancelledAt)) {
+		return errors.New("cancelled subscription must end by its cancellation date")
+	}
+	return nil
+}
+
+// monthOf is the first day of the month of t, the unit of billing.
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
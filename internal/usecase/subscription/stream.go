@@ -0,0 +1,153 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"restservice/internal/entity"
+)
+
+const (
+	streamBatchSize      = 100
+	listenRetryBaseDelay = time.Second
+	listenRetryMaxDelay  = 30 * time.Second
+)
+
+// ErrInvalidPosition is returned for a malformed stream position.
+var ErrInvalidPosition = errors.New("invalid stream position")
+
+// Position is a place in the change stream: the writing transaction and the
+// event ID. Events are ordered by both, so a position never moves past an
+// event that has yet to commit. The zero Position is before every event.
+type Position struct {
+	TxID    int64
+	EventID int64
+}
+
+// String encodes the position as "<tx>-<event>", the form used for SSE
+// event IDs.
+func (p Position) String() string {
+	return strconv.FormatInt(p.TxID, 10) + "-" + strconv.FormatInt(p.EventID, 10)
+}
+
+func ParsePosition(s string) (Position, error) {
+	tx, event, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Position{}, ErrInvalidPosition
+	}
+	txID, err := strconv.ParseInt(tx, 10, 64)
+	if err != nil || txID < 0 {
+		return Position{}, ErrInvalidPosition
+	}
+	eventID, err := strconv.ParseInt(event, 10, 64)
+	if err != nil || eventID < 0 {
+		return Position{}, ErrInvalidPosition
+	}
+	return Position{TxID: txID, EventID: eventID}, nil
+}
+
+// StreamEvent is an outbox event together with its stream position.
+type StreamEvent struct {
+	Position Position
+	Event    entity.OutboxEvent
+}
+
+// EventLog reads committed subscription events in stream order.
+type EventLog interface {
+	// Head returns the position of the newest event that no running
+	// transaction can still precede.
+	Head(ctx context.Context) (Position, error)
+	// ReadAfter returns up to limit created, updated and deleted events
+	// after pos that match filter.
+	ReadAfter(ctx context.Context, pos Position, filter StreamFilter, limit int) ([]StreamEvent, error)
+	// Listen calls notify whenever events may have been appended, from
+	// any replica, until ctx is done or the connection fails.
+	Listen(ctx context.Context, notify func()) error
+}
+
+// Stream lets many readers follow the event log. A single Listen
+// connection per process wakes all readers; each then reads the log from
+// its own position, so resuming and filtering need no shared state.
+type Stream struct {
+	log    EventLog
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	changed chan struct{}
+	done    chan struct{}
+}
+
+func NewStream(log EventLog, logger *slog.Logger) *Stream {
+	return &Stream{log: log, logger: logger, changed: make(chan struct{}), done: make(chan struct{})}
+}
+
+// Run listens for appended events until ctx is cancelled, reconnecting with
+// backoff. Readers are woken after every reconnect as notifications may
+// have been missed in between. When Run returns, Done is closed.
+func (s *Stream) Run(ctx context.Context) {
+	defer close(s.done)
+	delay := listenRetryBaseDelay
+	for {
+		s.wake()
+		err := s.log.Listen(ctx, func() {
+			delay = listenRetryBaseDelay
+			s.wake()
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Error("event stream listen failed", "error", err, "retry_in", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, listenRetryMaxDelay)
+	}
+}
+
+// Changed returns a channel closed the next time events may have been
+// appended. Take it before reading, so nothing appended during the read is
+// missed.
+func (s *Stream) Changed() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+// Done is closed once the stream stops, so readers can end before the
+// server waits for open connections on shutdown.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Stream) wake() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Head returns the position new readers start from.
+func (s *Stream) Head(ctx context.Context) (Position, error) {
+	pos, err := s.log.Head(ctx)
+	if err != nil {
+		return Position{}, fmt.Errorf("read stream head: %w", err)
+	}
+	return pos, nil
+}
+
+// ReadAfter returns the next batch of matching events after pos.
+func (s *Stream) ReadAfter(ctx context.Context, pos Position, filter StreamFilter) ([]StreamEvent, error) {
+	events, err := s.log.ReadAfter(ctx, pos, filter, streamBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("read stream: %w", err)
+	}
+	return events, nil
+}
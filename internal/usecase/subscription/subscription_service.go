@@ -2,6 +2,7 @@ package subscription
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -19,24 +20,40 @@ const (
 var (
 	ErrNotFound   = errors.New("subscription not found")
 	ErrValidation = errors.New("validation error")
+	// ErrUnknownUser is returned by repositories that know the users when
+	// a subscription refers to one that does not exist.
+	ErrUnknownUser = errors.New("unknown user")
 )
 
 type Repository interface {
 	Create(ctx context.Context, s entity.Subscription) (entity.Subscription, error)
 	Get(ctx context.Context, id uuid.UUID) (entity.Subscription, error)
+	// GetForUpdate is Get that also locks the row until the transaction in
+	// ctx ends, so a concurrent change waits instead of being overwritten.
+	// Writes read the row they replace with it.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (entity.Subscription, error)
 	List(ctx context.Context, filter ListFilter) ([]entity.Subscription, error)
 	Update(ctx context.Context, id uuid.UUID, s entity.Subscription) (entity.Subscription, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	Sum(ctx context.Context, filter SummaryFilter) (int, error)
+	// SumGroups is Sum split by category or tag, ordered by key.
+	SumGroups(ctx context.Context, filter SummaryFilter, by GroupBy) ([]GroupTotal, error)
 }
 
 type Service struct {
 	repository Repository
 	logger     *slog.Logger
+	uow        UnitOfWork
+	publisher  Publisher
+	resolver   ServiceResolver
 }
 
-func NewService(repository Repository, logger *slog.Logger) *Service {
-	return &Service{repository: repository, logger: logger}
+func NewService(repository Repository, logger *slog.Logger, opts ...Option) *Service {
+	s := &Service{repository: repository, logger: logger}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func Validate(s entity.Subscription) error {
@@ -55,17 +72,37 @@ func Validate(s entity.Subscription) error {
 	if s.EndDate != nil && s.EndDate.Before(s.StartDate) {
 		return errors.New("end date be before start date")
 	}
-	return nil
+	if err := validateLabels(s); err != nil {
+		return err
+	}
+	return validateLifecycle(s)
 }
 
 func (s *Service) Create(ctx context.Context, sub entity.Subscription) (entity.Subscription, error) {
+	sub, err := s.resolveService(ctx, sub)
+	if err != nil {
+		s.logger.Error("subscription create failed", "error", err)
+		return entity.Subscription{}, fmt.Errorf("create subscription: %w", err)
+	}
+	sub = startLifecycle(normalizeLabels(sub))
 	if err := Validate(sub); err != nil {
 		s.logger.Info("subscription validation failed", "error", err)
 		return entity.Subscription{}, fmt.Errorf("%w: %s", ErrValidation, err)
 	}
 
-	created, err := s.repository.Create(ctx, sub)
+	var created entity.Subscription
+	err = s.commit(ctx, sql.LevelReadCommitted, func(ctx context.Context) ([]Event, error) {
+		var err error
+		if created, err = s.repository.Create(ctx, sub); err != nil {
+			return nil, err
+		}
+		return []Event{SubscriptionCreated{Subscription: created}}, nil
+	})
 	if err != nil {
+		if errors.Is(err, ErrUnknownUser) {
+			s.logger.Info("subscription user not found", "user_id", sub.UserID)
+			return entity.Subscription{}, fmt.Errorf("%w: user %s does not exist", ErrValidation, sub.UserID)
+		}
 		s.logger.Error("subscription create failed", "error", err)
 		return entity.Subscription{}, fmt.Errorf("create subscription: %w", err)
 	}
@@ -91,6 +128,14 @@ func (s *Service) Get(ctx context.Context, id uuid.UUID) (entity.Subscription, e
 }
 
 func (s *Service) List(ctx context.Context, filter ListFilter) ([]entity.Subscription, error) {
+	name, err := s.resolveFilterName(ctx, filter.ServiceName)
+	if err != nil {
+		s.logger.Error("subscription list failed", "error", err)
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+	filter.ServiceName = name
+	filter.Category, filter.Tag = normalizeLabelFilter(filter.Category), normalizeLabelFilter(filter.Tag)
+
 	items, err := s.repository.List(ctx, filter)
 	if err != nil {
 		s.logger.Error("subscription list failed", "error", err)
@@ -101,18 +146,59 @@ func (s *Service) List(ctx context.Context, filter ListFilter) ([]entity.Subscri
 	return items, nil
 }
 
-func (s *Service) Update(ctx context.Context, id uuid.UUID, sub entity.Subscription) (entity.Subscription, error) {
-	if err := Validate(sub); err != nil {
+func (s *Service) Update(ctx context.Context, id uuid.UUID, sub entity.Subscription, opts ...UpdateOption) (entity.Subscription, error) {
+	var o updateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	sub, err := s.resolveService(ctx, sub)
+	if err != nil {
+		s.logger.Error("subscription update failed", "error", err, "subscription_id", id)
+		return entity.Subscription{}, fmt.Errorf("update subscription: %w", err)
+	}
+	sub = normalizeLabels(sub)
+	// The lifecycle in the request is replaced by the stored one below.
+	if err := Validate(keepLifecycle(sub, entity.Subscription{})); err != nil {
 		s.logger.Info("subscription validation failed", "error", err)
 		return entity.Subscription{}, fmt.Errorf("%w: %s", ErrValidation, err)
 	}
 
-	updated, err := s.repository.Update(ctx, id, sub)
+	var updated entity.Subscription
+	// Repeatable read makes the update fail, and be retried, if the row
+	// changes after it was read, so the events describe what was replaced.
+	err = s.commit(ctx, sql.LevelRepeatableRead, func(ctx context.Context) ([]Event, error) {
+		// The events compare against the stored row, so read it first.
+		before, err := s.repository.GetForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		// The lifecycle changes through transitions only, and a cancelled
+		// subscription must still end by its cancellation.
+		sub := keepLifecycle(sub, before)
+		if o.keepLabels {
+			sub = keepLabels(sub, before)
+		}
+		if err := validateLifecycle(sub); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrValidation, err)
+		}
+		if updated, err = s.repository.Update(Replacing(ctx, before), id, sub); err != nil {
+			return nil, err
+		}
+		return updateEvents(before, updated), nil
+	})
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			s.logger.Info("subscription not found", "subscription_id", id)
 			return entity.Subscription{}, ErrNotFound
 		}
+		if errors.Is(err, ErrValidation) {
+			s.logger.Info("subscription validation failed", "error", err)
+			return entity.Subscription{}, err
+		}
+		if errors.Is(err, ErrUnknownUser) {
+			s.logger.Info("subscription user not found", "user_id", sub.UserID)
+			return entity.Subscription{}, fmt.Errorf("%w: user %s does not exist", ErrValidation, sub.UserID)
+		}
 		s.logger.Error("subscription update failed", "error", err, "subscription_id", id)
 		return entity.Subscription{}, fmt.Errorf("update subscription: %w", err)
 	}
@@ -122,7 +208,18 @@ func (s *Service) Update(ctx context.Context, id uuid.UUID, sub entity.Subscript
 }
 
 func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
-	if err := s.repository.Delete(ctx, id); err != nil {
+	err := s.commit(ctx, sql.LevelRepeatableRead, func(ctx context.Context) ([]Event, error) {
+		// The event carries the deleted row, so read it first.
+		existing, err := s.repository.GetForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repository.Delete(Replacing(ctx, existing), id); err != nil {
+			return nil, err
+		}
+		return []Event{SubscriptionDeleted{Subscription: existing}}, nil
+	})
+	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			s.logger.Info("subscription not found", "subscription_id", id)
 			return ErrNotFound
@@ -136,6 +233,12 @@ func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
 }
 
 func (s *Service) Sum(ctx context.Context, filter SummaryFilter) (int, error) {
+	filter, err := s.summaryFilter(ctx, filter)
+	if err != nil {
+		s.logger.Error("subscription summary failed", "error", err)
+		return 0, fmt.Errorf("summary subscriptions: %w", err)
+	}
+
 	total, err := s.repository.Sum(ctx, filter)
 	if err != nil {
 		s.logger.Error("subscription summary failed", "error", err)
@@ -145,3 +248,36 @@ func (s *Service) Sum(ctx context.Context, filter SummaryFilter) (int, error) {
 	s.logger.Info("subscription summary fetched", "total", total)
 	return total, nil
 }
+
+// SumGroups splits the summary by category or tag.
+func (s *Service) SumGroups(ctx context.Context, filter SummaryFilter, by GroupBy) ([]GroupTotal, error) {
+	if by != GroupByCategory && by != GroupByTag {
+		return nil, fmt.Errorf("%w: unknown grouping %q", ErrValidation, by)
+	}
+	filter, err := s.summaryFilter(ctx, filter)
+	if err != nil {
+		s.logger.Error("subscription summary failed", "error", err)
+		return nil, fmt.Errorf("summary subscriptions: %w", err)
+	}
+
+	groups, err := s.repository.SumGroups(ctx, filter, by)
+	if err != nil {
+		s.logger.Error("subscription summary failed", "error", err, "group_by", by)
+		return nil, fmt.Errorf("summary subscriptions: %w", err)
+	}
+
+	s.logger.Info("subscription summary fetched", "group_by", by, "groups", len(groups))
+	return groups, nil
+}
+
+// summaryFilter resolves the service name and normalizes the labels the
+// way stored subscriptions are.
+func (s *Service) summaryFilter(ctx context.Context, filter SummaryFilter) (SummaryFilter, error) {
+	name, err := s.resolveFilterName(ctx, filter.ServiceName)
+	if err != nil {
+		return SummaryFilter{}, err
+	}
+	filter.ServiceName = name
+	filter.Category, filter.Tag = normalizeLabelFilter(filter.Category), normalizeLabelFilter(filter.Tag)
+	return filter, nil
+}
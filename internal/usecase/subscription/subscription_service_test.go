@@ -0,0 +1,452 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+type stubRepo struct {
+	Repository
+	rows map[uuid.UUID]entity.Subscription
+	// scans counts the List calls without a service name filter.
+	scans int
+}
+
+func (r *stubRepo) Create(_ context.Context, s entity.Subscription) (entity.Subscription, error) {
+	s.ID = uuid.New()
+	r.rows[s.ID] = s
+	return s, nil
+}
+
+func (r *stubRepo) Get(_ context.Context, id uuid.UUID) (entity.Subscription, error) {
+	s, ok := r.rows[id]
+	if !ok {
+		return entity.Subscription{}, ErrNotFound
+	}
+	return s, nil
+}
+
+func (r *stubRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (entity.Subscription, error) {
+	return r.Get(ctx, id)
+}
+
+func (r *stubRepo) Update(_ context.Context, id uuid.UUID, s entity.Subscription) (entity.Subscription, error) {
+	s.ID = id
+	r.rows[id] = s
+	return s, nil
+}
+
+// stubUnitOfWork keeps appended events only if fn succeeds.
+type stubUnitOfWork struct {
+	committed []entity.OutboxEvent
+	pending   []entity.OutboxEvent
+	failOn    string
+}
+
+func (u *stubUnitOfWork) WithinTxLevel(ctx context.Context, _ sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	u.pending = nil
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	u.committed = append(u.committed, u.pending...)
+	return nil
+}
+
+func (u *stubUnitOfWork) Append(_ context.Context, e entity.OutboxEvent) error {
+	if e.Type == u.failOn {
+		return errors.New("outbox unavailable")
+	}
+	u.pending = append(u.pending, e)
+	return nil
+}
+
+type stubPublisher struct {
+	events []Event
+}
+
+func (p *stubPublisher) Publish(_ context.Context, events []Event) error {
+	p.events = append(p.events, events...)
+	return nil
+}
+
+func TestServiceEvents(t *testing.T) {
+	repo := &stubRepo{rows: map[uuid.UUID]entity.Subscription{}}
+	uow := &stubUnitOfWork{}
+	pub := &stubPublisher{}
+	service := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)), WithUnitOfWork(uow), WithPublisher(pub))
+	ctx := context.Background()
+
+	sub := entity.Subscription{
+		ServiceName: "Netflix",
+		Price:       400,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+	}
+	created, err := service.Create(ctx, sub)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	sub.Price = 500
+	if _, err := service.Update(ctx, created.ID, sub); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	var types []string
+	for _, e := range uow.committed {
+		types = append(types, e.Type)
+	}
+	want := []string{entity.EventSubscriptionCreated, entity.EventSubscriptionUpdated, entity.EventSubscriptionPriceChanged}
+	if len(types) != len(want) || types[0] != want[0] || types[1] != want[1] || types[2] != want[2] {
+		t.Fatalf("unexpected outbox events: %v", types)
+	}
+
+	var data PriceChangedData
+	if err := json.Unmarshal(uow.committed[2].Payload, &data); err != nil {
+		t.Fatalf("decode price changed: %v", err)
+	}
+	if data.OldPrice != 400 || data.Price != 500 || data.ID != created.ID {
+		t.Fatalf("unexpected price changed payload: %+v", data)
+	}
+	if len(pub.events) != 3 {
+		t.Fatalf("expected committed events to be published, got %d", len(pub.events))
+	}
+
+	// A failing outbox fails the operation and nothing is published.
+	uow.failOn = entity.EventSubscriptionUpdated
+	if _, err := service.Update(ctx, created.ID, sub); err == nil {
+		t.Fatal("expected update to fail with the outbox")
+	}
+	if len(uow.committed) != 3 || len(pub.events) != 3 {
+		t.Fatalf("failed update leaked events: %d committed, %d published", len(uow.committed), len(pub.events))
+	}
+}
+
+// endedRepo reports every subscription that ended before now once.
+type endedRepo struct {
+	stubRepo
+	reported map[uuid.UUID]bool
+}
+
+func (r *endedRepo) MarkEnded(_ context.Context, now time.Time, limit int) ([]entity.Subscription, error) {
+	var subs []entity.Subscription
+	for id, sub := range r.rows {
+		if sub.EndDate != nil && sub.EndDate.Before(monthOf(now)) && !r.reported[id] && len(subs) < limit {
+			r.reported[id] = true
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func TestReportEnded(t *testing.T) {
+	end := time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)
+	ended := entity.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 400, StartDate: end.AddDate(0, -2, 0), EndDate: &end}
+	running := entity.Subscription{ID: uuid.New(), ServiceName: "Spotify", Price: 200, StartDate: end}
+	repo := &endedRepo{
+		stubRepo: stubRepo{rows: map[uuid.UUID]entity.Subscription{ended.ID: ended, running.ID: running}},
+		reported: map[uuid.UUID]bool{},
+	}
+	uow := &stubUnitOfWork{}
+	pub := &stubPublisher{}
+	service := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)), WithUnitOfWork(uow), WithPublisher(pub))
+	ctx := context.Background()
+	now := time.Date(2025, time.September, 3, 0, 0, 0, 0, time.UTC)
+
+	n, err := service.ReportEnded(ctx, now)
+	if err != nil {
+		t.Fatalf("report ended: %v", err)
+	}
+	if n != 1 || len(uow.committed) != 1 || uow.committed[0].Type != entity.EventSubscriptionEnded || uow.committed[0].SubscriptionID != ended.ID {
+		t.Fatalf("expected one ended event in the outbox, got %d: %+v", n, uow.committed)
+	}
+	if len(pub.events) != 1 || pub.events[0].EventType() != entity.EventSubscriptionEnded {
+		t.Fatalf("expected the ended event to be published, got %v", pub.events)
+	}
+
+	if n, err := service.ReportEnded(ctx, now); err != nil || n != 0 {
+		t.Fatalf("expected nothing left to report, got %d, %v", n, err)
+	}
+
+	plain := NewService(&stubRepo{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, err := plain.ReportEnded(ctx, now); err == nil {
+		t.Fatal("expected a repository without reports to be refused")
+	}
+}
+
+// stubResolver knows Netflix under a few spellings.
+type stubResolver struct{}
+
+func (stubResolver) ResolveService(_ context.Context, name string) (entity.Service, bool, error) {
+	switch strings.ToLower(strings.Join(strings.Fields(name), " ")) {
+	case "netflix", "netflix premium":
+		return entity.Service{Name: "Netflix", DefaultPrice: 799}, true, nil
+	}
+	return entity.Service{}, false, nil
+}
+
+type filterRepo struct {
+	stubRepo
+	listed, summed *string
+}
+
+func (r *filterRepo) List(_ context.Context, filter ListFilter) ([]entity.Subscription, error) {
+	r.listed = filter.ServiceName
+	return nil, nil
+}
+
+func (r *filterRepo) Sum(_ context.Context, filter SummaryFilter) (int, error) {
+	r.summed = filter.ServiceName
+	return 0, nil
+}
+
+func TestServiceResolvesServiceNames(t *testing.T) {
+	repo := &filterRepo{stubRepo: stubRepo{rows: map[uuid.UUID]entity.Subscription{}}}
+	service := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)), WithServiceResolver(stubResolver{}))
+	ctx := context.Background()
+	start := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	created, err := service.Create(ctx, entity.Subscription{ServiceName: " NETFLIX  Premium", UserID: uuid.New(), StartDate: start})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.ServiceName != "Netflix" || created.Price != 799 {
+		t.Fatalf("expected canonical name and default price, got %q %d", created.ServiceName, created.Price)
+	}
+
+	updated, err := service.Update(ctx, created.ID, entity.Subscription{ServiceName: "netflix", Price: 500, UserID: created.UserID, StartDate: start})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.ServiceName != "Netflix" || updated.Price != 500 {
+		t.Fatalf("an explicit price must win over the default, got %q %d", updated.ServiceName, updated.Price)
+	}
+
+	unknown, err := service.Create(ctx, entity.Subscription{ServiceName: " Kinopoisk ", Price: 300, UserID: uuid.New(), StartDate: start})
+	if err != nil {
+		t.Fatalf("create unknown: %v", err)
+	}
+	if unknown.ServiceName != "Kinopoisk" {
+		t.Fatalf("unknown names are kept trimmed, got %q", unknown.ServiceName)
+	}
+	if _, err := service.Create(ctx, entity.Subscription{ServiceName: "Kinopoisk", UserID: uuid.New(), StartDate: start}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected a validation error without a price or default, got %v", err)
+	}
+
+	filterName := "netflix premium"
+	if _, err := service.List(ctx, ListFilter{ServiceName: &filterName}); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if _, err := service.Sum(ctx, SummaryFilter{ServiceName: &filterName}); err != nil {
+		t.Fatalf("sum: %v", err)
+	}
+	if repo.listed == nil || *repo.listed != "Netflix" || repo.summed == nil || *repo.summed != "Netflix" {
+		t.Fatalf("filters were not resolved: list %v, sum %v", repo.listed, repo.summed)
+	}
+}
+
+func TestUpdateKeepLabels(t *testing.T) {
+	repo := &stubRepo{rows: map[uuid.UUID]entity.Subscription{}}
+	service := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	sub := entity.Subscription{
+		ServiceName: "Netflix",
+		Price:       400,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		Category:    "video",
+		Tags:        []string{"family"},
+	}
+	created, err := service.Create(ctx, sub)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	sub.Price, sub.Category, sub.Tags = 500, "", nil
+	updated, err := service.Update(ctx, created.ID, sub, KeepLabels())
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Price != 500 || updated.Category != "video" || len(updated.Tags) != 1 || updated.Tags[0] != "family" {
+		t.Fatalf("expected the stored labels to be kept, got %+v", updated)
+	}
+
+	updated, err = service.Update(ctx, created.ID, sub)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Category != "" || len(updated.Tags) != 0 {
+		t.Fatalf("expected the labels to be cleared, got %q %v", updated.Category, updated.Tags)
+	}
+}
+
+func TestServiceTransitions(t *testing.T) {
+	repo := &stubRepo{rows: map[uuid.UUID]entity.Subscription{}}
+	service := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	month := func(m time.Month) time.Time { return time.Date(2025, m, 1, 0, 0, 0, 0, time.UTC) }
+
+	trialEnd := month(time.August)
+	created, err := service.Create(ctx, entity.Subscription{
+		ServiceName:  "Netflix",
+		Price:        400,
+		UserID:       uuid.New(),
+		StartDate:    month(time.July),
+		TrialEndDate: &trialEnd,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.Status != entity.StatusTrial {
+		t.Fatalf("expected a trial, got %q", created.Status)
+	}
+
+	if _, err := service.Pause(ctx, created.ID, month(time.July)); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("pausing a trial: expected invalid transition, got %v", err)
+	}
+	paused, err := service.Pause(ctx, created.ID, month(time.September).AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if paused.Status != entity.StatusPaused || len(paused.Pauses) != 1 || !paused.Pauses[0].Start.Equal(month(time.September)) {
+		t.Fatalf("unexpected paused subscription: %+v", paused)
+	}
+	if _, err := service.Resume(ctx, created.ID, month(time.August)); !errors.Is(err, ErrValidation) {
+		t.Fatalf("resuming before the pause: expected validation error, got %v", err)
+	}
+	resumed, err := service.Resume(ctx, created.ID, month(time.November))
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if resumed.Status != entity.StatusActive || !resumed.Pauses[0].End.Equal(month(time.November)) {
+		t.Fatalf("unexpected resumed subscription: %+v", resumed)
+	}
+
+	// An update keeps the lifecycle it cannot change.
+	update := resumed
+	update.Price, update.Status, update.Pauses = 500, entity.StatusCancelled, nil
+	updated, err := service.Update(ctx, created.ID, update)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Status != entity.StatusActive || len(updated.Pauses) != 1 {
+		t.Fatalf("update changed the lifecycle: %+v", updated)
+	}
+
+	cancelled, err := service.Cancel(ctx, created.ID, month(time.December).AddDate(0, 0, 9))
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancelled.Status != entity.StatusCancelled || !cancelled.EndDate.Equal(month(time.December)) || cancelled.CancelledAt.Day() != 10 {
+		t.Fatalf("unexpected cancelled subscription: %+v", cancelled)
+	}
+	if _, err := service.Cancel(ctx, created.ID, month(time.December)); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("cancelling twice: expected invalid transition, got %v", err)
+	}
+	if _, err := service.Pause(ctx, uuid.New(), month(time.December)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("pausing a missing subscription: expected not found, got %v", err)
+	}
+}
+
+func TestAfterCommit(t *testing.T) {
+	repo := &stubRepo{rows: map[uuid.UUID]entity.Subscription{}}
+	uow := &stubUnitOfWork{}
+	service := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)), WithUnitOfWork(uow))
+
+	var ran []string
+	register := func(name string, events []Event, err error) error {
+		return service.commit(context.Background(), sql.LevelDefault, func(ctx context.Context) ([]Event, error) {
+			if !InUnitOfWork(ctx) {
+				t.Fatal("fn must run in a unit of work")
+			}
+			AfterCommit(ctx, func() { ran = append(ran, name) })
+			return events, err
+		})
+	}
+
+	if err := register("committed", nil, nil); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := register("failed", nil, errors.New("boom")); err == nil {
+		t.Fatal("expected the error of fn")
+	}
+	AfterCommit(context.Background(), func() { ran = append(ran, "outside") })
+
+	if strings.Join(ran, ",") != "committed,outside" {
+		t.Fatalf("unexpected hooks: %v", ran)
+	}
+}
+
+// List pages over the rows in id order, filtered by service name like the
+// repositories.
+func (r *stubRepo) List(_ context.Context, filter ListFilter) ([]entity.Subscription, error) {
+	rows := slices.SortedFunc(maps.Values(r.rows), func(a, b entity.Subscription) int {
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	if filter.ServiceName != nil && strings.TrimSpace(*filter.ServiceName) != "" {
+		rows = slices.DeleteFunc(rows, func(row entity.Subscription) bool {
+			return row.ServiceName != strings.TrimSpace(*filter.ServiceName)
+		})
+	} else {
+		r.scans++
+	}
+	rows = rows[min(filter.Offset, len(rows)):]
+	if filter.Limit > 0 {
+		rows = rows[:min(filter.Limit, len(rows))]
+	}
+	return rows, nil
+}
+
+func TestRenameServices(t *testing.T) {
+	repo := &stubRepo{rows: map[uuid.UUID]entity.Subscription{}}
+	uow := &stubUnitOfWork{}
+	service := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)), WithUnitOfWork(uow))
+	for _, name := range []string{"netflix ", "netflix ", "NETFLIX", "Netflix", "Spotify"} {
+		repo.Create(context.Background(), entity.Subscription{ServiceName: name, Price: 100})
+	}
+
+	counts, err := service.RenameServices(context.Background(), map[string]string{"netflix ": "Netflix", "NETFLIX": "Netflix"})
+	if err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if counts["netflix "] != 2 || counts["NETFLIX"] != 1 {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+	for _, row := range repo.rows {
+		if row.ServiceName != "Netflix" && row.ServiceName != "Spotify" {
+			t.Fatalf("%q was not renamed", row.ServiceName)
+		}
+	}
+	if len(uow.committed) != 3 {
+		t.Fatalf("expected an event per renamed subscription, got %d", len(uow.committed))
+	}
+	for _, e := range uow.committed {
+		if e.Type != entity.EventSubscriptionUpdated {
+			t.Fatalf("unexpected event %s", e.Type)
+		}
+	}
+
+	// Names stored without surrounding whitespace are found by the filter
+	// alone.
+	scans := repo.scans
+	repo.Create(context.Background(), entity.Subscription{ServiceName: "Spotify Premium", Price: 100})
+	if counts, err := service.RenameServices(context.Background(), map[string]string{"Spotify Premium": "Spotify"}); err != nil || counts["Spotify Premium"] != 1 {
+		t.Fatalf("rename: %v, %v", counts, err)
+	}
+	if repo.scans != scans {
+		t.Fatalf("renaming a trimmed name read every subscription")
+	}
+}
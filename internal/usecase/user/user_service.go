@@ -0,0 +1,168 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	// Zone names are validated against the embedded database so the
+	// result does not depend on the host's zoneinfo.
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+const (
+	DefaultTimezone = "UTC"
+	DefaultCurrency = "RUB"
+)
+
+var (
+	ErrNotFound   = errors.New("user not found")
+	ErrValidation = errors.New("validation error")
+	// ErrEmailTaken means another user already has the email.
+	ErrEmailTaken = errors.New("email already in use")
+	// ErrHasSubscriptions means the user still owns subscriptions and
+	// cannot be deleted.
+	ErrHasSubscriptions = errors.New("user has subscriptions")
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type Repository interface {
+	Create(ctx context.Context, u entity.User) (entity.User, error)
+	Get(ctx context.Context, id uuid.UUID) (entity.User, error)
+	List(ctx context.Context, filter ListFilter) ([]entity.User, error)
+	Update(ctx context.Context, id uuid.UUID, u entity.User) (entity.User, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type ListFilter struct {
+	// Limit caps the number of returned items; zero means no limit.
+	Limit  int
+	Offset int
+}
+
+type Service struct {
+	repository Repository
+	logger     *slog.Logger
+}
+
+func NewService(repository Repository, logger *slog.Logger) *Service {
+	return &Service{repository: repository, logger: logger}
+}
+
+func Validate(u entity.User) error {
+	if strings.TrimSpace(u.Name) == "" {
+		return errors.New("name is required")
+	}
+	if u.Email != "" {
+		if addr, err := mail.ParseAddress(u.Email); err != nil || addr.Address != u.Email {
+			return errors.New("email is not a valid address")
+		}
+	}
+	if _, err := time.LoadLocation(u.Timezone); err != nil || u.Timezone == "" || u.Timezone == "Local" {
+		return fmt.Errorf("unknown timezone %q", u.Timezone)
+	}
+	if !currencyCode.MatchString(u.Currency) {
+		return errors.New("currency must be a three-letter ISO 4217 code")
+	}
+	return nil
+}
+
+// normalize trims the fields and fills in the defaults for the optional
+// ones.
+func normalize(u entity.User) entity.User {
+	u.Name = strings.TrimSpace(u.Name)
+	u.Email = strings.TrimSpace(u.Email)
+	u.Timezone = strings.TrimSpace(u.Timezone)
+	if u.Timezone == "" {
+		u.Timezone = DefaultTimezone
+	}
+	u.Currency = strings.ToUpper(strings.TrimSpace(u.Currency))
+	if u.Currency == "" {
+		u.Currency = DefaultCurrency
+	}
+	return u
+}
+
+func (s *Service) Create(ctx context.Context, u entity.User) (entity.User, error) {
+	u = normalize(u)
+	if err := Validate(u); err != nil {
+		s.logger.Info("user validation failed", "error", err)
+		return entity.User{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+
+	created, err := s.repository.Create(ctx, u)
+	if err != nil {
+		if errors.Is(err, ErrEmailTaken) {
+			return entity.User{}, ErrEmailTaken
+		}
+		s.logger.Error("user create failed", "error", err)
+		return entity.User{}, fmt.Errorf("create user: %w", err)
+	}
+
+	s.logger.Info("user created", "user_id", created.ID)
+	return created, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (entity.User, error) {
+	u, err := s.repository.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return entity.User{}, ErrNotFound
+		}
+		s.logger.Error("user get failed", "error", err, "user_id", id)
+		return entity.User{}, fmt.Errorf("get user: %w", err)
+	}
+	return u, nil
+}
+
+func (s *Service) List(ctx context.Context, filter ListFilter) ([]entity.User, error) {
+	items, err := s.repository.List(ctx, filter)
+	if err != nil {
+		s.logger.Error("user list failed", "error", err)
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	return items, nil
+}
+
+func (s *Service) Update(ctx context.Context, id uuid.UUID, u entity.User) (entity.User, error) {
+	u = normalize(u)
+	if err := Validate(u); err != nil {
+		s.logger.Info("user validation failed", "error", err)
+		return entity.User{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+
+	updated, err := s.repository.Update(ctx, id, u)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrEmailTaken) {
+			return entity.User{}, err
+		}
+		s.logger.Error("user update failed", "error", err, "user_id", id)
+		return entity.User{}, fmt.Errorf("update user: %w", err)
+	}
+
+	s.logger.Info("user updated", "user_id", id)
+	return updated, nil
+}
+
+// Delete removes a user that no longer has subscriptions.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrHasSubscriptions) {
+			return err
+		}
+		s.logger.Error("user delete failed", "error", err, "user_id", id)
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	s.logger.Info("user deleted", "user_id", id)
+	return nil
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errPrivateDestination is returned when a webhook would reach the
+// service's own network. Webhook URLs come from API clients, and the
+// deliveries endpoint shows what the target answered, so such targets
+// would let anyone probe internal services.
+var errPrivateDestination = errors.New("destination is not a public address")
+
+// nonPublicPrefixes are ranges that are global unicast by the standard
+// library's definition but are not reachable on the internet.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// isPublic reports whether ip may be a webhook destination. Loopback,
+// private, link-local (including the 169.254.169.254 metadata address),
+// multicast and unspecified addresses are not.
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkURLHost rejects URLs that name a non-public address directly, so
+// clients learn about it when they register the webhook. Host names are
+// checked when they are resolved, see dialControl.
+func checkURLHost(u *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errPrivateDestination
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !isPublic(ip) {
+		return errPrivateDestination
+	}
+	return nil
+}
+
+// dialControl runs after the host name is resolved and before every
+// connection, including those of redirects, so a name that resolves to
+// an internal address is refused too.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !isPublic(ip) {
+		return fmt.Errorf("%w: %s", errPrivateDestination, host)
+	}
+	return nil
+}
+
+// newDeliveryClient returns the client webhooks are sent with. It never
+// uses a proxy, which would dial on its behalf and bypass dialControl.
+func newDeliveryClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: timeout,
+		},
+	}
+}
@@ -0,0 +1,253 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+const (
+	defaultInterval  = 5 * time.Second
+	defaultBatchSize = 100
+	// defaultConcurrency is how many deliveries are claimed and sent at
+	// once. They are sent in parallel, so one lease covers them all.
+	defaultConcurrency = 16
+	defaultMaxAttempts = 8
+	defaultBaseBackoff = 10 * time.Second
+	defaultMaxBackoff  = time.Hour
+	defaultTimeout     = 10 * time.Second
+
+	// maxErrorLength bounds the response excerpt stored with a failure.
+	maxErrorLength = 512
+
+	userAgent = "restservice-webhooks/1"
+)
+
+// Job is a claimed delivery together with everything needed to send it.
+type Job struct {
+	DeliveryID int64
+	// Attempt counts this try, starting at 1.
+	Attempt   int
+	WebhookID uuid.UUID
+	URL       string
+	Secret    string
+	Event     entity.OutboxEvent
+}
+
+// Store is the persistence the dispatcher needs. Claims must be safe to
+// run from several replicas at once.
+type Store interface {
+	// FanOut turns undispatched outbox events into pending deliveries for
+	// every webhook subscribed to them.
+	FanOut(ctx context.Context, limit int) (int, error)
+	// Claim returns due pending deliveries and hides them from other
+	// claimers for lease.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+	MarkDelivered(ctx context.Context, deliveryID int64, statusCode int) error
+	// MarkFailed records a failed attempt and schedules the next one at
+	// next, or marks the delivery dead when dead is set.
+	MarkFailed(ctx context.Context, deliveryID int64, statusCode int, reason string, next time.Time, dead bool) error
+}
+
+// EndedReporter records subscription.ended for subscriptions whose last
+// month has passed and that were not reported yet, returning how many.
+type EndedReporter interface {
+	ReportEnded(ctx context.Context, now time.Time) (int, error)
+}
+
+type DispatcherConfig struct {
+	Interval    time.Duration
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+}
+
+// Dispatcher sends outbox events to webhooks. Each tick it reports ended
+// subscriptions, fans new events out into deliveries and sends the due
+// ones. Failed deliveries are retried with exponential backoff until
+// MaxAttempts, after which they are dead until redelivered.
+type Dispatcher struct {
+	store  Store
+	ended  EndedReporter
+	client *http.Client
+	logger *slog.Logger
+	cfg    DispatcherConfig
+	now    func() time.Time
+}
+
+// NewDispatcher returns a dispatcher for store. ended, if not nil, is
+// asked each tick to report ended subscriptions before the fan-out.
+func NewDispatcher(store Store, ended EndedReporter, logger *slog.Logger, cfg DispatcherConfig) *Dispatcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaultBaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &Dispatcher{
+		store:  store,
+		ended:  ended,
+		client: newDeliveryClient(cfg.Timeout),
+		logger: logger,
+		cfg:    cfg,
+		now:    time.Now,
+	}
+}
+
+// Run dispatches until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Tick(ctx); err != nil && ctx.Err() == nil {
+			d.logger.Error("webhook dispatch failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick runs one dispatch round.
+func (d *Dispatcher) Tick(ctx context.Context) error {
+	if d.ended != nil {
+		if n, err := d.ended.ReportEnded(ctx, d.now()); err != nil {
+			return fmt.Errorf("report ended subscriptions: %w", err)
+		} else if n > 0 {
+			d.logger.Info("subscription ended events recorded", "count", n)
+		}
+	}
+
+	if _, err := d.store.FanOut(ctx, defaultBatchSize); err != nil {
+		return fmt.Errorf("fan out events: %w", err)
+	}
+
+	for sent := 0; sent < defaultBatchSize; {
+		// Every claimed job is sent at once and a send is bounded by the
+		// timeout, so the lease outlives the whole group. A crashed
+		// replica's claims still come back instead of being lost.
+		jobs, err := d.store.Claim(ctx, defaultConcurrency, 2*d.cfg.Timeout)
+		if err != nil {
+			return fmt.Errorf("claim deliveries: %w", err)
+		}
+		if err := d.deliverAll(ctx, jobs); err != nil {
+			return err
+		}
+		if len(jobs) < defaultConcurrency {
+			return nil
+		}
+		sent += len(jobs)
+	}
+	return nil
+}
+
+// deliverAll sends jobs in parallel. A job that cannot be recorded does
+// not stop the others; its error is returned once all are done.
+func (d *Dispatcher) deliverAll(ctx context.Context, jobs []Job) error {
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.deliver(ctx, job)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// payload is the body of every webhook request.
+type payload struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job Job) error {
+	body, err := json.Marshal(payload{
+		ID:        job.Event.ID,
+		Type:      job.Event.Type,
+		CreatedAt: job.Event.CreatedAt.UTC(),
+		Data:      job.Event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	status, reason := d.send(ctx, job, body)
+	if reason == "" {
+		d.logger.Info("webhook delivered", "delivery_id", job.DeliveryID, "webhook_id", job.WebhookID, "status", status)
+		return d.store.MarkDelivered(ctx, job.DeliveryID, status)
+	}
+
+	dead := job.Attempt >= d.cfg.MaxAttempts
+	next := d.now().Add(d.backoff(job.Attempt))
+	d.logger.Warn("webhook delivery failed",
+		"delivery_id", job.DeliveryID, "webhook_id", job.WebhookID,
+		"attempt", job.Attempt, "status", status, "reason", reason, "dead", dead)
+	return d.store.MarkFailed(ctx, job.DeliveryID, status, reason, next, dead)
+}
+
+// send posts body and returns the response status and, on failure, a
+// short reason.
+func (d *Dispatcher) send(ctx context.Context, job Job, body []byte) (int, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Webhook-Event", job.Event.Type)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(job.DeliveryID, 10))
+	req.Header.Set(SignatureHeader, Sign(job.Secret, d.now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp.StatusCode, ""
+	}
+	reason := resp.Status
+	if len(excerpt) > 0 {
+		reason += ": " + string(excerpt)
+	}
+	return resp.StatusCode, reason
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	b := d.cfg.BaseBackoff << (attempt - 1)
+	if b <= 0 || b > d.cfg.MaxBackoff {
+		b = d.cfg.MaxBackoff
+	}
+	return b
+}
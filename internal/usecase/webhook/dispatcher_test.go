@@ -0,0 +1,246 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"restservice/internal/entity"
+)
+
+type failure struct {
+	status int
+	next   time.Time
+	dead   bool
+}
+
+type fakeStore struct {
+	mu        sync.Mutex
+	jobs      []Job
+	delivered map[int64]int
+	failed    map[int64]failure
+	// failMark makes recording a failure of this delivery fail.
+	failMark int64
+}
+
+func newFakeStore(jobs ...Job) *fakeStore {
+	return &fakeStore{jobs: jobs, delivered: map[int64]int{}, failed: map[int64]failure{}}
+}
+
+func (s *fakeStore) FanOut(context.Context, int) (int, error) { return 0, nil }
+
+func (s *fakeStore) Claim(_ context.Context, limit int, _ time.Duration) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := s.jobs[:min(limit, len(s.jobs))]
+	s.jobs = s.jobs[len(jobs):]
+	return jobs, nil
+}
+
+func (s *fakeStore) MarkDelivered(_ context.Context, id int64, status int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delivered[id] = status
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(_ context.Context, id int64, status int, _ string, next time.Time, dead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id == s.failMark {
+		return errors.New("store unavailable")
+	}
+	s.failed[id] = failure{status: status, next: next, dead: dead}
+	return nil
+}
+
+func TestDispatcherTick(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	secret := "0123456789abcdef"
+
+	var (
+		mu       sync.Mutex
+		received []http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header.Get(SignatureHeader), body, now, time.Minute); err != nil {
+			t.Errorf("signature: %v", err)
+		}
+		var p payload
+		if err := json.Unmarshal(body, &p); err != nil || p.Type != entity.EventSubscriptionCreated {
+			t.Errorf("unexpected payload %s: %v", body, err)
+		}
+		mu.Lock()
+		received = append(received, r.Header.Clone())
+		mu.Unlock()
+		if r.URL.Path == "/fail" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	event := entity.OutboxEvent{ID: 7, Type: entity.EventSubscriptionCreated, Payload: json.RawMessage(`{}`), CreatedAt: now}
+	store := newFakeStore(
+		Job{DeliveryID: 1, Attempt: 1, URL: server.URL + "/ok", Secret: secret, Event: event},
+		Job{DeliveryID: 2, Attempt: 2, URL: server.URL + "/fail", Secret: secret, Event: event},
+		Job{DeliveryID: 3, Attempt: 3, URL: server.URL + "/fail", Secret: secret, Event: event},
+	)
+	d := NewDispatcher(store, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DispatcherConfig{
+		MaxAttempts: 3,
+		BaseBackoff: time.Second,
+	})
+	d.now = func() time.Time { return now }
+	d.client = server.Client()
+
+	if err := d.Tick(context.Background()); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+
+	if len(received) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(received))
+	}
+	var ids []string
+	for _, h := range received {
+		ids = append(ids, h.Get("X-Webhook-Delivery"))
+	}
+	if slices.Sort(ids); !slices.Equal(ids, []string{"1", "2", "3"}) {
+		t.Fatalf("unexpected delivery headers %v", ids)
+	}
+	if store.delivered[1] != http.StatusOK {
+		t.Fatalf("delivery 1 not marked delivered: %v", store.delivered)
+	}
+	if f := store.failed[2]; f.dead || f.status != http.StatusInternalServerError || !f.next.Equal(now.Add(2*time.Second)) {
+		t.Fatalf("delivery 2: unexpected failure %+v", f)
+	}
+	if f := store.failed[3]; !f.dead {
+		t.Fatalf("delivery 3 should be dead after max attempts: %+v", f)
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	d := NewDispatcher(newFakeStore(), nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DispatcherConfig{
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Minute,
+	})
+	if got := d.backoff(1); got != time.Second {
+		t.Fatalf("attempt 1: %v", got)
+	}
+	if got := d.backoff(4); got != 8*time.Second {
+		t.Fatalf("attempt 4: %v", got)
+	}
+	if got := d.backoff(100); got != time.Minute {
+		t.Fatalf("attempt 100: %v", got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":1}`)
+	header := Sign("secret", now, body)
+
+	if err := Verify("secret", header, body, now.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := Verify("other", header, body, now, time.Minute); err == nil {
+		t.Fatal("wrong secret accepted")
+	}
+	if err := Verify("secret", header, []byte(`{"id":2}`), now, time.Minute); err == nil {
+		t.Fatal("tampered body accepted")
+	}
+	if err := Verify("secret", header, body, now.Add(time.Hour), time.Minute); err == nil {
+		t.Fatal("stale signature accepted")
+	}
+	if err := Verify("secret", "garbage", body, now, time.Minute); err == nil {
+		t.Fatal("malformed header accepted")
+	}
+}
+
+func TestDispatcherTickSendsEveryClaimedJob(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var jobs []Job
+	for id := int64(1); id <= defaultConcurrency+4; id++ {
+		jobs = append(jobs, Job{DeliveryID: id, Attempt: 1, URL: server.URL, Event: entity.OutboxEvent{Payload: json.RawMessage(`{}`)}})
+	}
+	store := newFakeStore(jobs...)
+	store.failMark = 2
+	d := NewDispatcher(store, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DispatcherConfig{})
+	d.client = server.Client()
+
+	// A delivery that cannot be recorded fails the tick, but only after
+	// the rest of its group is sent; later jobs are not claimed at all.
+	if err := d.Tick(context.Background()); err == nil {
+		t.Fatal("expected the failed mark to be reported")
+	}
+	if got := requests.Load(); got != defaultConcurrency {
+		t.Fatalf("expected %d requests, got %d", defaultConcurrency, got)
+	}
+	if len(store.failed) != defaultConcurrency-1 || len(store.jobs) != 4 {
+		t.Fatalf("unexpected state: %d failures recorded, %d jobs left", len(store.failed), len(store.jobs))
+	}
+
+	store.failMark = 0
+	if err := d.Tick(context.Background()); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if got := requests.Load(); got != int32(len(jobs)) {
+		t.Fatalf("expected %d requests, got %d", len(jobs), got)
+	}
+}
+
+func TestDispatcherRefusesPrivateDestinations(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	// The name resolves to loopback, so only the dialer can catch it.
+	target := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	store := newFakeStore(Job{DeliveryID: 1, Attempt: 1, URL: target, Event: entity.OutboxEvent{Payload: json.RawMessage(`{}`)}})
+	d := NewDispatcher(store, nil, slog.New(slog.NewTextHandler(io.Discard, nil)), DispatcherConfig{})
+	if err := d.Tick(context.Background()); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if requests.Load() != 0 {
+		t.Fatal("private destination was reached")
+	}
+	if _, ok := store.failed[1]; !ok {
+		t.Fatalf("delivery not marked failed: %+v", store.delivered)
+	}
+}
+
+func TestValidateRejectsPrivateURLs(t *testing.T) {
+	for _, raw := range []string{
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"http://10.1.2.3/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/hook",
+		"http://[::ffff:192.168.0.1]/hook",
+	} {
+		err := Validate(entity.Webhook{URL: raw, Events: []string{entity.EventSubscriptionCreated}})
+		if !errors.Is(err, errPrivateDestination) {
+			t.Errorf("%s: expected a private destination error, got %v", raw, err)
+		}
+	}
+	if err := Validate(entity.Webhook{URL: "https://example.com/hook", Events: []string{entity.EventSubscriptionCreated}}); err != nil {
+		t.Fatalf("public url: %v", err)
+	}
+}
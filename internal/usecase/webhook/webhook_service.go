@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+)
+
+const (
+	minSecretLength = 16
+	// generatedSecretBytes is the entropy of secrets created for clients
+	// that do not bring their own.
+	generatedSecretBytes = 32
+)
+
+var (
+	ErrNotFound   = errors.New("webhook not found")
+	ErrValidation = errors.New("validation error")
+)
+
+type Repository interface {
+	Create(ctx context.Context, w entity.Webhook) (entity.Webhook, error)
+	Get(ctx context.Context, id uuid.UUID) (entity.Webhook, error)
+	List(ctx context.Context) ([]entity.Webhook, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, filter DeliveryFilter) ([]entity.WebhookDelivery, error)
+	// Redeliver queues a delivery again with a fresh attempt budget,
+	// whatever its current status.
+	Redeliver(ctx context.Context, webhookID uuid.UUID, deliveryID int64) (entity.WebhookDelivery, error)
+}
+
+type DeliveryFilter struct {
+	// Status limits the result to one status; empty means any.
+	Status entity.DeliveryStatus
+	Limit  int
+}
+
+type Service struct {
+	repository Repository
+	logger     *slog.Logger
+}
+
+func NewService(repository Repository, logger *slog.Logger) *Service {
+	return &Service{repository: repository, logger: logger}
+}
+
+func Validate(w entity.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+	if err := checkURLHost(u); err != nil {
+		return fmt.Errorf("url: %w", err)
+	}
+	if len(w.Events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	for _, event := range w.Events {
+		if !slices.Contains(entity.EventTypes, event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	if w.Secret != "" && len(w.Secret) < minSecretLength {
+		return fmt.Errorf("secret must be at least %d characters", minSecretLength)
+	}
+	return nil
+}
+
+// Create registers a webhook. A secret is generated when none is given;
+// either way the returned webhook is the only place it is shown.
+func (s *Service) Create(ctx context.Context, w entity.Webhook) (entity.Webhook, error) {
+	w.URL = strings.TrimSpace(w.URL)
+	if err := Validate(w); err != nil {
+		s.logger.Info("webhook validation failed", "error", err)
+		return entity.Webhook{}, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+	w.Events = slices.Compact(slices.Sorted(slices.Values(w.Events)))
+
+	if w.Secret == "" {
+		secret := make([]byte, generatedSecretBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return entity.Webhook{}, fmt.Errorf("generate webhook secret: %w", err)
+		}
+		w.Secret = hex.EncodeToString(secret)
+	}
+
+	created, err := s.repository.Create(ctx, w)
+	if err != nil {
+		s.logger.Error("webhook create failed", "error", err)
+		return entity.Webhook{}, fmt.Errorf("create webhook: %w", err)
+	}
+
+	s.logger.Info("webhook created", "webhook_id", created.ID, "events", created.Events)
+	return created, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (entity.Webhook, error) {
+	w, err := s.repository.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return entity.Webhook{}, ErrNotFound
+		}
+		s.logger.Error("webhook get failed", "error", err, "webhook_id", id)
+		return entity.Webhook{}, fmt.Errorf("get webhook: %w", err)
+	}
+	return w, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]entity.Webhook, error) {
+	items, err := s.repository.List(ctx)
+	if err != nil {
+		s.logger.Error("webhook list failed", "error", err)
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	return items, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrNotFound
+		}
+		s.logger.Error("webhook delete failed", "error", err, "webhook_id", id)
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	s.logger.Info("webhook deleted", "webhook_id", id)
+	return nil
+}
+
+func (s *Service) ListDeliveries(ctx context.Context, webhookID uuid.UUID, filter DeliveryFilter) ([]entity.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	items, err := s.repository.ListDeliveries(ctx, webhookID, filter)
+	if err != nil {
+		s.logger.Error("webhook deliveries list failed", "error", err, "webhook_id", webhookID)
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	return items, nil
+}
+
+func (s *Service) Redeliver(ctx context.Context, webhookID uuid.UUID, deliveryID int64) (entity.WebhookDelivery, error) {
+	d, err := s.repository.Redeliver(ctx, webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return entity.WebhookDelivery{}, ErrNotFound
+		}
+		s.logger.Error("webhook redeliver failed", "error", err, "delivery_id", deliveryID)
+		return entity.WebhookDelivery{}, fmt.Errorf("redeliver webhook: %w", err)
+	}
+
+	s.logger.Info("webhook delivery requeued", "webhook_id", webhookID, "delivery_id", deliveryID)
+	return d, nil
+}
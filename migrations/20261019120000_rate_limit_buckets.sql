@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists rate_limit_buckets (
+    key text primary key,
+    tokens double precision not null,
+    updated_at timestamptz not null,
+    full_at timestamptz not null
+);
+
+create index if not exists idx_rate_limit_buckets_full_at
+    on rate_limit_buckets(full_at);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists rate_limit_buckets;
+-- +goose StatementEnd
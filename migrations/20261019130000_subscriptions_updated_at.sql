@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table subscriptions
+    add column if not exists updated_at timestamptz not null default now();
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table subscriptions
+    drop column if exists updated_at;
+-- +goose StatementEnd
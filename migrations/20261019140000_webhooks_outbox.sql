@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists outbox (
+    id bigserial primary key,
+    event_type text not null,
+    subscription_id uuid not null,
+    payload jsonb not null,
+    created_at timestamptz not null default now(),
+    -- dispatched_at is set once the event has been fanned out to webhooks.
+    dispatched_at timestamptz null
+);
+
+create index if not exists idx_outbox_undispatched
+    on outbox(id) where dispatched_at is null;
+
+create table if not exists webhooks (
+    id uuid primary key default uuid_generate_v4(),
+    url text not null,
+    events text[] not null,
+    secret text not null,
+    created_at timestamptz not null default now()
+);
+
+create table if not exists webhook_deliveries (
+    id bigserial primary key,
+    webhook_id uuid not null references webhooks(id) on delete cascade,
+    event_id bigint not null references outbox(id) on delete cascade,
+    status text not null default 'pending'
+        check (status in ('pending', 'delivered', 'dead')),
+    attempts int not null default 0,
+    next_attempt_at timestamptz not null default now(),
+    last_status_code int null,
+    last_error text null,
+    delivered_at timestamptz null,
+    created_at timestamptz not null default now(),
+
+    unique (webhook_id, event_id)
+);
+
+create index if not exists idx_webhook_deliveries_due
+    on webhook_deliveries(next_attempt_at) where status = 'pending';
+
+create index if not exists idx_webhook_deliveries_webhook
+    on webhook_deliveries(webhook_id, id);
+
+-- ended_notified_at records that subscription.ended was emitted, so the
+-- dispatcher reports every ended subscription exactly once.
+alter table subscriptions
+    add column if not exists ended_notified_at timestamptz null;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table subscriptions
+    drop column if exists ended_notified_at;
+drop table if exists webhook_deliveries;
+drop table if exists webhooks;
+drop table if exists outbox;
+-- +goose StatementEnd
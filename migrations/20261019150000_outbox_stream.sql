@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- tx_id orders the outbox as a stream. Sequence values are handed out at
+-- insert time, so a later id can commit before an earlier one; rows whose
+-- tx_id is below the oldest running transaction can no longer be joined by
+-- anything that sorts before them.
+alter table outbox
+    add column if not exists tx_id xid8 not null default pg_current_xact_id();
+
+create index if not exists idx_outbox_stream
+    on outbox(tx_id, id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists idx_outbox_stream;
+alter table outbox
+    drop column if exists tx_id;
+-- +goose StatementEnd
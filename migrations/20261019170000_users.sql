@@ -0,0 +1,63 @@
+-- +goose NO TRANSACTION
+-- Each step commits on its own, so adding the foreign key holds its lock
+-- on subscriptions only briefly and validating it does not block writes.
+-- The steps can be rerun if the migration stops halfway.
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists users (
+    id uuid primary key default uuid_generate_v4(),
+    -- name is empty for users backfilled from existing subscriptions.
+    name text not null default '',
+    email text null,
+    timezone text not null default 'UTC',
+    currency text not null default 'RUB' check (currency ~ '^[A-Z]{3}$'),
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+create unique index if not exists idx_users_email
+    on users(lower(email)) where email is not null;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+insert into users (id)
+select distinct user_id from subscriptions
+on conflict (id) do nothing;
+-- +goose StatementEnd
+
+-- Subscriptions keep their users: a user is deleted only once it has none.
+-- New rows are checked from here on, existing ones once validated.
+-- +goose StatementBegin
+do $$
+begin
+    if not exists (select 1 from pg_constraint where conname = 'subscriptions_user_id_fkey') then
+        alter table subscriptions
+            add constraint subscriptions_user_id_fkey
+            foreign key (user_id) references users(id) on delete restrict
+            not valid;
+    end if;
+end
+$$;
+-- +goose StatementEnd
+
+-- Subscriptions created between the first backfill and the constraint.
+-- +goose StatementBegin
+insert into users (id)
+select distinct user_id from subscriptions
+on conflict (id) do nothing;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+alter table subscriptions validate constraint subscriptions_user_id_fkey;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table subscriptions
+    drop constraint if exists subscriptions_user_id_fkey;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+drop table if exists users;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists services (
+    id uuid primary key default uuid_generate_v4(),
+    name text not null,
+    -- name_key is the name lowercased with whitespace collapsed, the form
+    -- incoming service names are matched in.
+    name_key text not null unique,
+    category text not null default '',
+    vendor_url text not null default '',
+    default_price int not null default 0 check (default_price >= 0),
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+create table if not exists service_aliases (
+    service_id uuid not null references services(id) on delete cascade,
+    pattern text not null,
+    match text not null check (match in ('exact', 'prefix')),
+
+    primary key (match, pattern)
+);
+
+create index if not exists idx_service_aliases_service_id
+    on service_aliases(service_id);
+
+create index if not exists idx_subscriptions_service_name
+    on subscriptions(service_name);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists idx_subscriptions_service_name;
+drop table if exists service_aliases;
+drop table if exists services;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table subscriptions
+    add column if not exists status text not null default 'active'
+        check (status in ('trial', 'active', 'paused', 'cancelled')),
+    add column if not exists trial_end_date date null,
+    add column if not exists cancelled_at date null,
+    -- Pauses are always read and written with their subscription:
+    -- [{"start": "...", "end": "..."}], the last one possibly without end.
+    add column if not exists pauses jsonb not null default '[]';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table subscriptions
+    drop column if exists pauses,
+    drop column if exists cancelled_at,
+    drop column if exists trial_end_date,
+    drop column if exists status;
+-- +goose StatementEnd
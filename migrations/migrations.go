@@ -0,0 +1,14 @@
+// Package migrations embeds the goose SQL migrations into the binary.
+package migrations
+
+import "embed"
+
+// FS holds the Postgres migrations.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// SQLiteFS holds the migrations of the SQLite backend under sqlite/.
+//
+//go:embed sqlite/*.sql
+var SQLiteFS embed.FS
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Mirrors the Postgres schema. UUIDs are stored as text, dates as
+-- YYYY-MM-DD and timestamps as RFC 3339 in UTC, which sort as text in the
+-- same order as the values they encode.
+create table if not exists subscriptions (
+    id text primary key,
+    service_name text not null,
+    price integer not null check (price > 0),
+    user_id text not null,
+
+    start_date text not null,
+    end_date text null,
+    updated_at text not null,
+
+    check (end_date is null or end_date >= start_date)
+);
+
+create index if not exists idx_subscriptions_user_id
+    on subscriptions(user_id);
+
+create index if not exists idx_subscriptions_user_service
+    on subscriptions(user_id, service_name);
+
+create index if not exists idx_subscriptions_start_date
+    on subscriptions(start_date);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists subscriptions;
+-- +goose StatementEnd
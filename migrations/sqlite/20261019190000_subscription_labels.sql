@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table subscriptions add column category text not null default '';
+
+create index if not exists idx_subscriptions_category
+    on subscriptions(category);
+
+create table if not exists tags (
+    id integer primary key autoincrement,
+    name text not null unique
+);
+
+create table if not exists subscription_tags (
+    subscription_id text not null references subscriptions(id) on delete cascade,
+    tag_id integer not null references tags(id) on delete cascade,
+
+    primary key (subscription_id, tag_id)
+);
+
+create index if not exists idx_subscription_tags_tag_id
+    on subscription_tags(tag_id, subscription_id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists subscription_tags;
+drop table if exists tags;
+drop index if exists idx_subscriptions_category;
+alter table subscriptions drop column category;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table subscriptions add column status text not null default 'active'
+    check (status in ('trial', 'active', 'paused', 'cancelled'));
+alter table subscriptions add column trial_end_date text null;
+alter table subscriptions add column cancelled_at text null;
+alter table subscriptions add column pauses text not null default '[]';
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+alter table subscriptions drop column pauses;
+alter table subscriptions drop column cancelled_at;
+alter table subscriptions drop column trial_end_date;
+alter table subscriptions drop column status;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists users (
+    id text primary key,
+    -- name is empty for users backfilled from existing subscriptions.
+    name text not null default '',
+    email text null,
+    timezone text not null default 'UTC',
+    currency text not null default 'RUB' check (length(currency) = 3 and currency = upper(currency)),
+    created_at text not null,
+    updated_at text not null
+);
+
+create unique index if not exists idx_users_email
+    on users(lower(email)) where email is not null;
+
+insert or ignore into users (id, created_at, updated_at)
+select distinct user_id,
+    strftime('%Y-%m-%dT%H:%M:%f000Z', 'now'),
+    strftime('%Y-%m-%dT%H:%M:%f000Z', 'now')
+from subscriptions;
+
+-- SQLite cannot add a foreign key to an existing table, so triggers play
+-- the part of subscriptions_user_id_fkey in the Postgres schema.
+create trigger if not exists subscriptions_user_insert
+before insert on subscriptions
+when not exists (select 1 from users where id = new.user_id)
+begin
+    select raise(abort, 'unknown user');
+end;
+
+create trigger if not exists subscriptions_user_update
+before update of user_id on subscriptions
+when not exists (select 1 from users where id = new.user_id)
+begin
+    select raise(abort, 'unknown user');
+end;
+
+create trigger if not exists users_subscriptions_delete
+before delete on users
+when exists (select 1 from subscriptions where user_id = old.id)
+begin
+    select raise(abort, 'user has subscriptions');
+end;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+drop trigger if exists users_subscriptions_delete;
+drop trigger if exists subscriptions_user_update;
+drop trigger if exists subscriptions_user_insert;
+drop table if exists users;
+-- +goose StatementEnd
@@ -0,0 +1,232 @@
+// Package client is a typed Go client for the subscriptions REST API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout     = 10 * time.Second
+	defaultMaxRetries  = 2
+	defaultBackoff     = 200 * time.Millisecond
+	defaultMaxBackoff  = 5 * time.Second
+	defaultPageSize    = 100
+	maxPageSize        = 1000 // the server rejects larger limits
+	maxErrorBodyLength = 4096
+)
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	headers    http.Header
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	pageSize   int
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default http.Client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithBearerToken sends "Authorization: Bearer <token>" with every request.
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithHeader adds a header to every request, e.g. an API key.
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.headers.Set(key, value) }
+}
+
+// WithRetries configures how often a failed idempotent request is retried
+// and the initial backoff, which doubles on every attempt up to max.
+func WithRetries(maxRetries int, backoff, max time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+		c.maxBackoff = max
+	}
+}
+
+// WithPageSize sets how many items ListAll requests per page. Values up
+// to zero keep the default; values above the server's limit of 1000 are
+// clamped to it.
+func WithPageSize(n int) Option {
+	return func(c *Client) {
+		switch {
+		case n <= 0:
+			c.pageSize = defaultPageSize
+		case n > maxPageSize:
+			c.pageSize = maxPageSize
+		default:
+			c.pageSize = n
+		}
+	}
+}
+
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("base url %q must be absolute", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		headers:    make(http.Header),
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+		maxBackoff: defaultMaxBackoff,
+		pageSize:   defaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// do sends a request and decodes a JSON response into out when it is not
+// nil. GET, PUT and DELETE are retried on transport errors, 429 and 5xx;
+// POST is never retried because create is not idempotent.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	u := c.baseURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	attempts := 1
+	if method != http.MethodPost {
+		attempts += c.maxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.backoffFor(attempt, lastErr)); err != nil {
+				return err
+			}
+		}
+
+		retry, err := c.send(ctx, method, u.String(), payload, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) send(ctx context.Context, method, target string, payload []byte, out any) (bool, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return false, fmt.Errorf("build request: %w", err)
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return true, fmt.Errorf("%s %s: %w", method, target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := decodeError(resp)
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return retry, apiErr
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("decode response: %w", err)
+	}
+	return false, nil
+}
+
+// backoffFor returns the wait before a retry. A Retry-After sent by the
+// server replaces the exponential backoff but is still capped.
+func (c *Client) backoffFor(attempt int, lastErr error) time.Duration {
+	d := c.backoff << (attempt - 1)
+	var apiErr *APIError
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+		d = apiErr.RetryAfter
+	}
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	return d
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func decodeError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		apiErr.RetryAfter = time.Duration(secs) * time.Second
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+
+	var body struct {
+		Error  string       `json:"error"`
+		Fields []FieldError `json:"fields"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil && body.Error != "" {
+		apiErr.Message = body.Error
+		apiErr.Fields = body.Fields
+	} else {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	return apiErr
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"restservice/internal/entity"
+	httpa "restservice/internal/http"
+	"restservice/internal/repo/memory"
+	"restservice/internal/usecase/subscription"
+)
+
+// newTestServer serves a fresh in-memory repository that knows the users
+// with the given ids.
+func newTestServer(t *testing.T, wrap func(http.Handler) http.Handler, userIDs ...uuid.UUID) *httptest.Server {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := memory.NewSubscriptionRepo()
+	for _, id := range userIDs {
+		repo.AddUsers(entity.User{ID: id})
+	}
+	handler := httpa.NewHandler(subscription.NewService(repo, logger), logger)
+
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	var h http.Handler = mux
+	if wrap != nil {
+		h = wrap(h)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{WithRetries(2, time.Millisecond, 5*time.Millisecond)}, opts...)
+	c, err := New(srv.URL, opts...)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return c
+}
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestClientCRUD(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	c := newTestClient(t, newTestServer(t, nil, userID))
+
+	end := month(2025, time.September)
+	created, err := c.Create(ctx, SubscriptionInput{
+		ServiceName: "Yandex Plus",
+		Price:       400,
+		UserID:      userID,
+		StartDate:   month(2025, time.July),
+		EndDate:     &end,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.ID == uuid.Nil || created.UserID != userID || !created.EndDate.Equal(end) {
+		t.Fatalf("unexpected created subscription: %+v", created)
+	}
+
+	got, err := c.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.ServiceName != "Yandex Plus" || !got.StartDate.Equal(month(2025, time.July)) {
+		t.Fatalf("unexpected subscription: %+v", got)
+	}
+
+	updated, err := c.Update(ctx, created.ID, SubscriptionInput{
+		ServiceName: "Yandex Plus",
+		Price:       500,
+		UserID:      userID,
+		StartDate:   month(2025, time.July),
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Price != 500 || updated.EndDate != nil {
+		t.Fatalf("unexpected updated subscription: %+v", updated)
+	}
+
+	total, err := c.Summary(ctx, SummaryOptions{
+		UserID:    &userID,
+		StartDate: month(2025, time.July),
+		EndDate:   month(2025, time.August),
+	})
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if total != 2*500 {
+		t.Fatalf("unexpected total: %d", total)
+	}
+
+	if err := c.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := c.Get(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := c.Delete(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+func TestClientValidationError(t *testing.T) {
+	c := newTestClient(t, newTestServer(t, nil))
+
+	_, err := c.Create(context.Background(), SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       0,
+		UserID:      uuid.New(),
+		StartDate:   month(2025, time.July),
+	})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 APIError, got %v", err)
+	}
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "price" {
+		t.Fatalf("unexpected field errors: %+v", apiErr.Fields)
+	}
+}
+
+func TestClientListAllPaginates(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	var requests atomic.Int32
+	srv := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				requests.Add(1)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, userID)
+	c := newTestClient(t, srv, WithPageSize(2))
+
+	for i := 0; i < 5; i++ {
+		if _, err := c.Create(ctx, SubscriptionInput{
+			ServiceName: "Spotify",
+			Price:       100 + i,
+			UserID:      userID,
+			StartDate:   month(2025, time.Month(i+1)),
+		}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	var seen []Subscription
+	for sub, err := range c.ListAll(ctx, ListOptions{UserID: &userID}) {
+		if err != nil {
+			t.Fatalf("list all: %v", err)
+		}
+		seen = append(seen, sub)
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("expected 5 subscriptions, got %d", len(seen))
+	}
+	if !seen[0].StartDate.Equal(month(2025, time.May)) {
+		t.Fatalf("unexpected order, first start date %s", seen[0].StartDate)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Fatalf("expected 3 page requests, got %d", got)
+	}
+}
+
+func TestWithPageSizeStaysInServerLimits(t *testing.T) {
+	for n, want := range map[int]int{0: defaultPageSize, -1: defaultPageSize, 50: 50, 5000: maxPageSize} {
+		c, err := New("http://example.com", WithPageSize(n))
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		if c.pageSize != want {
+			t.Errorf("WithPageSize(%d): page size %d, want %d", n, c.pageSize, want)
+		}
+	}
+}
+
+func TestClientRetriesAndAuth(t *testing.T) {
+	var failures atomic.Int32
+	failures.Store(2)
+	srv := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if failures.Add(-1) >= 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	c := newTestClient(t, srv, WithBearerToken("secret"))
+	if _, err := c.List(context.Background(), ListOptions{}); err != nil {
+		t.Fatalf("list after retries: %v", err)
+	}
+
+	unauthorized := newTestClient(t, srv)
+	if _, err := unauthorized.List(context.Background(), ListOptions{}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrValidation       = errors.New("validation error")
+	ErrBadRequest       = errors.New("bad request")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrServer           = errors.New("server error")
+)
+
+// APIError is returned for every non-2xx response. It unwraps to one of the
+// sentinel errors above, so callers can use errors.Is(err, ErrNotFound).
+type APIError struct {
+	StatusCode int
+	Message    string
+	// Fields lists per-field validation failures when the server reports
+	// them.
+	Fields []FieldError
+	// RetryAfter is the wait the server asked for with Retry-After, if
+	// any.
+	RetryAfter time.Duration
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusBadRequest && e.Message == "validation error":
+		return ErrValidation
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusMethodNotAllowed:
+		return ErrMethodNotAllowed
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	subscriptionsPath = "/api/subscriptions"
+	summaryPath       = "/api/subscriptions/summary"
+	monthYear         = "01-2006"
+)
+
+// Subscription is a subscription as returned by the API. Dates are the
+// first day of the month in UTC.
+type Subscription struct {
+	ID          uuid.UUID
+	ServiceName string
+	Price       int
+	UserID      uuid.UUID
+	StartDate   time.Time
+	EndDate     *time.Time
+}
+
+// SubscriptionInput is the body of create and update requests.
+type SubscriptionInput struct {
+	ServiceName string
+	Price       int
+	UserID      uuid.UUID
+	StartDate   time.Time
+	EndDate     *time.Time
+}
+
+type ListOptions struct {
+	UserID      *uuid.UUID
+	ServiceName string
+	Limit       int
+	Offset      int
+}
+
+type SummaryOptions struct {
+	UserID      *uuid.UUID
+	ServiceName string
+	StartDate   time.Time
+	EndDate     time.Time
+}
+
+type subscriptionWire struct {
+	ID          string  `json:"id,omitempty"`
+	ServiceName string  `json:"service_name"`
+	Price       int     `json:"price"`
+	UserID      string  `json:"user_id"`
+	StartDate   string  `json:"start_date"`
+	EndDate     *string `json:"end_date,omitempty"`
+}
+
+type summaryWire struct {
+	Total int `json:"total"`
+}
+
+func (in SubscriptionInput) wire() subscriptionWire {
+	w := subscriptionWire{
+		ServiceName: in.ServiceName,
+		Price:       in.Price,
+		UserID:      in.UserID.String(),
+		StartDate:   in.StartDate.UTC().Format(monthYear),
+	}
+	if in.EndDate != nil {
+		end := in.EndDate.UTC().Format(monthYear)
+		w.EndDate = &end
+	}
+	return w
+}
+
+func (w subscriptionWire) subscription() (Subscription, error) {
+	id, err := uuid.Parse(w.ID)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("decode id: %w", err)
+	}
+	userID, err := uuid.Parse(w.UserID)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("decode user_id: %w", err)
+	}
+	start, err := time.Parse(monthYear, w.StartDate)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("decode start_date: %w", err)
+	}
+
+	sub := Subscription{
+		ID:          id,
+		ServiceName: w.ServiceName,
+		Price:       w.Price,
+		UserID:      userID,
+		StartDate:   start,
+	}
+	if w.EndDate != nil {
+		end, err := time.Parse(monthYear, *w.EndDate)
+		if err != nil {
+			return Subscription{}, fmt.Errorf("decode end_date: %w", err)
+		}
+		sub.EndDate = &end
+	}
+	return sub, nil
+}
+
+func (c *Client) Create(ctx context.Context, in SubscriptionInput) (Subscription, error) {
+	var out subscriptionWire
+	if err := c.do(ctx, http.MethodPost, subscriptionsPath, nil, in.wire(), &out); err != nil {
+		return Subscription{}, err
+	}
+	return out.subscription()
+}
+
+func (c *Client) Get(ctx context.Context, id uuid.UUID) (Subscription, error) {
+	var out subscriptionWire
+	if err := c.do(ctx, http.MethodGet, subscriptionsPath+"/"+id.String(), nil, nil, &out); err != nil {
+		return Subscription{}, err
+	}
+	return out.subscription()
+}
+
+func (c *Client) Update(ctx context.Context, id uuid.UUID, in SubscriptionInput) (Subscription, error) {
+	var out subscriptionWire
+	if err := c.do(ctx, http.MethodPut, subscriptionsPath+"/"+id.String(), nil, in.wire(), &out); err != nil {
+		return Subscription{}, err
+	}
+	return out.subscription()
+}
+
+func (c *Client) Delete(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, subscriptionsPath+"/"+id.String(), nil, nil, nil)
+}
+
+// List returns a single page of subscriptions.
+func (c *Client) List(ctx context.Context, opts ListOptions) ([]Subscription, error) {
+	query := url.Values{}
+	if opts.UserID != nil {
+		query.Set("user_id", opts.UserID.String())
+	}
+	if opts.ServiceName != "" {
+		query.Set("service_name", opts.ServiceName)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	var out []subscriptionWire
+	if err := c.do(ctx, http.MethodGet, subscriptionsPath, query, nil, &out); err != nil {
+		return nil, err
+	}
+
+	subs := make([]Subscription, 0, len(out))
+	for _, w := range out {
+		sub, err := w.subscription()
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// ListAll iterates over every subscription matching opts, fetching pages
+// of the configured page size on demand. Limit and Offset in opts are
+// ignored. Iteration stops at the first error, which is yielded once.
+func (c *Client) ListAll(ctx context.Context, opts ListOptions) iter.Seq2[Subscription, error] {
+	return func(yield func(Subscription, error) bool) {
+		page := opts
+		page.Limit = c.pageSize
+		page.Offset = 0
+		for {
+			subs, err := c.List(ctx, page)
+			if err != nil {
+				yield(Subscription{}, err)
+				return
+			}
+			for _, sub := range subs {
+				if !yield(sub, nil) {
+					return
+				}
+			}
+			if len(subs) == 0 || len(subs) < page.Limit {
+				return
+			}
+			page.Offset += len(subs)
+		}
+	}
+}
+
+// Summary returns the total cost of subscriptions active in the period.
+// Only the month and year of the dates are sent.
+func (c *Client) Summary(ctx context.Context, opts SummaryOptions) (int, error) {
+	query := url.Values{}
+	query.Set("start_date", opts.StartDate.UTC().Format(monthYear))
+	query.Set("end_date", opts.EndDate.UTC().Format(monthYear))
+	if opts.UserID != nil {
+		query.Set("user_id", opts.UserID.String())
+	}
+	if opts.ServiceName != "" {
+		query.Set("service_name", opts.ServiceName)
+	}
+
+	var out summaryWire
+	if err := c.do(ctx, http.MethodGet, summaryPath, query, nil, &out); err != nil {
+		return 0, err
+	}
+	return out.Total, nil
+}